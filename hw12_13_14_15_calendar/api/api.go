@@ -0,0 +1,9 @@
+// Package api содержит описания API календаря: gRPC в EventService.proto и HTTP в openapi.json.
+package api
+
+import _ "embed" // для go:embed
+
+// OpenAPI - спецификация OpenAPI 3 HTTP сервера.
+//
+//go:embed openapi.json
+var OpenAPI []byte
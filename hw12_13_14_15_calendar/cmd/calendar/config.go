@@ -4,8 +4,13 @@ import (
 	"errors"
 	"fmt"
 	"strings"
+	"time"
 
 	"github.com/spf13/viper"
+
+	"github.com/anfilat/otus-go/hw12_13_14_15_calendar/internal/logger"
+	"github.com/anfilat/otus-go/hw12_13_14_15_calendar/internal/storage/initstorage"
+	"github.com/anfilat/otus-go/hw12_13_14_15_calendar/internal/tracing"
 )
 
 func newConfig(configFile string) (Config, error) {
@@ -39,18 +44,31 @@ func configure(v *viper.Viper) {
 	v.AutomaticEnv()
 
 	v.SetDefault("logger.level", "INFO")
+	v.SetDefault("logger.format", logger.FormatText)
 
 	v.SetDefault("server.host", "127.0.0.1")
 	v.SetDefault("server.httpPort", "8080")
 	v.SetDefault("server.grpcPort", "8081")
+	v.SetDefault("server.gatewayPort", "8082")
+	v.SetDefault("server.shutdownDelay", "5s")
 
 	v.SetDefault("database.inmem", true)
+	v.SetDefault("database.driver", initstorage.DriverPostgres)
+	v.SetDefault("database.migrate", false)
+
+	v.SetDefault("retention.age", 365*24*time.Hour)
+	v.SetDefault("retention.interval", time.Hour)
+	v.SetDefault("retention.dryRun", false)
+
+	v.SetDefault("tracing.exporter", tracing.ExporterNone)
 }
 
 type Config struct {
-	Logger   LoggerConf
-	Server   ServerConf
-	Database DatabaseConf
+	Logger    LoggerConf
+	Server    ServerConf
+	Database  DatabaseConf
+	Retention RetentionConf
+	Tracing   TracingConf
 }
 
 func (c Config) Validate() error {
@@ -62,18 +80,47 @@ func (c Config) Validate() error {
 		return err
 	}
 
+	if err := c.Retention.Validate(); err != nil {
+		return err
+	}
+
+	if err := c.Tracing.Validate(); err != nil {
+		return err
+	}
+
 	return nil
 }
 
 type LoggerConf struct {
 	Level string
-	File  string
+	// text или json
+	Format string
+	File   string
+	// ротация файла лога: размер в мегабайтах, возраст в днях, число старых файлов
+	MaxSize    int
+	MaxAge     int
+	MaxBackups int
+}
+
+func (c LoggerConf) Options() logger.Options {
+	return logger.Options{
+		Level:      c.Level,
+		Format:     c.Format,
+		File:       c.File,
+		MaxSize:    c.MaxSize,
+		MaxAge:     c.MaxAge,
+		MaxBackups: c.MaxBackups,
+	}
 }
 
 type ServerConf struct {
 	Host     string
 	HTTPPort string
 	GrpcPort string
+	// порт REST/JSON шлюза к gRPC сервису
+	GatewayPort string
+	// сколько серверы еще принимают запросы после перехода в состояние "не готов"
+	ShutdownDelay time.Duration
 }
 
 func (c ServerConf) Validate() error {
@@ -89,18 +136,76 @@ func (c ServerConf) Validate() error {
 		return errors.New("grpc app server port is required")
 	}
 
+	if c.GatewayPort == "" {
+		return errors.New("gateway app server port is required")
+	}
+
+	if c.ShutdownDelay < 0 {
+		return errors.New("server shutdown delay must not be negative")
+	}
+
 	return nil
 }
 
 type DatabaseConf struct {
-	Inmem   bool
+	Inmem bool
+	// postgres, sqlite или wal
+	Driver string
+	// строка подключения к PostgreSQL, путь к файлу базы SQLite
+	// или каталог журнала wal с параметрами, например "data?fsync=interval"
 	Connect string
+	// применять непримененные миграции при старте
+	Migrate bool
 }
 
 func (c DatabaseConf) Validate() error {
 	if !c.Inmem && c.Connect == "" {
 		return errors.New("database connect is required")
 	}
+	switch c.Driver {
+	case initstorage.DriverPostgres, initstorage.DriverSQLite, initstorage.DriverWAL:
+	default:
+		return fmt.Errorf("unknown database driver %q", c.Driver)
+	}
+
+	return nil
+}
+
+type RetentionConf struct {
+	Age      time.Duration
+	Interval time.Duration
+	DryRun   bool
+}
+
+func (c RetentionConf) Validate() error {
+	if c.Age <= 0 {
+		return errors.New("retention age must be positive")
+	}
+
+	if c.Interval <= 0 {
+		return errors.New("retention interval must be positive")
+	}
+
+	return nil
+}
+
+type TracingConf struct {
+	// none, stdout или file
+	Exporter string
+	// файл для экспортера file
+	File string
+}
+
+func (c TracingConf) Validate() error {
+	switch c.Exporter {
+	case tracing.ExporterNone, tracing.ExporterStdout:
+	case tracing.ExporterFile:
+		if c.File == "" {
+			return errors.New("tracing file is required")
+		}
+	default:
+		return fmt.Errorf("%w: %q", tracing.ErrUnknownExporter, c.Exporter)
+	}
 
 	return nil
 }
@@ -9,13 +9,18 @@ import (
 	"sync"
 	"syscall"
 	"time"
+	_ "time/tzdata" // в образе alpine нет базы часовых поясов
 
 	"github.com/anfilat/otus-go/hw12_13_14_15_calendar/internal/app"
+	"github.com/anfilat/otus-go/hw12_13_14_15_calendar/internal/health"
 	"github.com/anfilat/otus-go/hw12_13_14_15_calendar/internal/logger"
+	"github.com/anfilat/otus-go/hw12_13_14_15_calendar/internal/scheduler"
+	"github.com/anfilat/otus-go/hw12_13_14_15_calendar/internal/server/gatewayserver"
 	"github.com/anfilat/otus-go/hw12_13_14_15_calendar/internal/server/grpcserver"
 	"github.com/anfilat/otus-go/hw12_13_14_15_calendar/internal/server/httpserver"
 	"github.com/anfilat/otus-go/hw12_13_14_15_calendar/internal/storage"
 	"github.com/anfilat/otus-go/hw12_13_14_15_calendar/internal/storage/initstorage"
+	"github.com/anfilat/otus-go/hw12_13_14_15_calendar/internal/tracing"
 )
 
 var configFile string
@@ -41,21 +46,52 @@ func main() {
 		log.Fatal(err)
 	}
 
-	logg, err := logger.New(config.Logger.Level, nil, config.Logger.File)
+	if isMigrateCommand() {
+		if err := runMigrate(mainCtx, config.Database, flag.Arg(1), os.Stdout); err != nil {
+			log.Fatal(err)
+		}
+		os.Exit(0)
+	}
+
+	logg, err := logger.New(config.Logger.Options(), nil)
 	if err != nil {
 		log.Fatal(err)
 	}
 
 	logg.Info("starting calendar")
 
-	db, err := initstorage.New(mainCtx, config.Database.Inmem, config.Database.Connect)
+	shutdownTracing, err := tracing.Init(config.Tracing.Exporter, config.Tracing.File, "calendar")
+	if err != nil {
+		logg.Fatal(err)
+	}
+
+	if !config.Database.Inmem && config.Database.Migrate {
+		err := migrateOnStart(mainCtx, logg, config.Database)
+		if err != nil {
+			logg.Fatal(err)
+		}
+	}
+
+	db, err := initstorage.New(mainCtx, config.Database.Inmem, config.Database.Driver, config.Database.Connect)
 	if err != nil {
 		logg.Fatal(err)
 	}
 
 	calendar := app.New(logg, db)
+	calendarHealth := health.New(health.Check{Name: "storage", Check: db.Ping})
+
+	cleaner := scheduler.NewCleaner(logg, calendar, scheduler.Retention{
+		Age:      config.Retention.Age,
+		Interval: config.Retention.Interval,
+		DryRun:   config.Retention.DryRun,
+	})
+	cleanerDone := make(chan struct{})
+	go func() {
+		defer close(cleanerDone)
+		cleaner.Run(mainCtx)
+	}()
 
-	httpServer := httpserver.NewServer(calendar, logg)
+	httpServer := httpserver.NewServer(calendar, calendarHealth, logg)
 	go func() {
 		err := httpServer.Start(config.Server.Host + ":" + config.Server.HTTPPort)
 		if err != nil {
@@ -64,7 +100,7 @@ func main() {
 		}
 	}()
 
-	grpcServer := grpcserver.NewServer(calendar, logg)
+	grpcServer := grpcserver.NewServer(calendar, calendarHealth, logg)
 	go func() {
 		err := grpcServer.Start(config.Server.Host + ":" + config.Server.GrpcPort)
 		if err != nil {
@@ -73,13 +109,33 @@ func main() {
 		}
 	}()
 
+	gatewayServer := gatewayserver.NewServer(calendar, logg)
+	go func() {
+		err := gatewayServer.Start(config.Server.Host + ":" + config.Server.GatewayPort)
+		if err != nil {
+			logg.Error(err)
+			cancel()
+		}
+	}()
+
 	logg.Info("calendar is running...")
 
 	<-mainCtx.Done()
 
 	logg.Info("stopping calendar...")
 	cancel()
-	shutDown(logg, httpServer, grpcServer, db)
+	// очистка могла начаться до отмены, хранилище закрывается после ее завершения
+	<-cleanerDone
+	shutDown(
+		logg,
+		calendarHealth,
+		config.Server.ShutdownDelay,
+		httpServer,
+		grpcServer,
+		gatewayServer,
+		db,
+		shutdownTracing,
+	)
 	logg.Info("calendar is stopped")
 }
 
@@ -91,7 +147,21 @@ func watchSignals(cancel context.CancelFunc) {
 	cancel()
 }
 
-func shutDown(logg logger.Logger, httpServer httpserver.Server, grpcServer grpcserver.Server, db storage.Storage) {
+func shutDown(
+	logg logger.Logger,
+	calendarHealth health.Health,
+	delay time.Duration,
+	httpServer httpserver.Server,
+	grpcServer grpcserver.Server,
+	gatewayServer gatewayserver.Server,
+	db storage.Storage,
+	shutdownTracing func(context.Context) error,
+) {
+	// сначала /readyz и gRPC health сообщают о неготовности, и пока балансировщики
+	// это замечают, серверы продолжают обслуживать запросы
+	calendarHealth.SetNotReady()
+	time.Sleep(delay)
+
 	ctx, cancel := context.WithTimeout(context.Background(), time.Second*5)
 	defer cancel()
 
@@ -113,9 +183,22 @@ func shutDown(logg logger.Logger, httpServer httpserver.Server, grpcServer grpcs
 		}
 	}()
 
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		if err := gatewayServer.Stop(ctx); err != nil {
+			logg.Error(err)
+		}
+	}()
+
 	wg.Wait()
 
 	if err := db.Close(ctx); err != nil {
 		logg.Error(err)
 	}
+
+	// после остановки серверов и хранилища, чтобы выгрузить их последние спаны
+	if err := shutdownTracing(ctx); err != nil {
+		logg.Error(err)
+	}
 }
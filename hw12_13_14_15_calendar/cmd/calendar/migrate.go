@@ -0,0 +1,113 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"io/fs"
+	"text/tabwriter"
+	"time"
+
+	// init db drivers.
+	_ "github.com/jackc/pgx/v4/stdlib"
+	_ "modernc.org/sqlite"
+
+	"github.com/anfilat/otus-go/hw12_13_14_15_calendar/internal/logger"
+	"github.com/anfilat/otus-go/hw12_13_14_15_calendar/internal/migrator"
+	"github.com/anfilat/otus-go/hw12_13_14_15_calendar/internal/storage/initstorage"
+	"github.com/anfilat/otus-go/hw12_13_14_15_calendar/migrations"
+)
+
+func isMigrateCommand() bool {
+	return flag.Arg(0) == "migrate"
+}
+
+func newMigrator(conf DatabaseConf) (migrator.Migrator, io.Closer, error) {
+	if conf.Inmem {
+		return nil, nil, errors.New("migrations are not used with database.inmem = true")
+	}
+	if conf.Driver == initstorage.DriverWAL {
+		return nil, nil, errors.New("migrations are not used with database.driver = wal")
+	}
+
+	var fsys fs.FS = migrations.FS
+	driverName, dialect := "pgx", migrator.Postgres
+	if conf.Driver == initstorage.DriverSQLite {
+		driverName, dialect, fsys = "sqlite", migrator.SQLite, migrations.SQLite
+	}
+	db, err := sql.Open(driverName, conf.Connect)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to connect to database: %w", err)
+	}
+	m, err := migrator.New(db, dialect, fsys)
+	if err != nil {
+		db.Close()
+		return nil, nil, err
+	}
+	return m, db, nil
+}
+
+// runMigrate выполняет команду migrate up|down|status.
+func runMigrate(ctx context.Context, conf DatabaseConf, command string, w io.Writer) error {
+	m, db, err := newMigrator(conf)
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	switch command {
+	case "up":
+		applied, err := m.Up(ctx)
+		for _, migration := range applied {
+			fmt.Fprintf(w, "applied %s\n", migration.Name)
+		}
+		if err != nil {
+			return err
+		}
+		if len(applied) == 0 {
+			fmt.Fprintln(w, "no migrations to apply")
+		}
+	case "down":
+		migration, err := m.Down(ctx)
+		if err != nil {
+			return err
+		}
+		fmt.Fprintf(w, "rolled back %s\n", migration.Name)
+	case "status":
+		statuses, err := m.Status(ctx)
+		if err != nil {
+			return err
+		}
+		tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(tw, "Applied At\tMigration")
+		for _, status := range statuses {
+			appliedAt := "Pending"
+			if status.Applied {
+				appliedAt = status.AppliedAt.Format(time.RFC3339)
+			}
+			fmt.Fprintf(tw, "%s\t%s\n", appliedAt, status.Name)
+		}
+		return tw.Flush()
+	default:
+		return fmt.Errorf("unknown migrate command %q, expected up, down or status", command)
+	}
+	return nil
+}
+
+// migrateOnStart применяет непримененные миграции при старте сервиса.
+func migrateOnStart(ctx context.Context, logg logger.Logger, conf DatabaseConf) error {
+	m, db, err := newMigrator(conf)
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	applied, err := m.Up(ctx)
+	for _, migration := range applied {
+		logg.Info("applied migration ", migration.Name)
+	}
+	return err
+}
@@ -0,0 +1,157 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/spf13/viper"
+
+	"github.com/anfilat/otus-go/hw12_13_14_15_calendar/internal/logger"
+	"github.com/anfilat/otus-go/hw12_13_14_15_calendar/internal/storage/initstorage"
+)
+
+func newConfig(configFile string) (Config, error) {
+	config := Config{}
+
+	v := viper.New()
+
+	configure(v)
+
+	if configFile != "" {
+		v.SetConfigFile(configFile)
+		err := v.ReadInConfig()
+		if err != nil {
+			return config, fmt.Errorf("failed to read configuration: %w", err)
+		}
+	}
+
+	if err := v.Unmarshal(&config); err != nil {
+		return config, fmt.Errorf("failed to unmarshal configuration: %w", err)
+	}
+
+	if err := config.Validate(); err != nil {
+		return config, fmt.Errorf("failed to validate configuration: %w", err)
+	}
+
+	return config, nil
+}
+
+func configure(v *viper.Viper) {
+	v.SetEnvKeyReplacer(strings.NewReplacer(".", "_", "-", "_"))
+	v.AutomaticEnv()
+
+	v.SetDefault("logger.level", "INFO")
+	v.SetDefault("logger.format", logger.FormatText)
+
+	v.SetDefault("database.inmem", true)
+	v.SetDefault("database.driver", initstorage.DriverPostgres)
+
+	// очередь в памяти процесса не видна отправщику
+	v.SetDefault("queue.inmem", false)
+	v.SetDefault("queue.connect", "./queue")
+
+	v.SetDefault("scheduler.interval", time.Minute)
+	v.SetDefault("scheduler.stateFile", "./scheduler_state.json")
+}
+
+type Config struct {
+	Logger    LoggerConf
+	Database  DatabaseConf
+	Queue     QueueConf
+	Scheduler SchedulerConf
+}
+
+func (c Config) Validate() error {
+	if err := c.Database.Validate(); err != nil {
+		return err
+	}
+
+	if err := c.Queue.Validate(); err != nil {
+		return err
+	}
+
+	if err := c.Scheduler.Validate(); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+type LoggerConf struct {
+	Level string
+	// text или json
+	Format string
+	File   string
+	// ротация файла лога: размер в мегабайтах, возраст в днях, число старых файлов
+	MaxSize    int
+	MaxAge     int
+	MaxBackups int
+}
+
+func (c LoggerConf) Options() logger.Options {
+	return logger.Options{
+		Level:      c.Level,
+		Format:     c.Format,
+		File:       c.File,
+		MaxSize:    c.MaxSize,
+		MaxAge:     c.MaxAge,
+		MaxBackups: c.MaxBackups,
+	}
+}
+
+type DatabaseConf struct {
+	Inmem bool
+	// postgres или sqlite. Хранилище wal принадлежит процессу календаря,
+	// планировщик не может работать с ним одновременно.
+	Driver string
+	// строка подключения к PostgreSQL или путь к файлу базы SQLite
+	Connect string
+}
+
+func (c DatabaseConf) Validate() error {
+	if !c.Inmem && c.Connect == "" {
+		return errors.New("database connect is required")
+	}
+	switch c.Driver {
+	case initstorage.DriverPostgres, initstorage.DriverSQLite:
+	case initstorage.DriverWAL:
+		return errors.New("database driver wal cannot be shared with the calendar, use postgres or sqlite")
+	default:
+		return fmt.Errorf("unknown database driver %q", c.Driver)
+	}
+
+	return nil
+}
+
+type QueueConf struct {
+	Inmem   bool
+	Connect string
+}
+
+func (c QueueConf) Validate() error {
+	if !c.Inmem && c.Connect == "" {
+		return errors.New("queue connect is required")
+	}
+
+	return nil
+}
+
+type SchedulerConf struct {
+	Interval time.Duration
+	// файл с прогрессом отправки уведомлений, чтобы не повторять их после перезапуска
+	StateFile string
+}
+
+func (c SchedulerConf) Validate() error {
+	if c.Interval <= 0 {
+		return errors.New("scheduler interval must be positive")
+	}
+
+	if c.StateFile == "" {
+		return errors.New("scheduler state file is required")
+	}
+
+	return nil
+}
@@ -0,0 +1,91 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"log"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/anfilat/otus-go/hw12_13_14_15_calendar/internal/logger"
+	"github.com/anfilat/otus-go/hw12_13_14_15_calendar/internal/queue"
+	"github.com/anfilat/otus-go/hw12_13_14_15_calendar/internal/queue/initqueue"
+	"github.com/anfilat/otus-go/hw12_13_14_15_calendar/internal/scheduler"
+	"github.com/anfilat/otus-go/hw12_13_14_15_calendar/internal/storage"
+	"github.com/anfilat/otus-go/hw12_13_14_15_calendar/internal/storage/initstorage"
+)
+
+var configFile string
+
+func init() {
+	flag.StringVar(&configFile, "config", "", "Path to configuration file")
+}
+
+func main() {
+	flag.Parse()
+
+	if isVersionCommand() {
+		printVersion()
+		os.Exit(0)
+	}
+
+	mainCtx, cancel := context.WithCancel(context.Background())
+
+	go watchSignals(cancel)
+
+	config, err := newConfig(configFile)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	logg, err := logger.New(config.Logger.Options(), nil)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	logg.Info("starting calendar scheduler")
+
+	db, err := initstorage.New(mainCtx, config.Database.Inmem, config.Database.Driver, config.Database.Connect)
+	if err != nil {
+		logg.Fatal(err)
+	}
+
+	q, err := initqueue.New(mainCtx, config.Queue.Inmem, config.Queue.Connect)
+	if err != nil {
+		logg.Fatal(err)
+	}
+
+	state := scheduler.NewFileState(config.Scheduler.StateFile)
+	sched := scheduler.New(logg, db, q, state, config.Scheduler.Interval)
+
+	logg.Info("calendar scheduler is running...")
+
+	sched.Run(mainCtx)
+
+	logg.Info("stopping calendar scheduler...")
+	shutDown(logg, q, db)
+	logg.Info("calendar scheduler is stopped")
+}
+
+func watchSignals(cancel context.CancelFunc) {
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, syscall.SIGINT, syscall.SIGTERM)
+
+	<-signals
+	cancel()
+}
+
+func shutDown(logg logger.Logger, q queue.Queue, db storage.Storage) {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second*5)
+	defer cancel()
+
+	if err := q.Close(ctx); err != nil {
+		logg.Error(err)
+	}
+
+	if err := db.Close(ctx); err != nil {
+		logg.Error(err)
+	}
+}
@@ -0,0 +1,154 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/spf13/viper"
+
+	"github.com/anfilat/otus-go/hw12_13_14_15_calendar/internal/logger"
+	"github.com/anfilat/otus-go/hw12_13_14_15_calendar/internal/sink"
+)
+
+func newConfig(configFile string) (Config, error) {
+	config := Config{}
+
+	v := viper.New()
+
+	configure(v)
+
+	if configFile != "" {
+		v.SetConfigFile(configFile)
+		err := v.ReadInConfig()
+		if err != nil {
+			return config, fmt.Errorf("failed to read configuration: %w", err)
+		}
+	}
+
+	if err := v.Unmarshal(&config); err != nil {
+		return config, fmt.Errorf("failed to unmarshal configuration: %w", err)
+	}
+
+	if err := config.Validate(); err != nil {
+		return config, fmt.Errorf("failed to validate configuration: %w", err)
+	}
+
+	return config, nil
+}
+
+func configure(v *viper.Viper) {
+	v.SetEnvKeyReplacer(strings.NewReplacer(".", "_", "-", "_"))
+	v.AutomaticEnv()
+
+	v.SetDefault("logger.level", "INFO")
+	v.SetDefault("logger.format", logger.FormatText)
+
+	// очередь в памяти процесса не видна планировщику
+	v.SetDefault("queue.inmem", false)
+	v.SetDefault("queue.connect", "./queue")
+
+	v.SetDefault("sink.kind", sink.KindLog)
+	v.SetDefault("sink.timeout", 10*time.Second)
+
+	v.SetDefault("sender.attempts", 5)
+	v.SetDefault("sender.minBackoff", time.Second)
+	v.SetDefault("sender.maxBackoff", time.Minute)
+}
+
+type Config struct {
+	Logger LoggerConf
+	Queue  QueueConf
+	Sink   SinkConf
+	Sender SenderConf
+}
+
+func (c Config) Validate() error {
+	if err := c.Queue.Validate(); err != nil {
+		return err
+	}
+
+	if err := c.Sink.Validate(); err != nil {
+		return err
+	}
+
+	if err := c.Sender.Validate(); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+type LoggerConf struct {
+	Level string
+	// text или json
+	Format string
+	File   string
+	// ротация файла лога: размер в мегабайтах, возраст в днях, число старых файлов
+	MaxSize    int
+	MaxAge     int
+	MaxBackups int
+}
+
+func (c LoggerConf) Options() logger.Options {
+	return logger.Options{
+		Level:      c.Level,
+		Format:     c.Format,
+		File:       c.File,
+		MaxSize:    c.MaxSize,
+		MaxAge:     c.MaxAge,
+		MaxBackups: c.MaxBackups,
+	}
+}
+
+type QueueConf struct {
+	Inmem   bool
+	Connect string
+}
+
+func (c QueueConf) Validate() error {
+	if !c.Inmem && c.Connect == "" {
+		return errors.New("queue connect is required")
+	}
+
+	return nil
+}
+
+type SinkConf struct {
+	Kind    string
+	Target  string
+	Timeout time.Duration
+}
+
+func (c SinkConf) Validate() error {
+	switch c.Kind {
+	case sink.KindLog, sink.KindStdout:
+	case sink.KindWebhook, sink.KindFile:
+		if c.Target == "" {
+			return errors.New("sink target is required")
+		}
+	default:
+		return fmt.Errorf("unknown sink kind %q", c.Kind)
+	}
+
+	return nil
+}
+
+type SenderConf struct {
+	Attempts   int
+	MinBackoff time.Duration
+	MaxBackoff time.Duration
+}
+
+func (c SenderConf) Validate() error {
+	if c.Attempts < 1 {
+		return errors.New("sender attempts must be positive")
+	}
+
+	if c.MinBackoff <= 0 || c.MaxBackoff < c.MinBackoff {
+		return errors.New("sender backoff is invalid")
+	}
+
+	return nil
+}
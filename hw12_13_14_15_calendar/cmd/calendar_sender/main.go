@@ -0,0 +1,96 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"log"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/anfilat/otus-go/hw12_13_14_15_calendar/internal/logger"
+	"github.com/anfilat/otus-go/hw12_13_14_15_calendar/internal/queue"
+	"github.com/anfilat/otus-go/hw12_13_14_15_calendar/internal/queue/initqueue"
+	"github.com/anfilat/otus-go/hw12_13_14_15_calendar/internal/sender"
+	"github.com/anfilat/otus-go/hw12_13_14_15_calendar/internal/sink"
+)
+
+var configFile string
+
+func init() {
+	flag.StringVar(&configFile, "config", "", "Path to configuration file")
+}
+
+func main() {
+	flag.Parse()
+
+	if isVersionCommand() {
+		printVersion()
+		os.Exit(0)
+	}
+
+	mainCtx, cancel := context.WithCancel(context.Background())
+
+	go watchSignals(cancel)
+
+	config, err := newConfig(configFile)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	logg, err := logger.New(config.Logger.Options(), nil)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	logg.Info("starting calendar sender")
+
+	q, err := initqueue.New(mainCtx, config.Queue.Inmem, config.Queue.Connect)
+	if err != nil {
+		logg.Fatal(err)
+	}
+
+	out, err := sink.New(config.Sink.Kind, config.Sink.Target, config.Sink.Timeout, logg)
+	if err != nil {
+		logg.Fatal(err)
+	}
+
+	send := sender.New(logg, q, out, sender.Retry{
+		Attempts:   config.Sender.Attempts,
+		MinBackoff: config.Sender.MinBackoff,
+		MaxBackoff: config.Sender.MaxBackoff,
+	})
+
+	logg.Info("calendar sender is running...")
+
+	if err := send.Run(mainCtx); err != nil {
+		logg.Error(err)
+	}
+
+	logg.Info("stopping calendar sender...")
+	cancel()
+	shutDown(logg, q, out)
+	logg.Info("calendar sender is stopped")
+}
+
+func watchSignals(cancel context.CancelFunc) {
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, syscall.SIGINT, syscall.SIGTERM)
+
+	<-signals
+	cancel()
+}
+
+func shutDown(logg logger.Logger, q queue.Queue, out sink.Sink) {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second*5)
+	defer cancel()
+
+	if err := out.Close(); err != nil {
+		logg.Error(err)
+	}
+
+	if err := q.Close(ctx); err != nil {
+		logg.Error(err)
+	}
+}
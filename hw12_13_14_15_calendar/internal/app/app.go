@@ -2,8 +2,11 @@ package app
 
 import (
 	"context"
+	"errors"
+	"io"
 	"time"
 
+	"github.com/anfilat/otus-go/hw12_13_14_15_calendar/internal/ical"
 	"github.com/anfilat/otus-go/hw12_13_14_15_calendar/internal/logger"
 	"github.com/anfilat/otus-go/hw12_13_14_15_calendar/internal/storage"
 )
@@ -11,9 +14,26 @@ import (
 type app struct {
 	logger  logger.Logger
 	storage storage.Storage
+	feed    *feed
+	// изменения и их публикация в ленту идут под блокировкой пользователя,
+	// иначе порядок изменений в ленте может разойтись с порядком записи
+	locks userLocks
 }
 
-func (a *app) Create(ctx context.Context, userID int, title, desc string, start, stop time.Time, notif *time.Duration) (id int, err error) {
+// log возвращает логгер запроса из контекста, с ID запроса и пользователя.
+func (a *app) log(ctx context.Context) logger.Logger {
+	return logger.FromContext(ctx, a.logger)
+}
+
+func (a *app) Create(
+	ctx context.Context,
+	userID int,
+	title, desc string,
+	start, stop time.Time,
+	allDay bool,
+	notif *time.Duration,
+	rec *storage.Recurrence,
+) (id int, err error) {
 	if userID == 0 {
 		err = ErrNoUserID
 		return
@@ -25,70 +45,401 @@ func (a *app) Create(ctx context.Context, userID int, title, desc string, start,
 	if start.After(stop) {
 		start, stop = stop, start
 	}
-	if time.Now().After(start) {
-		err = ErrStartInPast
-		return
+	if allDay {
+		start, stop = storage.AllDayDates(start, stop)
+		rec = allDayRecurrence(rec)
 	}
-	isBusy, err := a.storage.IsTimeBusy(ctx, userID, start, stop, 0)
-	if err != nil {
+	if startsInPast(start, allDay) {
+		err = ErrStartInPast
 		return
 	}
-	if isBusy {
-		err = ErrDateBusy
-		return
+	if rec != nil {
+		if rec, err = a.withTimeZone(ctx, userID, start, rec); err != nil {
+			return
+		}
+		if err = rec.Validate(); err != nil {
+			return
+		}
 	}
-
-	return a.storage.Create(ctx, storage.Event{
+	event := storage.Event{
 		Title:        title,
 		Start:        start,
 		Stop:         stop,
 		Description:  desc,
 		UserID:       userID,
 		Notification: notif,
-	})
+		Recurrence:   rec,
+		AllDay:       allDay,
+	}
+	defer a.locks.lock(userID)()
+
+	id, err = a.storage.CreateIfFree(ctx, event)
+	if err != nil {
+		return
+	}
+	event.ID = id
+	event.Version = storage.InitialVersion
+	a.feed.publish(ChangeCreated, event, nil)
+	a.log(ctx).WithField("event_id", id).Debug("event created")
+	return
 }
 
-func (a *app) Update(ctx context.Context, id int, change storage.Event) error {
+func (a *app) Update(ctx context.Context, userID int, id int, change storage.Event) (int, error) {
+	if userID == 0 {
+		return 0, ErrNoUserID
+	}
+	if change.Version == 0 {
+		return 0, ErrNoVersion
+	}
 	if change.Title == "" {
-		return ErrEmptyTitle
+		return 0, ErrEmptyTitle
 	}
 	if change.Start.After(change.Stop) {
 		change.Start, change.Stop = change.Stop, change.Start
 	}
-	if time.Now().After(change.Start) {
-		return ErrStartInPast
+	if change.AllDay {
+		change.Start, change.Stop = storage.AllDayDates(change.Start, change.Stop)
+		change.Recurrence = allDayRecurrence(change.Recurrence)
+	}
+	if startsInPast(change.Start, change.AllDay) {
+		return 0, ErrStartInPast
+	}
+	if change.Recurrence != nil {
+		rec, err := a.withTimeZone(ctx, userID, change.Start, change.Recurrence)
+		if err != nil {
+			return 0, err
+		}
+		if err := rec.Validate(); err != nil {
+			return 0, err
+		}
+		change.Recurrence = rec
+	}
+	defer a.locks.lock(userID)()
+
+	prev, err := a.Get(ctx, userID, id)
+	if err != nil {
+		return 0, err
+	}
+	change.UserID = userID
+	if err := a.storage.UpdateIfFree(ctx, id, change); err != nil {
+		return 0, err
+	}
+	change.ID = id
+	change.Version++
+	a.feed.publish(ChangeUpdated, change, &prev)
+	a.log(ctx).WithField("event_id", id).Debug("event updated")
+	return change.Version, nil
+}
+
+// startsInPast проверяет, что событие начинается в прошлом. Событие на весь день
+// можно создать, пока не закончился его первый день.
+func startsInPast(start time.Time, allDay bool) bool {
+	if allDay {
+		return storage.Date(time.Now()).After(start)
+	}
+	return time.Now().After(start)
+}
+
+// allDayRecurrence возвращает копию правила повторения события на весь день
+// с исключениями, приведенными к датам, как и начала повторений.
+func allDayRecurrence(rec *storage.Recurrence) *storage.Recurrence {
+	if rec == nil {
+		return nil
+	}
+	result := *rec
+	result.Exceptions = make([]time.Time, 0, len(rec.Exceptions))
+	for _, exception := range rec.Exceptions {
+		result.Exceptions = append(result.Exceptions, storage.Date(exception))
+	}
+	// даты событий на весь день считаются в UTC
+	result.TimeZone = "UTC"
+	return &result
+}
+
+// withTimeZone возвращает правило повторения с часовым поясом, если он не задан явно.
+// Именованный пояс начала события (например, из TZID при импорте) важнее пояса из настроек
+// пользователя. Пояс из настроек берется, если начало в UTC (gRPC не передает смещение)
+// или его смещение совпадает с поясом настроек, иначе правило раскрывается со смещением начала.
+func (a *app) withTimeZone(
+	ctx context.Context,
+	userID int,
+	start time.Time,
+	rec *storage.Recurrence,
+) (*storage.Recurrence, error) {
+	if rec.TimeZone != "" {
+		return rec, nil
+	}
+	result := *rec
+	name := start.Location().String()
+	if name != "" && name != "UTC" && name != "Local" {
+		result.TimeZone = name
+		return &result, nil
+	}
+
+	settings, err := a.storage.GetSettings(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if settings.TimeZone != "" {
+		location, err := loadLocation(settings.TimeZone)
+		if err != nil {
+			return nil, err
+		}
+		_, offset := start.Zone()
+		if _, settingsOffset := start.In(location).Zone(); name == "UTC" || offset == settingsOffset {
+			result.TimeZone = settings.TimeZone
+			return &result, nil
+		}
+	}
+	if name == "UTC" {
+		result.TimeZone = name
+	} else {
+		result.TimeZone = start.Format("-07:00")
+	}
+	return &result, nil
+}
+
+func (a *app) Delete(ctx context.Context, userID int, id int) error {
+	if userID == 0 {
+		return ErrNoUserID
+	}
+	defer a.locks.lock(userID)()
+
+	event, err := a.Get(ctx, userID, id)
+	if err != nil {
+		return err
+	}
+	// событие могло быть удалено после Get, тогда Delete вернет ErrNotExistsEvent
+	if err := a.storage.Delete(ctx, id); err != nil {
+		return err
+	}
+	a.feed.publish(ChangeDeleted, event, nil)
+	a.log(ctx).WithField("event_id", id).Debug("event deleted")
+	return nil
+}
+
+func (a *app) Get(ctx context.Context, userID int, id int) (storage.Event, error) {
+	if userID == 0 {
+		return storage.Event{}, ErrNoUserID
+	}
+	event, err := a.storage.Get(ctx, id)
+	if err != nil {
+		return storage.Event{}, err
+	}
+	if event.UserID != userID {
+		return storage.Event{}, ErrForbidden
 	}
-	isBusy, err := a.storage.IsTimeBusy(ctx, change.UserID, change.Start, change.Stop, id)
+	return event, nil
+}
+
+func (a *app) DeleteAll(ctx context.Context, userID int) error {
+	if userID == 0 {
+		defer a.locks.lockAll()()
+
+		events, err := a.storage.ListAll(ctx)
+		if err != nil {
+			return err
+		}
+		if err := a.storage.DeleteAll(ctx); err != nil {
+			return err
+		}
+		for _, event := range events {
+			a.feed.publish(ChangeDeleted, event, nil)
+		}
+		a.log(ctx).WithField("count", len(events)).Debug("events deleted")
+		return nil
+	}
+	defer a.locks.lock(userID)()
+
+	events, err := a.ListAll(ctx, userID)
 	if err != nil {
 		return err
 	}
-	if isBusy {
-		return ErrDateBusy
+	count, err := a.deleteEvents(ctx, events)
+	a.log(ctx).WithField("count", count).Debug("events deleted")
+	return err
+}
+
+func (a *app) ListBefore(ctx context.Context, date time.Time) ([]storage.Event, error) {
+	return a.storage.ListBefore(ctx, date)
+}
+
+// DeleteBefore удаляет события по одному, а не storage.DeleteBefore, чтобы в ленту
+// попали удаления именно тех событий, которые удалены.
+func (a *app) DeleteBefore(ctx context.Context, date time.Time) (int, error) {
+	defer a.locks.lockAll()()
+
+	events, err := a.storage.ListBefore(ctx, date)
+	if err != nil {
+		return 0, err
 	}
+	return a.deleteEvents(ctx, events)
+}
 
-	return a.storage.Update(ctx, id, change)
+// deleteEvents удаляет события и публикует их удаление, блокировки должны быть захвачены.
+// События, уже удаленные другим процессом, пропускаются.
+func (a *app) deleteEvents(ctx context.Context, events []storage.Event) (int, error) {
+	count := 0
+	for _, event := range events {
+		err := a.storage.Delete(ctx, event.ID)
+		if errors.Is(err, storage.ErrNotExistsEvent) {
+			continue
+		}
+		if err != nil {
+			return count, err
+		}
+		a.feed.publish(ChangeDeleted, event, nil)
+		count++
+	}
+	return count, nil
 }
 
-func (a *app) Delete(ctx context.Context, id int) error {
-	return a.storage.Delete(ctx, id)
+func (a *app) ListAll(ctx context.Context, userID int) ([]storage.Event, error) {
+	events, err := a.storage.ListAll(ctx)
+	if err != nil || userID == 0 {
+		return events, err
+	}
+	result := make([]storage.Event, 0, len(events))
+	for _, event := range events {
+		if event.UserID == userID {
+			result = append(result, event)
+		}
+	}
+	return result, nil
 }
 
-func (a *app) DeleteAll(ctx context.Context) error {
-	return a.storage.DeleteAll(ctx)
+func (a *app) ListDay(ctx context.Context, userID int, date time.Time, timeZone string) ([]storage.Event, error) {
+	return a.listIn(ctx, userID, date, timeZone, a.storage.ListDay)
 }
 
-func (a *app) ListAll(ctx context.Context) ([]storage.Event, error) {
-	return a.storage.ListAll(ctx)
+func (a *app) ListWeek(ctx context.Context, userID int, date time.Time, timeZone string) ([]storage.Event, error) {
+	return a.listIn(ctx, userID, date, timeZone, a.storage.ListWeek)
 }
 
-func (a *app) ListDay(ctx context.Context, date time.Time) ([]storage.Event, error) {
-	return a.storage.ListDay(ctx, date)
+func (a *app) ListMonth(ctx context.Context, userID int, date time.Time, timeZone string) ([]storage.Event, error) {
+	return a.listIn(ctx, userID, date, timeZone, a.storage.ListMonth)
 }
 
-func (a *app) ListWeek(ctx context.Context, date time.Time) ([]storage.Event, error) {
-	return a.storage.ListWeek(ctx, date)
+// listIn вызывает список хранилища с date в часовом поясе запроса, см. ListEvents.
+func (a *app) listIn(
+	ctx context.Context,
+	userID int,
+	date time.Time,
+	timeZone string,
+	list func(ctx context.Context, userID int, date time.Time) ([]storage.Event, error),
+) ([]storage.Event, error) {
+	if userID == 0 {
+		return nil, ErrNoUserID
+	}
+	if timeZone == "" {
+		settings, err := a.storage.GetSettings(ctx, userID)
+		if err != nil {
+			return nil, err
+		}
+		timeZone = settings.TimeZone
+	}
+	if timeZone != "" {
+		location, err := loadLocation(timeZone)
+		if err != nil {
+			return nil, err
+		}
+		date = date.In(location)
+	}
+	return list(ctx, userID, date)
 }
 
-func (a *app) ListMonth(ctx context.Context, date time.Time) ([]storage.Event, error) {
-	return a.storage.ListMonth(ctx, date)
+// ListRange возвращает страницу событий пользователя, пересекающихся с интервалом [from, to).
+func (a *app) ListRange(
+	ctx context.Context,
+	userID int,
+	from, to time.Time,
+	filter storage.Filter,
+) (storage.Page, error) {
+	if userID == 0 {
+		return storage.Page{}, ErrNoUserID
+	}
+	if !to.After(from) {
+		return storage.Page{}, ErrInvalidRange
+	}
+	filter.UserID = userID
+	if filter.Limit <= 0 {
+		filter.Limit = DefaultPageSize
+	}
+	if filter.Limit > MaxPageSize {
+		filter.Limit = MaxPageSize
+	}
+	return a.storage.ListRange(ctx, from, to, filter)
+}
+
+// Export выгружает в формате iCalendar события пользователя (всех пользователей при userID = 0),
+// пересекающиеся с интервалом [from, to). Нулевые from и to не ограничивают интервал.
+func (a *app) Export(ctx context.Context, w io.Writer, userID int, from, to time.Time) error {
+	events, err := a.storage.ListAll(ctx)
+	if err != nil {
+		return err
+	}
+
+	result := make([]storage.Event, 0, len(events))
+	for _, event := range events {
+		if (userID == 0 || event.UserID == userID) && isInRange(event, from, to) {
+			result = append(result, event)
+		}
+	}
+	return ical.Encode(w, result, time.Now())
+}
+
+func isInRange(event storage.Event, from, to time.Time) bool {
+	if to.IsZero() {
+		lastStop, ok := storage.LastStop(event)
+		return !ok || lastStop.After(from)
+	}
+	return storage.Overlaps(event, from, to)
+}
+
+// Import создает события пользователя из календаря в формате iCalendar.
+// Время без часового пояса считается в поясе из настроек пользователя, если он не задан - в UTC.
+// Ошибки отдельных событий, например ErrDateBusy, не прерывают импорт остальных.
+func (a *app) Import(ctx context.Context, r io.Reader, userID int) ([]ImportResult, error) {
+	if userID == 0 {
+		return nil, ErrNoUserID
+	}
+	settings, err := a.storage.GetSettings(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	location := time.UTC
+	if settings.TimeZone != "" {
+		if location, err = loadLocation(settings.TimeZone); err != nil {
+			return nil, err
+		}
+	}
+
+	entries, err := ical.Decode(r, location)
+	if err != nil {
+		return nil, err
+	}
+
+	result := make([]ImportResult, 0, len(entries))
+	for _, entry := range entries {
+		item := ImportResult{UID: entry.UID, Err: entry.Err}
+		if item.Err == nil {
+			event := entry.Event
+			item.ID, item.Err = a.Create(
+				ctx,
+				userID,
+				event.Title,
+				event.Description,
+				event.Start,
+				event.Stop,
+				event.AllDay,
+				event.Notification,
+				event.Recurrence,
+			)
+		}
+		if item.Err != nil {
+			a.log(ctx).WithField("uid", item.UID).Warn("event is not imported: ", item.Err)
+		}
+		result = append(result, item)
+	}
+	return result, nil
 }
@@ -0,0 +1,137 @@
+package app_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/suite"
+
+	"github.com/anfilat/otus-go/hw12_13_14_15_calendar/internal/app"
+	"github.com/anfilat/otus-go/hw12_13_14_15_calendar/internal/storage"
+)
+
+type AllDayEventTest struct {
+	SuiteTest
+}
+
+func (s *AllDayEventTest) NewAllDayEvent(start time.Time, days int) storage.Event {
+	event := s.NewCommonEvent()
+	event.Title = "conference"
+	event.Start = start
+	event.Stop = start.AddDate(0, 0, days)
+	event.AllDay = true
+	return event
+}
+
+func (s *AllDayEventTest) TestCreate() {
+	ctx := context.Background()
+	moscow := time.FixedZone("MSK", 3*60*60)
+	monday := firstMonday()
+	// время отбрасывается, неполный последний день включается
+	event := s.NewAllDayEvent(time.Date(monday.Year(), monday.Month(), monday.Day(), 1, 0, 0, 0, moscow), 0)
+	event.Stop = event.Start.AddDate(0, 0, 2).Add(17 * time.Hour)
+	id, err := s.AddEvent(event)
+	s.Require().NoError(err)
+
+	saved, err := s.calendar.Get(ctx, event.UserID, id)
+	s.Require().NoError(err)
+	s.Require().True(saved.AllDay)
+	s.Require().Equal(monday, saved.Start.UTC())
+	s.Require().Equal(monday.AddDate(0, 0, 3), saved.Stop.UTC())
+
+	saved.AllDay = false
+	saved.Start = monday.Add(10 * time.Hour)
+	saved.Stop = monday.Add(11 * time.Hour)
+	_, err = s.calendar.Update(ctx, event.UserID, id, saved)
+	s.Require().NoError(err)
+	saved, err = s.calendar.Get(ctx, event.UserID, id)
+	s.Require().NoError(err)
+	s.Require().False(saved.AllDay)
+}
+
+// Событие на весь день можно создать, пока не закончился его первый день.
+func (s *AllDayEventTest) TestCreateToday() {
+	_, err := s.AddEvent(s.NewAllDayEvent(time.Now(), 1))
+	s.Require().NoError(err)
+
+	_, err = s.AddEvent(s.NewAllDayEvent(time.Now().AddDate(0, 0, -1), 1))
+	s.Require().True(errors.Is(err, app.ErrStartInPast))
+}
+
+func (s *AllDayEventTest) TestListMultiDay() {
+	ctx := context.Background()
+	monday := firstMonday()
+	id, err := s.AddEvent(s.NewAllDayEvent(monday, 3))
+	s.Require().NoError(err)
+
+	tests := []struct {
+		name     string
+		date     time.Time
+		timeZone string
+		count    int
+	}{
+		{"monday", monday.Add(12 * time.Hour), "", 1},
+		{"tuesday", monday.AddDate(0, 0, 1), "", 1},
+		{"wednesday in tokyo", monday.AddDate(0, 0, 2).Add(14 * time.Hour), "Asia/Tokyo", 1},
+		{"thursday", monday.AddDate(0, 0, 3), "", 0},
+		// в UTC уже понедельник, а в Лос-Анджелесе еще воскресенье
+		{"sunday in los angeles", monday.Add(2 * time.Hour), "America/Los_Angeles", 0},
+	}
+	for _, tt := range tests {
+		list, err := s.calendar.ListDay(ctx, 1, tt.date, tt.timeZone)
+		s.Require().NoError(err, tt.name)
+		s.Require().Equal(tt.count, len(list), tt.name)
+		if tt.count > 0 {
+			s.Require().Equal(id, list[0].ID, tt.name)
+		}
+	}
+}
+
+func (s *AllDayEventTest) TestRecurringExceptions() {
+	ctx := context.Background()
+	monday := firstMonday()
+	moscow := time.FixedZone("MSK", 3*60*60)
+	event := s.NewAllDayEvent(monday, 1)
+	// исключение задано временем внутри дня и приводится к дате
+	recurrence, err := storage.NewRecurrence("FREQ=DAILY;COUNT=3", []time.Time{
+		time.Date(monday.Year(), monday.Month(), monday.Day()+1, 1, 0, 0, 0, moscow),
+	})
+	s.Require().NoError(err)
+	event.Recurrence = recurrence
+	_, err = s.AddEvent(event)
+	s.Require().NoError(err)
+
+	list, err := s.calendar.ListWeek(ctx, 1, monday, "")
+	s.Require().NoError(err)
+	s.Require().Equal(2, len(list))
+	s.Require().Equal(monday, list[0].Start.UTC())
+	s.Require().Equal(monday.AddDate(0, 0, 2), list[1].Start.UTC())
+}
+
+// События на весь день не занимают время.
+func (s *AllDayEventTest) TestNotBusy() {
+	ctx := context.Background()
+	monday := firstMonday()
+	_, err := s.AddEvent(s.NewAllDayEvent(monday, 3))
+	s.Require().NoError(err)
+
+	meeting := s.NewCommonEvent()
+	meeting.Start = monday.AddDate(0, 0, 1).Add(10 * time.Hour)
+	meeting.Stop = meeting.Start.Add(time.Hour)
+	_, err = s.AddEvent(meeting)
+	s.Require().NoError(err)
+	_, err = s.AddEvent(s.NewAllDayEvent(monday.AddDate(0, 0, 1), 1))
+	s.Require().NoError(err)
+
+	from := monday.AddDate(0, 0, 1)
+	result, err := s.calendar.FreeBusy(ctx, []int{1}, from, from.Add(24*time.Hour), time.Hour, 0)
+	s.Require().NoError(err)
+	s.Require().Equal(1, len(result.Busy[0].Busy))
+	s.Require().True(meeting.Start.Equal(result.Busy[0].Busy[0].Start))
+}
+
+func TestAllDayEventTest(t *testing.T) {
+	suite.Run(t, new(AllDayEventTest))
+}
@@ -0,0 +1,97 @@
+package app_test
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/suite"
+
+	"github.com/anfilat/otus-go/hw12_13_14_15_calendar/internal/app"
+	"github.com/anfilat/otus-go/hw12_13_14_15_calendar/internal/storage"
+)
+
+type ConcurrentTest struct {
+	SuiteTest
+}
+
+const concurrency = 20
+
+func (s *ConcurrentTest) TestCreate() {
+	base := s.NewCommonEvent()
+
+	var wg sync.WaitGroup
+	errs := make([]error, concurrency)
+	for i := 0; i < concurrency; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			// все события пересекаются с base
+			event := base
+			event.Start = base.Start.Add(time.Duration(i) * time.Minute)
+			event.Stop = event.Start.Add(time.Hour)
+			_, errs[i] = s.AddEvent(event)
+		}(i)
+	}
+	wg.Wait()
+
+	created := 0
+	for _, err := range errs {
+		if err == nil {
+			created++
+		} else {
+			s.Require().Equal(app.ErrDateBusy, err)
+		}
+	}
+	s.Require().Equal(1, created)
+	s.Require().Equal(1, len(s.GetAll()))
+}
+
+func (s *ConcurrentTest) TestUpdate() {
+	ctx := context.Background()
+	base := s.NewCommonEvent()
+
+	// события не пересекаются друг с другом, но все переносятся на время base
+	ids := make([]int, concurrency)
+	for i := range ids {
+		event := base
+		event.Start = base.Start.Add(time.Duration(i+1) * 2 * time.Hour)
+		event.Stop = event.Start.Add(time.Hour)
+		id, err := s.AddEvent(event)
+		s.Require().NoError(err)
+		ids[i] = id
+	}
+
+	var wg sync.WaitGroup
+	errs := make([]error, concurrency)
+	for i, id := range ids {
+		wg.Add(1)
+		go func(i, id int) {
+			defer wg.Done()
+			_, errs[i] = s.calendar.Update(ctx, base.UserID, id, base)
+		}(i, id)
+	}
+	wg.Wait()
+
+	updated := 0
+	for _, err := range errs {
+		if err == nil {
+			updated++
+		} else {
+			s.Require().Equal(app.ErrDateBusy, err)
+		}
+	}
+	s.Require().Equal(1, updated)
+
+	events := s.GetAll()
+	for i, event := range events {
+		for _, other := range events[i+1:] {
+			s.Require().False(storage.Overlaps(event, other.Start, other.Stop), "%d and %d overlap", event.ID, other.ID)
+		}
+	}
+}
+
+func TestConcurrentTest(t *testing.T) {
+	suite.Run(t, new(ConcurrentTest))
+}
@@ -111,7 +111,9 @@ func (s *CreateEventTest) AddEventForTime(start, stop time.Time) error {
 		event.Description,
 		start,
 		stop,
+		false,
 		event.Notification,
+		event.Recurrence,
 	)
 	return err
 }
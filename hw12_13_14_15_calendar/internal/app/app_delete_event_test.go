@@ -2,10 +2,13 @@ package app_test
 
 import (
 	"context"
+	"errors"
 	"testing"
 	"time"
 
 	"github.com/stretchr/testify/suite"
+
+	"github.com/anfilat/otus-go/hw12_13_14_15_calendar/internal/storage"
 )
 
 type DeleteEventTest struct {
@@ -25,23 +28,23 @@ func (s *DeleteEventTest) TestDeleteEvent() {
 
 	ctx := context.Background()
 	// удаление несуществующего события
-	err = s.calendar.Delete(ctx, id2+1)
-	s.Require().NoError(err)
-	data, err := s.calendar.ListAll(ctx)
+	err = s.calendar.Delete(ctx, event.UserID, id2+1)
+	s.Require().True(errors.Is(err, storage.ErrNotExistsEvent))
+	data, err := s.calendar.ListAll(ctx, 0)
 	s.Require().NoError(err)
 	s.Require().Equal(2, len(data))
 
 	// удаление первого события
-	err = s.calendar.Delete(ctx, id1)
+	err = s.calendar.Delete(ctx, event.UserID, id1)
 	s.Require().NoError(err)
-	data, err = s.calendar.ListAll(ctx)
+	data, err = s.calendar.ListAll(ctx, 0)
 	s.Require().NoError(err)
 	s.Require().Equal(1, len(data))
 
 	// удаление второго события
-	err = s.calendar.Delete(ctx, id2)
+	err = s.calendar.Delete(ctx, event.UserID, id2)
 	s.Require().NoError(err)
-	data, err = s.calendar.ListAll(ctx)
+	data, err = s.calendar.ListAll(ctx, 0)
 	s.Require().NoError(err)
 	s.Require().Equal(0, len(data))
 }
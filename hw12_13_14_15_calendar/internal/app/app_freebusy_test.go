@@ -0,0 +1,91 @@
+package app_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/suite"
+
+	"github.com/anfilat/otus-go/hw12_13_14_15_calendar/internal/app"
+)
+
+type FreeBusyTest struct {
+	SuiteTest
+}
+
+func (s *FreeBusyTest) TestFreeBusy() {
+	ctx := context.Background()
+	base := s.NewCommonEvent()
+	from := base.Start.Truncate(time.Second)
+
+	// пользователь 1: [0:00, 1:00) и [1:00, 2:00), пользователь 2: [1:30, 3:00) и [4:00, 5:00)
+	add := func(userID int, start, stop time.Duration) {
+		event := s.NewCommonEvent()
+		event.UserID = userID
+		event.Start = from.Add(start)
+		event.Stop = from.Add(stop)
+		_, err := s.AddEvent(event)
+		s.Require().NoError(err)
+	}
+	add(1, 0, time.Hour)
+	add(1, time.Hour, 2*time.Hour)
+	add(2, 90*time.Minute, 3*time.Hour)
+	add(2, 4*time.Hour, 5*time.Hour)
+	add(3, 0, 8*time.Hour)
+
+	to := from.Add(8 * time.Hour)
+	result, err := s.calendar.FreeBusy(ctx, []int{1, 2}, from, to, time.Hour, 0)
+	s.Require().NoError(err)
+
+	s.Require().Equal(2, len(result.Busy))
+	s.Require().Equal(1, result.Busy[0].UserID)
+	s.EqualIntervals([]app.Interval{{from, from.Add(2 * time.Hour)}}, result.Busy[0].Busy)
+	s.Require().Equal(2, result.Busy[1].UserID)
+	s.EqualIntervals([]app.Interval{
+		{from.Add(90 * time.Minute), from.Add(3 * time.Hour)},
+		{from.Add(4 * time.Hour), from.Add(5 * time.Hour)},
+	}, result.Busy[1].Busy)
+
+	s.EqualIntervals([]app.Interval{
+		{from.Add(3 * time.Hour), from.Add(4 * time.Hour)},
+		{from.Add(5 * time.Hour), to},
+	}, result.Free)
+
+	// ограничение числа и длительности
+	result, err = s.calendar.FreeBusy(ctx, []int{1, 2}, from, to, 2*time.Hour, 1)
+	s.Require().NoError(err)
+	s.EqualIntervals([]app.Interval{{from.Add(5 * time.Hour), to}}, result.Free)
+
+	// занятое время обрезается по интервалу
+	result, err = s.calendar.FreeBusy(ctx, []int{3}, from.Add(time.Hour), from.Add(2*time.Hour), time.Minute, 0)
+	s.Require().NoError(err)
+	s.EqualIntervals([]app.Interval{{from.Add(time.Hour), from.Add(2 * time.Hour)}}, result.Busy[0].Busy)
+	s.Require().Equal(0, len(result.Free))
+}
+
+func (s *FreeBusyTest) TestFreeBusyFail() {
+	ctx := context.Background()
+	now := time.Now()
+
+	_, err := s.calendar.FreeBusy(ctx, nil, now, now.Add(time.Hour), time.Hour, 0)
+	s.Require().Equal(app.ErrNoUserID, err)
+
+	_, err = s.calendar.FreeBusy(ctx, []int{1}, now, now, time.Hour, 0)
+	s.Require().Equal(app.ErrInvalidRange, err)
+
+	_, err = s.calendar.FreeBusy(ctx, []int{1}, now, now.Add(time.Hour), 0, 0)
+	s.Require().Equal(app.ErrInvalidDuration, err)
+}
+
+func (s *FreeBusyTest) EqualIntervals(expected, actual []app.Interval) {
+	s.Require().Equal(len(expected), len(actual))
+	for i := range expected {
+		s.Require().True(expected[i].Start.Equal(actual[i].Start), i)
+		s.Require().True(expected[i].Stop.Equal(actual[i].Stop), i)
+	}
+}
+
+func TestFreeBusyTest(t *testing.T) {
+	suite.Run(t, new(FreeBusyTest))
+}
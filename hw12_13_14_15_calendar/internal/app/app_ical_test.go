@@ -0,0 +1,105 @@
+package app_test
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/suite"
+
+	"github.com/anfilat/otus-go/hw12_13_14_15_calendar/internal/app"
+	"github.com/anfilat/otus-go/hw12_13_14_15_calendar/internal/storage"
+)
+
+type ICalTest struct {
+	SuiteTest
+}
+
+func (s *ICalTest) TestExportImport() {
+	ctx := context.Background()
+	event := s.NewCommonEvent()
+	_, err := s.AddEvent(event)
+	s.Require().NoError(err)
+
+	other := s.NewCommonEvent()
+	other.UserID = 2
+	_, err = s.AddEvent(other)
+	s.Require().NoError(err)
+
+	var buf bytes.Buffer
+	err = s.calendar.Export(ctx, &buf, event.UserID, time.Time{}, time.Time{})
+	s.Require().NoError(err)
+	s.Require().Equal(1, strings.Count(buf.String(), "BEGIN:VEVENT"))
+	calendar := buf.String()
+
+	_ = s.calendar.DeleteAll(ctx, 0)
+
+	result, err := s.calendar.Import(ctx, strings.NewReader(calendar), 3)
+	s.Require().NoError(err)
+	s.Require().Equal(1, len(result))
+	s.Require().NoError(result[0].Err)
+
+	data := s.GetAll()
+	s.Require().Equal(1, len(data))
+	s.Require().Equal(result[0].ID, data[0].ID)
+	event.UserID = 3
+	s.EqualEvents(event, data[0])
+
+	// повторный импорт того же времени
+	result, err = s.calendar.Import(ctx, strings.NewReader(calendar), 3)
+	s.Require().NoError(err)
+	s.Require().Equal(1, len(result))
+	s.Require().Equal(app.ErrDateBusy, result[0].Err)
+}
+
+func (s *ICalTest) TestExportRange() {
+	ctx := context.Background()
+	event := s.NewCommonEvent()
+	_, err := s.AddEvent(event)
+	s.Require().NoError(err)
+
+	var buf bytes.Buffer
+	err = s.calendar.Export(ctx, &buf, 0, event.Stop, time.Time{})
+	s.Require().NoError(err)
+	s.Require().NotContains(buf.String(), "BEGIN:VEVENT")
+
+	buf.Reset()
+	err = s.calendar.Export(ctx, &buf, 0, event.Start, event.Stop)
+	s.Require().NoError(err)
+	s.Require().Contains(buf.String(), "BEGIN:VEVENT")
+}
+
+// Время без часового пояса считается в поясе из настроек пользователя.
+func (s *ICalTest) TestImportFloating() {
+	ctx := context.Background()
+	s.Require().NoError(s.calendar.SaveSettings(ctx, 1, storage.UserSettings{TimeZone: "Asia/Tokyo"}))
+	tokyo, err := time.LoadLocation("Asia/Tokyo")
+	s.Require().NoError(err)
+
+	start := time.Now().In(tokyo).AddDate(0, 0, 1)
+	calendar := "BEGIN:VCALENDAR\r\n" +
+		"BEGIN:VEVENT\r\n" +
+		"UID:floating\r\n" +
+		"DTSTART:" + start.Format("20060102T150405") + "\r\n" +
+		"DURATION:PT1H\r\n" +
+		"SUMMARY:Floating\r\n" +
+		"END:VEVENT\r\n" +
+		"END:VCALENDAR\r\n"
+	for userID, location := range map[int]*time.Location{1: tokyo, 2: time.UTC} {
+		result, err := s.calendar.Import(ctx, strings.NewReader(calendar), userID)
+		s.Require().NoError(err)
+		s.Require().NoError(result[0].Err)
+
+		event, err := s.calendar.Get(ctx, userID, result[0].ID)
+		s.Require().NoError(err)
+		wall := start.Truncate(time.Second)
+		expected := time.Date(wall.Year(), wall.Month(), wall.Day(), wall.Hour(), wall.Minute(), wall.Second(), 0, location)
+		s.Require().True(expected.Equal(event.Start), event.Start)
+	}
+}
+
+func TestICalTest(t *testing.T) {
+	suite.Run(t, new(ICalTest))
+}
@@ -2,11 +2,13 @@ package app_test
 
 import (
 	"context"
+	"errors"
 	"testing"
 	"time"
 
 	"github.com/stretchr/testify/suite"
 
+	"github.com/anfilat/otus-go/hw12_13_14_15_calendar/internal/app"
 	"github.com/anfilat/otus-go/hw12_13_14_15_calendar/internal/storage"
 )
 
@@ -14,13 +16,21 @@ type ListEventTest struct {
 	SuiteTest
 }
 
+// firstMonday возвращает первый понедельник месяца через год, чтобы события были в будущем.
+func firstMonday() time.Time {
+	year, month, _ := time.Now().UTC().AddDate(1, 0, 0).Date()
+	date := time.Date(year, month, 1, 0, 0, 0, 0, time.UTC)
+	return date.AddDate(0, 0, (8-int(date.Weekday()))%7)
+}
+
 func (s *ListEventTest) TestList() {
 	ctx := context.Background()
+	monday := firstMonday()
 	event1 := storage.Event{
 		ID:           1,
 		Title:        "Купить",
-		Start:        time.Date(2021, 12, 13, 12, 42, 5, 0, time.UTC),
-		Stop:         time.Date(2021, 12, 13, 13, 0, 0, 0, time.UTC),
+		Start:        monday.Add(12*time.Hour + 42*time.Minute + 5*time.Second),
+		Stop:         monday.Add(13 * time.Hour),
 		Description:  "Купить поесть",
 		UserID:       1,
 		Notification: nil,
@@ -28,8 +38,8 @@ func (s *ListEventTest) TestList() {
 	event2 := storage.Event{
 		ID:           2,
 		Title:        "Поесть",
-		Start:        time.Date(2021, 12, 13, 17, 42, 5, 0, time.UTC),
-		Stop:         time.Date(2021, 12, 13, 18, 0, 0, 0, time.UTC),
+		Start:        monday.Add(17*time.Hour + 42*time.Minute + 5*time.Second),
+		Stop:         monday.Add(18 * time.Hour),
 		Description:  "Поесть купленное",
 		UserID:       1,
 		Notification: nil,
@@ -37,8 +47,8 @@ func (s *ListEventTest) TestList() {
 	event3 := storage.Event{
 		ID:           3,
 		Title:        "Подвиг",
-		Start:        time.Date(2021, 12, 14, 9, 13, 17, 0, time.UTC),
-		Stop:         time.Date(2021, 12, 14, 9, 15, 9, 0, time.UTC),
+		Start:        monday.AddDate(0, 0, 1).Add(9*time.Hour + 13*time.Minute + 17*time.Second),
+		Stop:         monday.AddDate(0, 0, 1).Add(9*time.Hour + 15*time.Minute + 9*time.Second),
 		Description:  "Совершить подвиг",
 		UserID:       1,
 		Notification: nil,
@@ -46,8 +56,8 @@ func (s *ListEventTest) TestList() {
 	event4 := storage.Event{
 		ID:           4,
 		Title:        "Осень",
-		Start:        time.Date(2021, 11, 14, 9, 13, 17, 0, time.UTC),
-		Stop:         time.Date(2021, 11, 14, 9, 15, 9, 0, time.UTC),
+		Start:        monday.AddDate(0, -1, 0).Add(9*time.Hour + 13*time.Minute + 17*time.Second),
+		Stop:         monday.AddDate(0, -1, 0).Add(9*time.Hour + 15*time.Minute + 9*time.Second),
 		Description:  "Наблюдать осень",
 		UserID:       1,
 		Notification: nil,
@@ -63,20 +73,20 @@ func (s *ListEventTest) TestList() {
 	s.Require().NoError(err)
 
 	// за 1 день
-	list, err := s.calendar.ListDay(ctx, event1.Start)
+	list, err := s.calendar.ListDay(ctx, event1.UserID, event1.Start, "")
 	s.Require().NoError(err)
 	s.Require().Equal(2, len(list))
 	s.EqualEvents(event1, list[0])
 	s.EqualEvents(event2, list[1])
 
 	// за другой день
-	list, err = s.calendar.ListDay(ctx, event3.Start)
+	list, err = s.calendar.ListDay(ctx, event1.UserID, event3.Start, "")
 	s.Require().NoError(err)
 	s.Require().Equal(1, len(list))
 	s.EqualEvents(event3, list[0])
 
 	// за неделю
-	list, err = s.calendar.ListWeek(ctx, event1.Start)
+	list, err = s.calendar.ListWeek(ctx, event1.UserID, event1.Start, "")
 	s.Require().NoError(err)
 	s.Require().Equal(3, len(list))
 	s.EqualEvents(event1, list[0])
@@ -84,7 +94,7 @@ func (s *ListEventTest) TestList() {
 	s.EqualEvents(event3, list[2])
 
 	// за месяц
-	list, err = s.calendar.ListWeek(ctx, event1.Start)
+	list, err = s.calendar.ListMonth(ctx, event1.UserID, event1.Start, "")
 	s.Require().NoError(err)
 	s.Require().Equal(3, len(list))
 	s.EqualEvents(event1, list[0])
@@ -92,12 +102,70 @@ func (s *ListEventTest) TestList() {
 	s.EqualEvents(event3, list[2])
 
 	// за другой месяц
-	list, err = s.calendar.ListWeek(ctx, event4.Start)
+	list, err = s.calendar.ListMonth(ctx, event1.UserID, event4.Start, "")
 	s.Require().NoError(err)
 	s.Require().Equal(1, len(list))
 	s.EqualEvents(event4, list[0])
 }
 
+func (s *ListEventTest) TestListTimeZone() {
+	ctx := context.Background()
+	// понедельник 23:30 UTC - в Москве уже вторник, в Нью-Йорке еще понедельник
+	start := firstMonday().Add(23*time.Hour + 30*time.Minute)
+	event := s.NewCommonEvent()
+	event.Start = start
+	event.Stop = start.Add(20 * time.Minute)
+	id, err := s.AddEvent(event)
+	s.Require().NoError(err)
+
+	tuesday := time.Date(start.Year(), start.Month(), start.Day()+1, 12, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name     string
+		settings string
+		timeZone string
+		count    int
+	}{
+		{"zone of the date", "", "", 0},
+		{"zone of the request", "", "Europe/Moscow", 1},
+		{"zone of the user", "Europe/Moscow", "", 1},
+		{"request overrides user", "Europe/Moscow", "America/New_York", 0},
+		{"utc overrides user", "Europe/Moscow", "UTC", 0},
+	}
+	for _, tt := range tests {
+		err := s.calendar.SaveSettings(ctx, event.UserID, storage.UserSettings{TimeZone: tt.settings})
+		s.Require().NoError(err, tt.name)
+
+		list, err := s.calendar.ListDay(ctx, event.UserID, tuesday, tt.timeZone)
+		s.Require().NoError(err, tt.name)
+		s.Require().Equal(tt.count, len(list), tt.name)
+		if tt.count > 0 {
+			s.Require().Equal(id, list[0].ID)
+		}
+	}
+
+	for _, timeZone := range []string{"Mars/Olympus", "Local"} {
+		_, err = s.calendar.ListWeek(ctx, event.UserID, tuesday, timeZone)
+		s.Require().True(errors.Is(err, app.ErrInvalidTimeZone), timeZone)
+	}
+}
+
+func (s *ListEventTest) TestSettings() {
+	ctx := context.Background()
+	settings, err := s.calendar.GetSettings(ctx, 1)
+	s.Require().NoError(err)
+	s.Require().Equal("", settings.TimeZone)
+
+	s.Require().NoError(s.calendar.SaveSettings(ctx, 1, storage.UserSettings{TimeZone: "Asia/Tokyo"}))
+	settings, err = s.calendar.GetSettings(ctx, 1)
+	s.Require().NoError(err)
+	s.Require().Equal("Asia/Tokyo", settings.TimeZone)
+
+	err = s.calendar.SaveSettings(ctx, 1, storage.UserSettings{TimeZone: "Asia/Nowhere"})
+	s.Require().True(errors.Is(err, app.ErrInvalidTimeZone))
+	_, err = s.calendar.GetSettings(ctx, 0)
+	s.Require().Equal(app.ErrNoUserID, err)
+}
+
 func TestListEventTest(t *testing.T) {
 	suite.Run(t, new(ListEventTest))
 }
@@ -0,0 +1,145 @@
+package app_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/suite"
+
+	"github.com/anfilat/otus-go/hw12_13_14_15_calendar/internal/app"
+	"github.com/anfilat/otus-go/hw12_13_14_15_calendar/internal/storage"
+)
+
+type ListRangeTest struct {
+	SuiteTest
+}
+
+func (s *ListRangeTest) AddEvents() []storage.Event {
+	var events []storage.Event
+	titles := []string{"first", "second", "third", "fourth"}
+	for i, title := range titles {
+		event := s.NewCommonEvent()
+		event.Title = title
+		event.Start = event.Start.Add(time.Duration(i) * 2 * time.Hour)
+		event.Stop = event.Start.Add(time.Hour)
+		id, err := s.AddEvent(event)
+		s.Require().NoError(err)
+		event.ID = id
+		events = append(events, event)
+	}
+
+	other := s.NewCommonEvent()
+	other.UserID = 2
+	_, err := s.AddEvent(other)
+	s.Require().NoError(err)
+	return events
+}
+
+func (s *ListRangeTest) TestPages() {
+	ctx := context.Background()
+	events := s.AddEvents()
+	from := events[0].Start.Add(-time.Hour)
+	to := events[len(events)-1].Stop.Add(time.Hour)
+
+	for _, desc := range []bool{false, true} {
+		var titles []string
+		filter := storage.Filter{Limit: 3, Desc: desc}
+		for {
+			page, err := s.calendar.ListRange(ctx, 1, from, to, filter)
+			s.Require().NoError(err)
+			for _, event := range page.Events {
+				titles = append(titles, event.Title)
+			}
+			if page.NextCursor == "" {
+				break
+			}
+			filter.Cursor = page.NextCursor
+		}
+		if desc {
+			s.Require().Equal([]string{"fourth", "third", "second", "first"}, titles)
+		} else {
+			s.Require().Equal([]string{"first", "second", "third", "fourth"}, titles)
+		}
+	}
+}
+
+func (s *ListRangeTest) TestRange() {
+	ctx := context.Background()
+	events := s.AddEvents()
+
+	// пересечение с интервалом, а не только начало в нем
+	page, err := s.calendar.ListRange(ctx, 1, events[1].Start.Add(30*time.Minute), events[2].Start.Add(time.Minute),
+		storage.Filter{})
+	s.Require().NoError(err)
+	s.Require().Equal(2, len(page.Events))
+	s.Require().Equal(events[1].ID, page.Events[0].ID)
+	s.Require().Equal(events[2].ID, page.Events[1].ID)
+	s.Require().Equal("", page.NextCursor)
+
+	page, err = s.calendar.ListRange(ctx, 1, events[0].Stop, events[1].Start, storage.Filter{})
+	s.Require().NoError(err)
+	s.Require().Equal(0, len(page.Events))
+}
+
+func (s *ListRangeTest) TestSearch() {
+	ctx := context.Background()
+	events := s.AddEvents()
+
+	page, err := s.calendar.ListRange(ctx, 1, events[0].Start, events[3].Stop, storage.Filter{Search: "IR"})
+	s.Require().NoError(err)
+	s.Require().Equal(2, len(page.Events))
+	s.Require().Equal("first", page.Events[0].Title)
+	s.Require().Equal("third", page.Events[1].Title)
+
+	page, err = s.calendar.ListRange(ctx, 1, events[0].Start, events[3].Stop, storage.Filter{Search: "the event"})
+	s.Require().NoError(err)
+	s.Require().Equal(4, len(page.Events))
+}
+
+func (s *ListRangeTest) TestRecurring() {
+	ctx := context.Background()
+	event := s.NewCommonEvent()
+	recurrence, err := storage.NewRecurrence("FREQ=DAILY;COUNT=5", nil)
+	s.Require().NoError(err)
+	event.Recurrence = recurrence
+	id, err := s.AddEvent(event)
+	s.Require().NoError(err)
+
+	filter := storage.Filter{Limit: 2}
+	var starts []time.Time
+	for {
+		page, err := s.calendar.ListRange(ctx, 1, event.Start, event.Start.AddDate(0, 0, 10), filter)
+		s.Require().NoError(err)
+		for _, occurrence := range page.Events {
+			s.Require().Equal(id, occurrence.ID)
+			starts = append(starts, occurrence.Start)
+		}
+		if page.NextCursor == "" {
+			break
+		}
+		filter.Cursor = page.NextCursor
+	}
+	s.Require().Equal(5, len(starts))
+	for i, start := range starts {
+		s.Require().Equal(event.Start.AddDate(0, 0, i).Unix(), start.Unix())
+	}
+}
+
+func (s *ListRangeTest) TestFail() {
+	ctx := context.Background()
+	now := time.Now()
+
+	_, err := s.calendar.ListRange(ctx, 1, now, now, storage.Filter{})
+	s.Require().Equal(app.ErrInvalidRange, err)
+
+	_, err = s.calendar.ListRange(ctx, 0, now, now.Add(time.Hour), storage.Filter{})
+	s.Require().Equal(app.ErrNoUserID, err)
+
+	_, err = s.calendar.ListRange(ctx, 1, now, now.Add(time.Hour), storage.Filter{Cursor: "!"})
+	s.Require().Error(err)
+}
+
+func TestListRangeTest(t *testing.T) {
+	suite.Run(t, new(ListRangeTest))
+}
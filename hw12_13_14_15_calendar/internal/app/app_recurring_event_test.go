@@ -0,0 +1,166 @@
+package app_test
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/suite"
+
+	"github.com/anfilat/otus-go/hw12_13_14_15_calendar/internal/app"
+	"github.com/anfilat/otus-go/hw12_13_14_15_calendar/internal/storage"
+)
+
+type RecurringEventTest struct {
+	SuiteTest
+}
+
+func (s *RecurringEventTest) NewWeeklyEvent(rule string) storage.Event {
+	event := s.NewCommonEvent()
+	recurrence, err := storage.NewRecurrence(rule, []time.Time{event.Start.AddDate(0, 0, 14)})
+	s.Require().NoError(err)
+	event.Recurrence = recurrence
+	return event
+}
+
+func (s *RecurringEventTest) TestList() {
+	ctx := context.Background()
+	event := s.NewWeeklyEvent("FREQ=WEEKLY;COUNT=4")
+	id, err := s.AddEvent(event)
+	s.Require().NoError(err)
+
+	data := s.GetAll()
+	s.Require().Equal(1, len(data))
+	s.Require().Equal("FREQ=WEEKLY;COUNT=4", data[0].Recurrence.RRule())
+	s.Require().Equal(1, len(data[0].Recurrence.Exceptions))
+
+	tests := []struct {
+		name  string
+		weeks int
+		count int
+	}{
+		{"first", 0, 1},
+		{"second", 1, 1},
+		{"exception", 2, 0},
+		{"last", 3, 1},
+		{"after last", 4, 0},
+	}
+	for _, tt := range tests {
+		date := event.Start.AddDate(0, 0, 7*tt.weeks)
+		list, err := s.calendar.ListDay(ctx, event.UserID, date, "")
+		s.Require().NoError(err, tt.name)
+		s.Require().Equal(tt.count, len(list), tt.name)
+		if tt.count > 0 {
+			s.Require().Equal(id, list[0].ID)
+			s.Require().Equal(date.Unix(), list[0].Start.Unix())
+			s.Require().Equal(date.Add(time.Hour).Unix(), list[0].Stop.Unix())
+		}
+
+		list, err = s.calendar.ListWeek(ctx, event.UserID, date, "")
+		s.Require().NoError(err, tt.name)
+		s.Require().Equal(tt.count, len(list), tt.name)
+	}
+
+	list, err := s.calendar.ListMonth(ctx, event.UserID, event.Start.AddDate(0, 3, 0), "")
+	s.Require().NoError(err)
+	s.Require().Equal(0, len(list))
+}
+
+func (s *RecurringEventTest) TestCreateFailDateBusy() {
+	_, err := s.AddEvent(s.NewWeeklyEvent("FREQ=WEEKLY"))
+	s.Require().NoError(err)
+
+	// занято повторением
+	event := s.NewCommonEvent()
+	event.Start = event.Start.AddDate(0, 0, 7).Add(30 * time.Minute)
+	event.Stop = event.Start.Add(time.Hour)
+	_, err = s.AddEvent(event)
+	s.Require().Equal(app.ErrDateBusy, err)
+
+	// исключенное повторение свободно
+	event.Start = event.Start.AddDate(0, 0, 7)
+	event.Stop = event.Start.Add(time.Hour)
+	_, err = s.AddEvent(event)
+	s.Require().NoError(err)
+
+	// новое повторяющееся событие пересекается с событием через неделю
+	event = s.NewCommonEvent()
+	event.Start = event.Start.AddDate(0, 0, 6).Add(30 * time.Minute)
+	event.Stop = event.Start.Add(time.Hour)
+	recurrence, err := storage.NewRecurrence("FREQ=DAILY", nil)
+	s.Require().NoError(err)
+	event.Recurrence = recurrence
+	_, err = s.AddEvent(event)
+	s.Require().Equal(app.ErrDateBusy, err)
+}
+
+func (s *RecurringEventTest) TestCreateFailInvalidRule() {
+	event := s.NewCommonEvent()
+	event.Recurrence = &storage.Recurrence{Freq: "HOURLY"}
+	_, err := s.AddEvent(event)
+	s.Require().True(errors.Is(err, storage.ErrInvalidRecurrence))
+}
+
+// Правило раскрывается в поясе пользователя, даже если хранилище возвращает начало в другом поясе.
+func (s *RecurringEventTest) TestTimeZone() {
+	ctx := context.Background()
+	s.Require().NoError(s.calendar.SaveSettings(ctx, 1, storage.UserSettings{TimeZone: "Europe/Moscow"}))
+	moscow, err := time.LoadLocation("Europe/Moscow")
+	s.Require().NoError(err)
+	tokyo, err := time.LoadLocation("Asia/Tokyo")
+	s.Require().NoError(err)
+
+	// понедельник 00:30 по Москве - еще воскресенье в UTC
+	start := time.Date(2030, 3, 4, 0, 30, 0, 0, time.FixedZone("", 3*60*60))
+	tests := []struct {
+		name     string
+		userID   int
+		start    time.Time
+		timeZone string
+	}{
+		{"settings", 1, start, "Europe/Moscow"},
+		{"utc start", 1, start.AddDate(0, 0, 1).UTC(), "Europe/Moscow"},
+		{"named start", 1, start.AddDate(0, 0, 2).In(tokyo), "Asia/Tokyo"},
+		{"no settings", 2, start, "+03:00"},
+	}
+	for _, tt := range tests {
+		event := s.NewCommonEvent()
+		event.UserID = tt.userID
+		event.Start = tt.start
+		event.Stop = tt.start.Add(20 * time.Minute)
+		event.Recurrence, err = storage.NewRecurrence("FREQ=WEEKLY;BYDAY="+weekdayName(tt.start.In(moscow)), nil)
+		s.Require().NoError(err, tt.name)
+		id, err := s.AddEvent(event)
+		s.Require().NoError(err, tt.name)
+
+		saved, err := s.calendar.Get(ctx, tt.userID, id)
+		s.Require().NoError(err, tt.name)
+		s.Require().Equal(tt.timeZone, saved.Recurrence.TimeZone, tt.name)
+	}
+
+	list, err := s.calendar.ListMonth(ctx, 1, start, "Europe/Moscow")
+	s.Require().NoError(err)
+	s.Require().NotEmpty(list)
+	for _, event := range list {
+		local := event.Start.In(moscow)
+		s.Require().Equal(0, local.Hour(), local)
+		s.Require().Equal(30, local.Minute(), local)
+	}
+
+	list, err = s.calendar.ListMonth(ctx, 2, start, "Europe/Moscow")
+	s.Require().NoError(err)
+	s.Require().Len(list, 4)
+	for _, event := range list {
+		s.Require().Equal(time.Monday, event.Start.In(moscow).Weekday(), event.Start)
+	}
+}
+
+func weekdayName(date time.Time) string {
+	return strings.ToUpper(date.Weekday().String()[:2])
+}
+
+func TestRecurringEventTest(t *testing.T) {
+	suite.Run(t, new(RecurringEventTest))
+}
@@ -25,19 +25,22 @@ func (s *SuiteTest) SetupTest() {
 	ctx := context.Background()
 
 	var buf bytes.Buffer
-	s.logg, _ = logger.New("", &buf, "")
+	s.logg, _ = logger.New(logger.Options{}, &buf)
 
-	dbConnect := os.Getenv("PQ_TEST")
-	s.db, _ = initstorage.New(ctx, dbConnect == "", dbConnect)
+	dbDriver, dbConnect := initstorage.DriverPostgres, os.Getenv("PQ_TEST")
+	if sqliteConnect := os.Getenv("SQLITE_TEST"); sqliteConnect != "" {
+		dbDriver, dbConnect = initstorage.DriverSQLite, sqliteConnect
+	}
+	s.db, _ = initstorage.New(ctx, dbConnect == "", dbDriver, dbConnect)
 
 	s.calendar = app.New(s.logg, s.db)
 
-	_ = s.calendar.DeleteAll(ctx)
+	_ = s.calendar.DeleteAll(ctx, 0)
 }
 
 func (s *SuiteTest) TearDownTest() {
 	ctx := context.Background()
-	_ = s.calendar.DeleteAll(ctx)
+	_ = s.calendar.DeleteAll(ctx, 0)
 	_ = s.db.Close(ctx)
 }
 
@@ -54,6 +57,7 @@ func (s *SuiteTest) NewCommonEvent() storage.Event {
 		Description:  "the event",
 		UserID:       1,
 		Notification: &notification,
+		Version:      storage.InitialVersion,
 	}
 }
 
@@ -66,14 +70,16 @@ func (s *SuiteTest) AddEvent(event storage.Event) (int, error) {
 		event.Description,
 		event.Start,
 		event.Stop,
+		event.AllDay,
 		event.Notification,
+		event.Recurrence,
 	)
 	return id, err
 }
 
 func (s *SuiteTest) GetAll() []storage.Event {
 	ctx := context.Background()
-	data, err := s.calendar.ListAll(ctx)
+	data, err := s.calendar.ListAll(ctx, 0)
 	s.Require().NoError(err)
 	return data
 }
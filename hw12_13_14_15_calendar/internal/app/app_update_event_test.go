@@ -28,9 +28,11 @@ func (s *UpdateEventTest) TestUpdateEvent() {
 		Description:  "very long event",
 		UserID:       event.UserID,
 		Notification: nil,
+		Version:      storage.InitialVersion,
 	}
-	err = s.calendar.Update(ctx, id, updateEvent)
+	version, err := s.calendar.Update(ctx, event.UserID, id, updateEvent)
 	s.Require().NoError(err)
+	s.Require().Equal(storage.InitialVersion+1, version)
 
 	data := s.GetAll()
 	s.Require().Equal(1, len(data))
@@ -48,7 +50,7 @@ func (s *UpdateEventTest) TestUpdateEventFailNotExistsEvent() {
 	event.Start = event.Start.Add(3 * time.Hour)
 	event.Start = event.Stop.Add(3 * time.Hour)
 	ctx := context.Background()
-	err = s.calendar.Update(ctx, id+1, event)
+	_, err = s.calendar.Update(ctx, event.UserID, id+1, event)
 	s.Require().Equal(storage.ErrNotExistsEvent, err)
 }
 
@@ -59,7 +61,7 @@ func (s *UpdateEventTest) TestUpdateEventFailNoTitle() {
 
 	event.Title = ""
 	ctx := context.Background()
-	err = s.calendar.Update(ctx, id, event)
+	_, err = s.calendar.Update(ctx, event.UserID, id, event)
 	s.Require().Equal(app.ErrEmptyTitle, err)
 }
 
@@ -70,7 +72,7 @@ func (s *UpdateEventTest) TestUpdateEventFailStartInPast() {
 
 	event.Start = time.Now().Add(-time.Minute)
 	ctx := context.Background()
-	err = s.calendar.Update(ctx, id, event)
+	_, err = s.calendar.Update(ctx, event.UserID, id, event)
 	s.Require().Equal(app.ErrStartInPast, err)
 }
 
@@ -93,11 +95,13 @@ func (s *UpdateEventTest) TestUpdateEventNoDateBusy() {
 		{event.Start.Add(-30 * time.Minute), event.Start.Add(-20 * time.Minute)},
 		{event.Start.Add(1 * time.Hour), event.Start.Add(2 * time.Hour)},
 	}
+	version := storage.InitialVersion
 	for _, tt := range tests {
 		updateEvent := s.NewCommonEvent()
 		updateEvent.Start = tt.start
 		updateEvent.Stop = tt.stop
-		err := s.calendar.Update(ctx, id, updateEvent)
+		updateEvent.Version = version
+		version, err = s.calendar.Update(ctx, event.UserID, id, updateEvent)
 		s.Require().NoError(err)
 	}
 }
@@ -128,11 +132,44 @@ func (s *UpdateEventTest) TestUpdateEventFailDateBusy() {
 		updateEvent := s.NewCommonEvent()
 		updateEvent.Start = tt.start
 		updateEvent.Stop = tt.stop
-		err := s.calendar.Update(ctx, id, updateEvent)
+		_, err := s.calendar.Update(ctx, event.UserID, id, updateEvent)
 		s.Require().Equal(app.ErrDateBusy, err)
 	}
 }
 
+func (s *UpdateEventTest) TestUpdateEventFailConflict() {
+	event := s.NewCommonEvent()
+	id, err := s.AddEvent(event)
+	s.Require().NoError(err)
+
+	ctx := context.Background()
+	first := s.NewCommonEvent()
+	first.Title = "first change"
+	_, err = s.calendar.Update(ctx, event.UserID, id, first)
+	s.Require().NoError(err)
+
+	// вторая правка основана на устаревшей версии
+	second := s.NewCommonEvent()
+	second.Title = "second change"
+	_, err = s.calendar.Update(ctx, event.UserID, id, second)
+	s.Require().Equal(storage.ErrConflict, err)
+
+	data := s.GetAll()
+	s.Require().Equal(1, len(data))
+	s.Require().Equal("first change", data[0].Title)
+	s.Require().Equal(storage.InitialVersion+1, data[0].Version)
+}
+
+func (s *UpdateEventTest) TestUpdateEventFailNoVersion() {
+	event := s.NewCommonEvent()
+	id, err := s.AddEvent(event)
+	s.Require().NoError(err)
+
+	event.Version = 0
+	_, err = s.calendar.Update(context.Background(), event.UserID, id, event)
+	s.Require().Equal(app.ErrNoVersion, err)
+}
+
 func TestUpdateEventTest(t *testing.T) {
 	suite.Run(t, new(UpdateEventTest))
 }
@@ -0,0 +1,70 @@
+package app_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/suite"
+
+	"github.com/anfilat/otus-go/hw12_13_14_15_calendar/internal/app"
+)
+
+type UserScopeTest struct {
+	SuiteTest
+}
+
+func (s *UserScopeTest) TestList() {
+	ctx := context.Background()
+	event := s.NewCommonEvent()
+	id, err := s.AddEvent(event)
+	s.Require().NoError(err)
+
+	other := s.NewCommonEvent()
+	other.UserID = 2
+	_, err = s.AddEvent(other)
+	s.Require().NoError(err)
+
+	for _, fn := range []app.ListEvents{s.calendar.ListDay, s.calendar.ListWeek, s.calendar.ListMonth} {
+		list, err := fn(ctx, event.UserID, event.Start, "")
+		s.Require().NoError(err)
+		s.Require().Equal(1, len(list))
+		s.Require().Equal(id, list[0].ID)
+	}
+
+	_, err = s.calendar.ListDay(ctx, 0, event.Start, "")
+	s.Require().Equal(app.ErrNoUserID, err)
+}
+
+func (s *UserScopeTest) TestUpdateFailForbidden() {
+	ctx := context.Background()
+	event := s.NewCommonEvent()
+	id, err := s.AddEvent(event)
+	s.Require().NoError(err)
+
+	change := s.NewCommonEvent()
+	change.Title = "stolen event"
+	_, err = s.calendar.Update(ctx, 2, id, change)
+	s.Require().Equal(app.ErrForbidden, err)
+
+	data := s.GetAll()
+	s.Require().Equal(1, len(data))
+	s.EqualEvents(event, data[0])
+}
+
+func (s *UserScopeTest) TestDeleteFailForbidden() {
+	ctx := context.Background()
+	event := s.NewCommonEvent()
+	id, err := s.AddEvent(event)
+	s.Require().NoError(err)
+
+	err = s.calendar.Delete(ctx, 2, id)
+	s.Require().Equal(app.ErrForbidden, err)
+	s.Require().Equal(1, len(s.GetAll()))
+
+	err = s.calendar.Delete(ctx, 0, id)
+	s.Require().Equal(app.ErrNoUserID, err)
+}
+
+func TestUserScopeTest(t *testing.T) {
+	suite.Run(t, new(UserScopeTest))
+}
@@ -0,0 +1,270 @@
+package app_test
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/suite"
+
+	"github.com/anfilat/otus-go/hw12_13_14_15_calendar/internal/app"
+	"github.com/anfilat/otus-go/hw12_13_14_15_calendar/internal/storage"
+)
+
+type WatchTest struct {
+	SuiteTest
+}
+
+func (s *WatchTest) next(ch <-chan app.Change) app.Change {
+	select {
+	case change, ok := <-ch:
+		s.Require().True(ok, "the channel is closed")
+		return change
+	case <-time.After(time.Second):
+		s.FailNow("no change")
+	}
+	return app.Change{}
+}
+
+func (s *WatchTest) requireEmpty(ch <-chan app.Change) {
+	select {
+	case change := <-ch:
+		s.FailNow("unexpected change", "%+v", change)
+	default:
+	}
+}
+
+func (s *WatchTest) TestWatch() {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	ch, _, err := s.calendar.Watch(ctx, 1, app.WatchFilter{}, "")
+	s.Require().NoError(err)
+
+	event := s.NewCommonEvent()
+	id, err := s.AddEvent(event)
+	s.Require().NoError(err)
+	change := s.next(ch)
+	s.Require().Equal(app.ChangeCreated, change.Type)
+	s.Require().Equal(id, change.Event.ID)
+	s.EqualEvents(event, change.Event)
+
+	event.Title = "new title"
+	_, err = s.calendar.Update(ctx, 1, id, event)
+	s.Require().NoError(err)
+	change = s.next(ch)
+	s.Require().Equal(app.ChangeUpdated, change.Type)
+	s.Require().Equal("new title", change.Event.Title)
+	s.Require().Equal(2, change.Event.Version)
+
+	err = s.calendar.Delete(ctx, 1, id)
+	s.Require().NoError(err)
+	change = s.next(ch)
+	s.Require().Equal(app.ChangeDeleted, change.Type)
+	s.Require().Equal(id, change.Event.ID)
+
+	// события другого пользователя
+	other := s.NewCommonEvent()
+	other.UserID = 2
+	_, err = s.AddEvent(other)
+	s.Require().NoError(err)
+	s.requireEmpty(ch)
+
+	cancel()
+	s.Eventually(func() bool {
+		_, ok := <-ch
+		return !ok
+	}, time.Second, 10*time.Millisecond)
+}
+
+func (s *WatchTest) TestWatchRange() {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	event := s.NewCommonEvent()
+	filter := app.WatchFilter{From: event.Start.Add(-time.Minute), To: event.Stop}
+	ch, _, err := s.calendar.Watch(ctx, 1, filter, "")
+	s.Require().NoError(err)
+
+	// вне интервала
+	later := s.NewCommonEvent()
+	later.Start = later.Start.Add(24 * time.Hour)
+	later.Stop = later.Stop.Add(24 * time.Hour)
+	laterID, err := s.AddEvent(later)
+	s.Require().NoError(err)
+	s.requireEmpty(ch)
+
+	id, err := s.AddEvent(event)
+	s.Require().NoError(err)
+	s.Require().Equal(id, s.next(ch).Event.ID)
+
+	// перенос из интервала тоже виден
+	event.Start = event.Start.Add(48 * time.Hour)
+	event.Stop = event.Stop.Add(48 * time.Hour)
+	_, err = s.calendar.Update(ctx, 1, id, event)
+	s.Require().NoError(err)
+	change := s.next(ch)
+	s.Require().Equal(app.ChangeUpdated, change.Type)
+	s.Require().Equal(id, change.Event.ID)
+
+	s.Require().NoError(s.calendar.Delete(ctx, 1, laterID))
+	s.requireEmpty(ch)
+
+	_, _, err = s.calendar.Watch(ctx, 1, app.WatchFilter{From: event.Stop, To: event.Start}, "")
+	s.Require().True(errors.Is(err, app.ErrInvalidRange))
+}
+
+func (s *WatchTest) TestResume() {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	ch, _, err := s.calendar.Watch(ctx, 1, app.WatchFilter{}, "")
+	s.Require().NoError(err)
+
+	event := s.NewCommonEvent()
+	id1, err := s.AddEvent(event)
+	s.Require().NoError(err)
+	token := s.next(ch).Token
+	s.Require().NotEqual("", token)
+	cancel()
+
+	// изменения, пропущенные между подписками
+	event.Start = event.Stop.Add(time.Hour)
+	event.Stop = event.Start.Add(time.Hour)
+	id2, err := s.AddEvent(event)
+	s.Require().NoError(err)
+	s.Require().NoError(s.calendar.Delete(context.Background(), 1, id1))
+
+	ctx, cancel = context.WithCancel(context.Background())
+	defer cancel()
+	ch, _, err = s.calendar.Watch(ctx, 1, app.WatchFilter{}, token)
+	s.Require().NoError(err)
+
+	change := s.next(ch)
+	s.Require().Equal(app.ChangeCreated, change.Type)
+	s.Require().Equal(id2, change.Event.ID)
+	change = s.next(ch)
+	s.Require().Equal(app.ChangeDeleted, change.Type)
+	s.Require().Equal(id1, change.Event.ID)
+	s.requireEmpty(ch)
+}
+
+// Изменения попадают в ленту в порядке записи, даже если идут одновременно.
+func (s *WatchTest) TestConcurrentOrder() {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	id, err := s.AddEvent(s.NewCommonEvent())
+	s.Require().NoError(err)
+	ch, _, err := s.calendar.Watch(ctx, 1, app.WatchFilter{}, "")
+	s.Require().NoError(err)
+
+	var wg sync.WaitGroup
+	for i := 0; i < concurrency; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			// повтор со свежей версией, пока изменение не запишется
+			for {
+				event, err := s.calendar.Get(context.Background(), 1, id)
+				if err != nil {
+					return
+				}
+				_, err = s.calendar.Update(context.Background(), 1, id, event)
+				if !errors.Is(err, storage.ErrConflict) {
+					return
+				}
+			}
+		}()
+	}
+	wg.Wait()
+
+	for i := 0; i < concurrency; i++ {
+		change := s.next(ch)
+		s.Require().Equal(app.ChangeUpdated, change.Type)
+		s.Require().Equal(storage.InitialVersion+i+1, change.Event.Version)
+	}
+	s.requireEmpty(ch)
+}
+
+// Удаление всех событий и очистка старых событий тоже попадают в ленту.
+func (s *WatchTest) TestWatchDeleteMany() {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	event := s.NewCommonEvent()
+	id1, err := s.AddEvent(event)
+	s.Require().NoError(err)
+	other := s.NewCommonEvent()
+	other.UserID = 2
+	_, err = s.AddEvent(other)
+	s.Require().NoError(err)
+	ch, _, err := s.calendar.Watch(ctx, 1, app.WatchFilter{}, "")
+	s.Require().NoError(err)
+
+	count, err := s.calendar.DeleteBefore(ctx, event.Start)
+	s.Require().NoError(err)
+	s.Require().Equal(0, count)
+	count, err = s.calendar.DeleteBefore(ctx, event.Stop.Add(time.Minute))
+	s.Require().NoError(err)
+	s.Require().Equal(2, count)
+	change := s.next(ch)
+	s.Require().Equal(app.ChangeDeleted, change.Type)
+	s.Require().Equal(id1, change.Event.ID)
+	s.requireEmpty(ch)
+
+	id2, err := s.AddEvent(event)
+	s.Require().NoError(err)
+	s.Require().Equal(app.ChangeCreated, s.next(ch).Type)
+	s.Require().NoError(s.calendar.DeleteAll(ctx, 1))
+	change = s.next(ch)
+	s.Require().Equal(app.ChangeDeleted, change.Type)
+	s.Require().Equal(id2, change.Event.ID)
+
+	id3, err := s.AddEvent(event)
+	s.Require().NoError(err)
+	s.Require().Equal(app.ChangeCreated, s.next(ch).Type)
+	s.Require().NoError(s.calendar.DeleteAll(ctx, 0))
+	change = s.next(ch)
+	s.Require().Equal(app.ChangeDeleted, change.Type)
+	s.Require().Equal(id3, change.Event.ID)
+	s.requireEmpty(ch)
+}
+
+// Подписка с токеном head, полученным до первого изменения, ничего не пропускает.
+func (s *WatchTest) TestResumeFromHead() {
+	ctx, cancel := context.WithCancel(context.Background())
+	_, head, err := s.calendar.Watch(ctx, 1, app.WatchFilter{}, "")
+	s.Require().NoError(err)
+	s.Require().NotEqual("", head)
+	cancel()
+
+	id, err := s.AddEvent(s.NewCommonEvent())
+	s.Require().NoError(err)
+
+	ctx, cancel = context.WithCancel(context.Background())
+	defer cancel()
+	ch, _, err := s.calendar.Watch(ctx, 1, app.WatchFilter{}, head)
+	s.Require().NoError(err)
+	change := s.next(ch)
+	s.Require().Equal(app.ChangeCreated, change.Type)
+	s.Require().Equal(id, change.Event.ID)
+	s.requireEmpty(ch)
+}
+
+func (s *WatchTest) TestInvalidToken() {
+	ctx := context.Background()
+	for _, token := range []string{"abc", "1.1", "x.y"} {
+		_, _, err := s.calendar.Watch(ctx, 1, app.WatchFilter{}, token)
+		s.Require().True(errors.Is(err, app.ErrInvalidToken) || errors.Is(err, app.ErrTokenExpired), token)
+	}
+
+	_, _, err := s.calendar.Watch(ctx, 0, app.WatchFilter{}, "")
+	s.Require().True(errors.Is(err, app.ErrNoUserID))
+}
+
+func TestWatchTest(t *testing.T) {
+	suite.Run(t, new(WatchTest))
+}
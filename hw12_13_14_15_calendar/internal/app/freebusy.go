@@ -0,0 +1,109 @@
+package app
+
+import (
+	"context"
+	"sort"
+	"time"
+
+	"github.com/anfilat/otus-go/hw12_13_14_15_calendar/internal/storage"
+)
+
+// FreeBusy возвращает занятость пользователей в интервале [from, to) и самые ранние общие
+// свободные промежутки не короче duration, не больше limit штук (0 - все).
+func (a *app) FreeBusy(
+	ctx context.Context,
+	userIDs []int,
+	from, to time.Time,
+	duration time.Duration,
+	limit int,
+) (FreeBusy, error) {
+	if len(userIDs) == 0 {
+		return FreeBusy{}, ErrNoUserID
+	}
+	if !to.After(from) {
+		return FreeBusy{}, ErrInvalidRange
+	}
+	if duration <= 0 {
+		return FreeBusy{}, ErrInvalidDuration
+	}
+
+	var result FreeBusy
+	var all []Interval
+	for _, userID := range userIDs {
+		if userID == 0 {
+			return FreeBusy{}, ErrNoUserID
+		}
+		page, err := a.storage.ListRange(ctx, from, to, storage.Filter{UserID: userID})
+		if err != nil {
+			return FreeBusy{}, err
+		}
+		busy := busyIntervals(page.Events, from, to)
+		result.Busy = append(result.Busy, UserBusy{UserID: userID, Busy: busy})
+		all = append(all, busy...)
+	}
+	result.Free = freeIntervals(mergeIntervals(all), from, to, duration, limit)
+	return result, nil
+}
+
+// busyIntervals возвращает объединенные промежутки событий, обрезанные по [from, to).
+func busyIntervals(events []storage.Event, from, to time.Time) []Interval {
+	intervals := make([]Interval, 0, len(events))
+	for _, event := range events {
+		if event.AllDay {
+			// события на весь день время не занимают
+			continue
+		}
+		interval := Interval{Start: event.Start, Stop: event.Stop}
+		if interval.Start.Before(from) {
+			interval.Start = from
+		}
+		if interval.Stop.After(to) {
+			interval.Stop = to
+		}
+		if interval.Stop.After(interval.Start) {
+			intervals = append(intervals, interval)
+		}
+	}
+	return mergeIntervals(intervals)
+}
+
+// mergeIntervals упорядочивает промежутки и объединяет пересекающиеся и смежные.
+func mergeIntervals(intervals []Interval) []Interval {
+	sort.Slice(intervals, func(i, j int) bool {
+		return intervals[i].Start.Before(intervals[j].Start)
+	})
+
+	var result []Interval
+	for _, interval := range intervals {
+		last := len(result) - 1
+		if last >= 0 && !interval.Start.After(result[last].Stop) {
+			if interval.Stop.After(result[last].Stop) {
+				result[last].Stop = interval.Stop
+			}
+			continue
+		}
+		result = append(result, interval)
+	}
+	return result
+}
+
+// freeIntervals возвращает промежутки [from, to) между занятыми не короче duration.
+func freeIntervals(busy []Interval, from, to time.Time, duration time.Duration, limit int) []Interval {
+	var result []Interval
+	add := func(start, stop time.Time) bool {
+		if stop.Sub(start) >= duration {
+			result = append(result, Interval{Start: start, Stop: stop})
+		}
+		return limit == 0 || len(result) < limit
+	}
+
+	start := from
+	for _, interval := range busy {
+		if !add(start, interval.Start) {
+			return result
+		}
+		start = interval.Stop
+	}
+	add(start, to)
+	return result
+}
@@ -0,0 +1,32 @@
+package app
+
+import "sync"
+
+// число блокировок пользователей, пользователи с одинаковым остатком от деления делят блокировку
+const userLockCount = 64
+
+// userLocks упорядочивает изменения событий пользователя с их публикацией в ленту.
+// Подписчик получает изменения только своих событий, поэтому порядок нужен в пределах
+// пользователя, а изменения разных пользователей идут параллельно. От гонок между
+// процессами защищает хранилище, а лента видит только изменения своего процесса.
+type userLocks [userLockCount]sync.Mutex
+
+// lock захватывает блокировку пользователя и возвращает функцию ее освобождения.
+func (l *userLocks) lock(userID int) func() {
+	mu := &l[uint(userID)%userLockCount]
+	mu.Lock()
+	return mu.Unlock
+}
+
+// lockAll захватывает блокировки всех пользователей для изменений событий многих пользователей.
+// Блокировки захватываются по порядку, поэтому взаимоблокировок с lock не бывает.
+func (l *userLocks) lockAll() func() {
+	for i := range l {
+		l[i].Lock()
+	}
+	return func() {
+		for i := range l {
+			l[i].Unlock()
+		}
+	}
+}
@@ -3,33 +3,163 @@ package app
 import (
 	"context"
 	"errors"
+	"io"
 	"time"
 
+	"github.com/anfilat/otus-go/hw12_13_14_15_calendar/internal/ical"
 	"github.com/anfilat/otus-go/hw12_13_14_15_calendar/internal/logger"
 	"github.com/anfilat/otus-go/hw12_13_14_15_calendar/internal/storage"
 )
 
-type ListEvents func(ctx context.Context, date time.Time) ([]storage.Event, error)
+// ListEvents возвращает события пользователя за день, неделю или месяц, содержащие date.
+// Границы считаются в часовом поясе IANA timeZone, а если он пустой - в поясе из настроек
+// пользователя или, если и он не задан, в поясе date.
+type ListEvents func(ctx context.Context, userID int, date time.Time, timeZone string) ([]storage.Event, error)
 
 type App interface {
-	Create(ctx context.Context, userID int, title, desc string, start, stop time.Time, notif *time.Duration) (id int, err error)
-	Update(ctx context.Context, id int, change storage.Event) error
-	Delete(ctx context.Context, id int) error
-	DeleteAll(ctx context.Context) error
-	ListAll(ctx context.Context) ([]storage.Event, error)
-	ListDay(ctx context.Context, date time.Time) ([]storage.Event, error)
-	ListWeek(ctx context.Context, date time.Time) ([]storage.Event, error)
-	ListMonth(ctx context.Context, date time.Time) ([]storage.Event, error)
+	Create(
+		ctx context.Context,
+		userID int,
+		title, desc string,
+		start, stop time.Time,
+		// событие на весь день, из start и stop берутся даты, см. storage.AllDayDates
+		allDay bool,
+		notif *time.Duration,
+		rec *storage.Recurrence,
+	) (id int, err error)
+	// Update изменяет событие версии change.Version и возвращает новую версию.
+	Update(ctx context.Context, userID int, id int, change storage.Event) (version int, err error)
+	// Delete удаляет событие, для отсутствующего возвращает storage.ErrNotExistsEvent.
+	Delete(ctx context.Context, userID int, id int) error
+	// DeleteAll удаляет события пользователя, а при userID = 0 - события всех пользователей.
+	DeleteAll(ctx context.Context, userID int) error
+	Get(ctx context.Context, userID int, id int) (storage.Event, error)
+	// ListAll возвращает события пользователя, а при userID = 0 - события всех пользователей.
+	ListAll(ctx context.Context, userID int) ([]storage.Event, error)
+	// ListBefore возвращает события всех пользователей, закончившиеся раньше date.
+	ListBefore(ctx context.Context, date time.Time) ([]storage.Event, error)
+	// DeleteBefore удаляет события всех пользователей, закончившиеся раньше date,
+	// и возвращает число удаленных.
+	DeleteBefore(ctx context.Context, date time.Time) (int, error)
+	// ListDay, ListWeek и ListMonth - см. ListEvents.
+	ListDay(ctx context.Context, userID int, date time.Time, timeZone string) ([]storage.Event, error)
+	ListWeek(ctx context.Context, userID int, date time.Time, timeZone string) ([]storage.Event, error)
+	ListMonth(ctx context.Context, userID int, date time.Time, timeZone string) ([]storage.Event, error)
+	ListRange(ctx context.Context, userID int, from, to time.Time, filter storage.Filter) (storage.Page, error)
+	FreeBusy(
+		ctx context.Context,
+		userIDs []int,
+		from, to time.Time,
+		duration time.Duration,
+		limit int,
+	) (FreeBusy, error)
+	Export(ctx context.Context, w io.Writer, userID int, from, to time.Time) error
+	Import(ctx context.Context, r io.Reader, userID int) ([]ImportResult, error)
+	// Watch возвращает канал изменений событий пользователя. Канал закрывается при отмене ctx
+	// или если подписчик не успевает читать, тогда подписку надо возобновить с токеном
+	// последнего полученного изменения. head - токен последнего изменения на момент подписки:
+	// с ним подписка возобновляется без пропусков, даже если изменений еще не было получено.
+	Watch(ctx context.Context, userID int, filter WatchFilter, token string) (changes <-chan Change, head string, err error)
+	GetSettings(ctx context.Context, userID int) (storage.UserSettings, error)
+	// SaveSettings проверяет и сохраняет настройки пользователя.
+	SaveSettings(ctx context.Context, userID int, settings storage.UserSettings) error
+}
+
+type ChangeType int
+
+const (
+	ChangeCreated ChangeType = iota + 1
+	ChangeUpdated
+	ChangeDeleted
+)
+
+// Change - изменение события. Token позволяет возобновить подписку после этого изменения.
+type Change struct {
+	Type  ChangeType
+	Event storage.Event
+	Token string
+}
+
+// WatchFilter - интервал [From, To), нулевые From и To не ограничивают интервал.
+type WatchFilter struct {
+	From time.Time
+	To   time.Time
+}
+
+// Interval - промежуток времени [Start, Stop).
+type Interval struct {
+	Start time.Time
+	Stop  time.Time
+}
+
+// UserBusy - занятые промежутки пользователя, упорядоченные и не пересекающиеся.
+type UserBusy struct {
+	UserID int
+	Busy   []Interval
+}
+
+// FreeBusy - занятость пользователей и общие для них свободные промежутки.
+type FreeBusy struct {
+	Busy []UserBusy
+	Free []Interval
+}
+
+// ImportResult - результат импорта одного события: ID созданного события или ошибка.
+type ImportResult struct {
+	UID string
+	ID  int
+	Err error
 }
 
 func New(logger logger.Logger, storage storage.Storage) App {
-	return &app{
-		logger,
-		storage,
+	return &tracedApp{
+		app: &app{
+			logger:  logger,
+			storage: storage,
+			feed:    newFeed(),
+		},
 	}
 }
 
 var ErrNoUserID = errors.New("no user id of the event")
 var ErrEmptyTitle = errors.New("no title of the event")
 var ErrStartInPast = errors.New("start time of the event in the past")
-var ErrDateBusy = errors.New("this time is already occupied by another event")
+var ErrDateBusy = storage.ErrDateBusy
+var ErrNoVersion = errors.New("no version of the event")
+var ErrForbidden = errors.New("the event belongs to another user")
+var ErrInvalidRange = errors.New("the end of the range is not after its start")
+var ErrInvalidDuration = errors.New("the duration is not positive")
+var ErrInvalidToken = errors.New("invalid resume token")
+var ErrTokenExpired = errors.New("the resume token has expired")
+var ErrInvalidTimeZone = errors.New("unknown time zone")
+
+// ошибки неверных данных запроса
+var validationErrors = []error{
+	ErrNoUserID,
+	ErrEmptyTitle,
+	ErrStartInPast,
+	ErrNoVersion,
+	ErrInvalidRange,
+	ErrInvalidDuration,
+	ErrInvalidToken,
+	ErrInvalidTimeZone,
+	storage.ErrInvalidRecurrence,
+	storage.ErrInvalidCursor,
+	ical.ErrNoCalendar,
+}
+
+// IsValidationError проверяет, что ошибка вызвана неверными данными запроса.
+func IsValidationError(err error) bool {
+	for _, target := range validationErrors {
+		if errors.Is(err, target) {
+			return true
+		}
+	}
+	return false
+}
+
+// размер страницы ListRange по умолчанию и максимальный
+const (
+	DefaultPageSize = 100
+	MaxPageSize     = 1000
+)
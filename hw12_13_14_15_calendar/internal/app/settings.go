@@ -0,0 +1,45 @@
+package app
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/anfilat/otus-go/hw12_13_14_15_calendar/internal/storage"
+)
+
+func (a *app) GetSettings(ctx context.Context, userID int) (storage.UserSettings, error) {
+	if userID == 0 {
+		return storage.UserSettings{}, ErrNoUserID
+	}
+	return a.storage.GetSettings(ctx, userID)
+}
+
+func (a *app) SaveSettings(ctx context.Context, userID int, settings storage.UserSettings) error {
+	if userID == 0 {
+		return ErrNoUserID
+	}
+	if settings.TimeZone != "" {
+		if _, err := loadLocation(settings.TimeZone); err != nil {
+			return err
+		}
+	}
+	if err := a.storage.SaveSettings(ctx, userID, settings); err != nil {
+		return err
+	}
+	a.log(ctx).WithField("time_zone", settings.TimeZone).Debug("settings saved")
+	return nil
+}
+
+// loadLocation возвращает часовой пояс IANA. Пояс сервера "Local" не принимается:
+// результат запроса не должен зависеть от того, где запущен сервис.
+func loadLocation(name string) (*time.Location, error) {
+	if name == "Local" {
+		return nil, fmt.Errorf("%w: %q", ErrInvalidTimeZone, name)
+	}
+	location, err := time.LoadLocation(name)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %q", ErrInvalidTimeZone, name)
+	}
+	return location, nil
+}
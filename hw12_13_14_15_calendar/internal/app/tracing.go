@@ -0,0 +1,179 @@
+package app
+
+import (
+	"context"
+	"io"
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+
+	"github.com/anfilat/otus-go/hw12_13_14_15_calendar/internal/storage"
+	"github.com/anfilat/otus-go/hw12_13_14_15_calendar/internal/tracing"
+)
+
+// tracedApp создает спан на каждый вызов методов приложения.
+type tracedApp struct {
+	app App
+}
+
+var (
+	userIDKey   = attribute.Key("calendar.user_id")
+	eventIDKey  = attribute.Key("calendar.event_id")
+	timeZoneKey = attribute.Key("calendar.time_zone")
+)
+
+func startSpan(ctx context.Context, name string, attrs ...attribute.KeyValue) (context.Context, trace.Span) {
+	return tracing.Start(ctx, "app."+name, trace.WithAttributes(attrs...))
+}
+
+func (t *tracedApp) Create(
+	ctx context.Context,
+	userID int,
+	title, desc string,
+	start, stop time.Time,
+	allDay bool,
+	notif *time.Duration,
+	rec *storage.Recurrence,
+) (id int, err error) {
+	ctx, span := startSpan(ctx, "Create", userIDKey.Int(userID))
+	defer func() { tracing.End(span, err) }()
+	id, err = t.app.Create(ctx, userID, title, desc, start, stop, allDay, notif, rec)
+	span.SetAttributes(eventIDKey.Int(id))
+	return
+}
+
+func (t *tracedApp) Update(ctx context.Context, userID int, id int, change storage.Event) (version int, err error) {
+	ctx, span := startSpan(ctx, "Update", userIDKey.Int(userID), eventIDKey.Int(id))
+	defer func() { tracing.End(span, err) }()
+	return t.app.Update(ctx, userID, id, change)
+}
+
+func (t *tracedApp) Delete(ctx context.Context, userID int, id int) (err error) {
+	ctx, span := startSpan(ctx, "Delete", userIDKey.Int(userID), eventIDKey.Int(id))
+	defer func() { tracing.End(span, err) }()
+	return t.app.Delete(ctx, userID, id)
+}
+
+func (t *tracedApp) DeleteAll(ctx context.Context, userID int) (err error) {
+	ctx, span := startSpan(ctx, "DeleteAll", userIDKey.Int(userID))
+	defer func() { tracing.End(span, err) }()
+	return t.app.DeleteAll(ctx, userID)
+}
+
+func (t *tracedApp) Get(ctx context.Context, userID int, id int) (event storage.Event, err error) {
+	ctx, span := startSpan(ctx, "Get", userIDKey.Int(userID), eventIDKey.Int(id))
+	defer func() { tracing.End(span, err) }()
+	return t.app.Get(ctx, userID, id)
+}
+
+func (t *tracedApp) ListAll(ctx context.Context, userID int) (events []storage.Event, err error) {
+	ctx, span := startSpan(ctx, "ListAll", userIDKey.Int(userID))
+	defer func() { tracing.End(span, err) }()
+	return t.app.ListAll(ctx, userID)
+}
+
+func (t *tracedApp) ListBefore(ctx context.Context, date time.Time) (events []storage.Event, err error) {
+	ctx, span := startSpan(ctx, "ListBefore")
+	defer func() { tracing.End(span, err) }()
+	return t.app.ListBefore(ctx, date)
+}
+
+func (t *tracedApp) DeleteBefore(ctx context.Context, date time.Time) (count int, err error) {
+	ctx, span := startSpan(ctx, "DeleteBefore")
+	defer func() { tracing.End(span, err) }()
+	return t.app.DeleteBefore(ctx, date)
+}
+
+func (t *tracedApp) ListDay(
+	ctx context.Context,
+	userID int,
+	date time.Time,
+	timeZone string,
+) (events []storage.Event, err error) {
+	ctx, span := startSpan(ctx, "ListDay", userIDKey.Int(userID), timeZoneKey.String(timeZone))
+	defer func() { tracing.End(span, err) }()
+	return t.app.ListDay(ctx, userID, date, timeZone)
+}
+
+func (t *tracedApp) ListWeek(
+	ctx context.Context,
+	userID int,
+	date time.Time,
+	timeZone string,
+) (events []storage.Event, err error) {
+	ctx, span := startSpan(ctx, "ListWeek", userIDKey.Int(userID), timeZoneKey.String(timeZone))
+	defer func() { tracing.End(span, err) }()
+	return t.app.ListWeek(ctx, userID, date, timeZone)
+}
+
+func (t *tracedApp) ListMonth(
+	ctx context.Context,
+	userID int,
+	date time.Time,
+	timeZone string,
+) (events []storage.Event, err error) {
+	ctx, span := startSpan(ctx, "ListMonth", userIDKey.Int(userID), timeZoneKey.String(timeZone))
+	defer func() { tracing.End(span, err) }()
+	return t.app.ListMonth(ctx, userID, date, timeZone)
+}
+
+func (t *tracedApp) ListRange(
+	ctx context.Context,
+	userID int,
+	from, to time.Time,
+	filter storage.Filter,
+) (page storage.Page, err error) {
+	ctx, span := startSpan(ctx, "ListRange", userIDKey.Int(userID))
+	defer func() { tracing.End(span, err) }()
+	return t.app.ListRange(ctx, userID, from, to, filter)
+}
+
+func (t *tracedApp) FreeBusy(
+	ctx context.Context,
+	userIDs []int,
+	from, to time.Time,
+	duration time.Duration,
+	limit int,
+) (result FreeBusy, err error) {
+	ctx, span := startSpan(ctx, "FreeBusy")
+	defer func() { tracing.End(span, err) }()
+	return t.app.FreeBusy(ctx, userIDs, from, to, duration, limit)
+}
+
+func (t *tracedApp) Export(ctx context.Context, w io.Writer, userID int, from, to time.Time) (err error) {
+	ctx, span := startSpan(ctx, "Export", userIDKey.Int(userID))
+	defer func() { tracing.End(span, err) }()
+	return t.app.Export(ctx, w, userID, from, to)
+}
+
+func (t *tracedApp) Import(ctx context.Context, r io.Reader, userID int) (results []ImportResult, err error) {
+	ctx, span := startSpan(ctx, "Import", userIDKey.Int(userID))
+	defer func() { tracing.End(span, err) }()
+	return t.app.Import(ctx, r, userID)
+}
+
+// Watch отмечает в спане только оформление подписки, а не весь поток изменений.
+func (t *tracedApp) Watch(
+	ctx context.Context,
+	userID int,
+	filter WatchFilter,
+	token string,
+) (changes <-chan Change, head string, err error) {
+	// подписка живет дольше спана, поэтому ей передается исходный контекст
+	_, span := startSpan(ctx, "Watch", userIDKey.Int(userID))
+	defer func() { tracing.End(span, err) }()
+	return t.app.Watch(ctx, userID, filter, token)
+}
+
+func (t *tracedApp) GetSettings(ctx context.Context, userID int) (settings storage.UserSettings, err error) {
+	ctx, span := startSpan(ctx, "GetSettings", userIDKey.Int(userID))
+	defer func() { tracing.End(span, err) }()
+	return t.app.GetSettings(ctx, userID)
+}
+
+func (t *tracedApp) SaveSettings(ctx context.Context, userID int, settings storage.UserSettings) (err error) {
+	ctx, span := startSpan(ctx, "SaveSettings", userIDKey.Int(userID), timeZoneKey.String(settings.TimeZone))
+	defer func() { tracing.End(span, err) }()
+	return t.app.SaveSettings(ctx, userID, settings)
+}
@@ -0,0 +1,190 @@
+package app
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/anfilat/otus-go/hw12_13_14_15_calendar/internal/storage"
+)
+
+// размер истории изменений, по которой возобновляется подписка,
+// и буфер канала подписчика сверх догоняемых изменений
+const (
+	watchHistorySize = 1000
+	watchBufferSize  = 100
+)
+
+// feed - лента изменений событий в памяти процесса.
+// Каждое изменение получает номер, по которому подписка возобновляется без пропусков.
+// Номера действуют только в пределах одного запуска, поэтому в токене есть epoch.
+type feed struct {
+	mu          sync.Mutex
+	epoch       int64
+	seq         uint64
+	history     []feedEntry
+	subscribers map[*subscriber]struct{}
+}
+
+type feedEntry struct {
+	seq    uint64
+	change Change
+	// событие до изменения, чтобы подписчик на интервал узнал об уходе события из него
+	prev *storage.Event
+}
+
+type subscriber struct {
+	userID int
+	filter WatchFilter
+	ch     chan Change
+}
+
+func newFeed() *feed {
+	return &feed{
+		epoch:       time.Now().UnixNano(),
+		subscribers: make(map[*subscriber]struct{}),
+	}
+}
+
+func (f *feed) publish(changeType ChangeType, event storage.Event, prev *storage.Event) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.seq++
+	entry := feedEntry{
+		seq: f.seq,
+		change: Change{
+			Type:  changeType,
+			Event: event,
+			Token: f.token(f.seq),
+		},
+		prev: prev,
+	}
+	f.history = append(f.history, entry)
+	if len(f.history) > watchHistorySize {
+		f.history = f.history[len(f.history)-watchHistorySize:]
+	}
+
+	for sub := range f.subscribers {
+		if !sub.match(entry) {
+			continue
+		}
+		select {
+		case sub.ch <- entry.change:
+		default:
+			// подписчик не успевает читать - закрываем канал, он переподключится с последним токеном
+			delete(f.subscribers, sub)
+			close(sub.ch)
+		}
+	}
+}
+
+func (f *feed) subscribe(
+	ctx context.Context,
+	userID int,
+	filter WatchFilter,
+	token string,
+) (<-chan Change, string, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	sub := &subscriber{
+		userID: userID,
+		filter: filter,
+	}
+	var backlog []Change
+	if token != "" {
+		seq, err := f.parseToken(token)
+		if err != nil {
+			return nil, "", err
+		}
+		if len(f.history) > 0 && f.history[0].seq > seq+1 {
+			return nil, "", ErrTokenExpired
+		}
+		for _, entry := range f.history {
+			if entry.seq > seq && sub.match(entry) {
+				backlog = append(backlog, entry.change)
+			}
+		}
+	}
+
+	sub.ch = make(chan Change, len(backlog)+watchBufferSize)
+	for _, change := range backlog {
+		sub.ch <- change
+	}
+	f.subscribers[sub] = struct{}{}
+
+	go func() {
+		<-ctx.Done()
+		f.unsubscribe(sub)
+	}()
+	return sub.ch, f.token(f.seq), nil
+}
+
+func (f *feed) unsubscribe(sub *subscriber) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if _, ok := f.subscribers[sub]; ok {
+		delete(f.subscribers, sub)
+		close(sub.ch)
+	}
+}
+
+// token возвращает токен изменения с номером seq, f.mu должен быть захвачен.
+func (f *feed) token(seq uint64) string {
+	return strconv.FormatInt(f.epoch, 36) + "." + strconv.FormatUint(seq, 36)
+}
+
+func (f *feed) parseToken(token string) (uint64, error) {
+	parts := strings.Split(token, ".")
+	if len(parts) != 2 {
+		return 0, ErrInvalidToken
+	}
+	epoch, err := strconv.ParseInt(parts[0], 36, 64)
+	if err != nil {
+		return 0, fmt.Errorf("%w: %s", ErrInvalidToken, err)
+	}
+	seq, err := strconv.ParseUint(parts[1], 36, 64)
+	if err != nil {
+		return 0, fmt.Errorf("%w: %s", ErrInvalidToken, err)
+	}
+	if epoch != f.epoch {
+		// токен выдан до перезапуска сервиса
+		return 0, ErrTokenExpired
+	}
+	if seq > f.seq {
+		return 0, ErrInvalidToken
+	}
+	return seq, nil
+}
+
+func (s *subscriber) match(entry feedEntry) bool {
+	if entry.change.Event.UserID != s.userID {
+		return false
+	}
+	if isInRange(entry.change.Event, s.filter.From, s.filter.To) {
+		return true
+	}
+	return entry.prev != nil && isInRange(*entry.prev, s.filter.From, s.filter.To)
+}
+
+// Watch подписывает на изменения событий пользователя, пересекающихся с интервалом фильтра.
+// С непустым token сначала отдаются изменения, сделанные после изменения с этим токеном.
+func (a *app) Watch(
+	ctx context.Context,
+	userID int,
+	filter WatchFilter,
+	token string,
+) (<-chan Change, string, error) {
+	if userID == 0 {
+		return nil, "", ErrNoUserID
+	}
+	if !filter.To.IsZero() && !filter.To.After(filter.From) {
+		return nil, "", ErrInvalidRange
+	}
+	return a.feed.subscribe(ctx, userID, filter, token)
+}
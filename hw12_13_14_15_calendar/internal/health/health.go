@@ -0,0 +1,32 @@
+package health
+
+import (
+	"context"
+	"fmt"
+	"sync/atomic"
+)
+
+type health struct {
+	checks   []Check
+	notReady int32
+}
+
+func newHealth(checks []Check) *health {
+	return &health{checks: checks}
+}
+
+func (h *health) Ready(ctx context.Context) error {
+	if atomic.LoadInt32(&h.notReady) != 0 {
+		return ErrShuttingDown
+	}
+	for _, check := range h.checks {
+		if err := check.Check(ctx); err != nil {
+			return fmt.Errorf("%s: %w", check.Name, err)
+		}
+	}
+	return nil
+}
+
+func (h *health) SetNotReady() {
+	atomic.StoreInt32(&h.notReady, 1)
+}
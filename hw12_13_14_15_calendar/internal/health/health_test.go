@@ -0,0 +1,29 @@
+package health
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestReady(t *testing.T) {
+	ctx := context.Background()
+	errDown := errors.New("down")
+	var storageErr error
+	h := New(Check{Name: "storage", Check: func(_ context.Context) error { return storageErr }})
+
+	require.NoError(t, h.Ready(ctx))
+
+	storageErr = errDown
+	err := h.Ready(ctx)
+	require.True(t, errors.Is(err, errDown))
+	require.Contains(t, err.Error(), "storage")
+
+	storageErr = nil
+	require.NoError(t, h.Ready(ctx))
+
+	h.SetNotReady()
+	require.True(t, errors.Is(h.Ready(ctx), ErrShuttingDown))
+}
@@ -0,0 +1,30 @@
+// Package health хранит состояние готовности календаря к приему запросов.
+// Живость (liveness) означает только то, что процесс отвечает, готовность (readiness) -
+// что доступны зависимости и не начата остановка.
+package health
+
+import (
+	"context"
+	"errors"
+)
+
+var ErrShuttingDown = errors.New("the calendar is shutting down")
+
+// Check проверяет зависимость, например доступность хранилища.
+type Check struct {
+	Name  string
+	Check func(ctx context.Context) error
+}
+
+type Health interface {
+	// Ready возвращает nil, если календарь готов принимать запросы,
+	// иначе ErrShuttingDown или ошибку первой не прошедшей проверки.
+	Ready(ctx context.Context) error
+	// SetNotReady вызывается в начале остановки, чтобы балансировщики
+	// перестали направлять запросы до остановки серверов.
+	SetNotReady()
+}
+
+func New(checks ...Check) Health {
+	return newHealth(checks)
+}
@@ -0,0 +1,316 @@
+package ical
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/anfilat/otus-go/hw12_13_14_15_calendar/internal/storage"
+)
+
+// Decode разбирает календарь iCalendar (RFC 5545) и возвращает все его события VEVENT.
+// Время без TZID ("плавающее") считается в поясе loc, при nil - в UTC.
+// Ошибки отдельных событий возвращаются в Entry.Err и не прерывают разбор.
+func Decode(r io.Reader, loc *time.Location) ([]Entry, error) {
+	if loc == nil {
+		loc = time.UTC
+	}
+
+	lines, err := readLines(r)
+	if err != nil {
+		return nil, err
+	}
+
+	var result []Entry
+	var current *eventBuilder
+	found := false
+	inAlarm := false
+	for _, line := range lines {
+		if line == "" {
+			continue
+		}
+		prop, err := parseLine(line)
+		if err != nil {
+			if current != nil {
+				current.fail(err)
+			}
+			continue
+		}
+
+		switch {
+		case prop.name == "BEGIN" && strings.EqualFold(prop.value, "VCALENDAR"):
+			found = true
+		case prop.name == "BEGIN" && strings.EqualFold(prop.value, "VEVENT"):
+			current = &eventBuilder{loc: loc}
+			inAlarm = false
+		case prop.name == "END" && strings.EqualFold(prop.value, "VEVENT"):
+			if current != nil {
+				result = append(result, current.entry())
+				current = nil
+			}
+		case current == nil:
+		case prop.name == "BEGIN" && strings.EqualFold(prop.value, "VALARM"):
+			inAlarm = true
+		case prop.name == "END" && strings.EqualFold(prop.value, "VALARM"):
+			inAlarm = false
+		case inAlarm:
+			current.alarmProperty(prop)
+		default:
+			current.property(prop)
+		}
+	}
+
+	if !found {
+		return nil, ErrNoCalendar
+	}
+	return result, nil
+}
+
+// readLines читает строки, склеивая свернутые.
+func readLines(r io.Reader) ([]string, error) {
+	var result []string
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		line := strings.TrimRight(scanner.Text(), "\r")
+		if len(result) > 0 && (strings.HasPrefix(line, " ") || strings.HasPrefix(line, "\t")) {
+			result[len(result)-1] += line[1:]
+			continue
+		}
+		result = append(result, line)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("ical read: %w", err)
+	}
+	return result, nil
+}
+
+type property struct {
+	name   string
+	params map[string]string
+	value  string
+}
+
+// parseLine разбирает строку вида NAME;PARAM=VALUE;PARAM="VALUE":VALUE.
+func parseLine(line string) (property, error) {
+	prop := property{params: map[string]string{}}
+
+	end := strings.IndexAny(line, ";:")
+	if end <= 0 {
+		return prop, fmt.Errorf("invalid line %q", line)
+	}
+	prop.name = strings.ToUpper(line[:end])
+
+	rest := line[end:]
+	for strings.HasPrefix(rest, ";") {
+		rest = rest[1:]
+		eq := strings.IndexByte(rest, '=')
+		if eq <= 0 {
+			return prop, fmt.Errorf("invalid parameter in %q", line)
+		}
+		key := strings.ToUpper(rest[:eq])
+		rest = rest[eq+1:]
+
+		var value string
+		if strings.HasPrefix(rest, `"`) {
+			closing := strings.IndexByte(rest[1:], '"')
+			if closing < 0 {
+				return prop, fmt.Errorf("invalid parameter in %q", line)
+			}
+			value = rest[1 : closing+1]
+			rest = rest[closing+2:]
+		} else {
+			stop := strings.IndexAny(rest, ";:")
+			if stop < 0 {
+				return prop, fmt.Errorf("invalid parameter in %q", line)
+			}
+			value = rest[:stop]
+			rest = rest[stop:]
+		}
+		prop.params[key] = value
+	}
+
+	if !strings.HasPrefix(rest, ":") {
+		return prop, fmt.Errorf("invalid line %q", line)
+	}
+	prop.value = rest[1:]
+	return prop, nil
+}
+
+type eventBuilder struct {
+	loc          *time.Location
+	uid          string
+	event        storage.Event
+	hasStop      bool
+	isDate       bool
+	duration     *time.Duration
+	rrule        string
+	exceptions   []time.Time
+	notification *time.Duration
+	err          error
+}
+
+func (b *eventBuilder) fail(err error) {
+	if b.err == nil {
+		b.err = err
+	}
+}
+
+func (b *eventBuilder) property(prop property) {
+	var err error
+	switch prop.name {
+	case "UID":
+		b.uid = prop.value
+	case "SUMMARY":
+		b.event.Title = unescapeText(prop.value)
+	case "DESCRIPTION":
+		b.event.Description = unescapeText(prop.value)
+	case "DTSTART":
+		b.event.Start, err = parseTime(prop.value, prop.params, b.loc)
+		b.isDate = isDateValue(prop.value, prop.params)
+	case "DTEND":
+		b.event.Stop, err = parseTime(prop.value, prop.params, b.loc)
+		b.hasStop = true
+	case "DURATION":
+		var d time.Duration
+		d, err = parseDuration(prop.value)
+		b.duration = &d
+	case "RRULE":
+		b.rrule = prop.value
+	case "EXDATE":
+		for _, value := range strings.Split(prop.value, ",") {
+			var date time.Time
+			date, err = parseTime(value, prop.params, b.loc)
+			if err != nil {
+				break
+			}
+			b.exceptions = append(b.exceptions, date)
+		}
+	}
+	if err != nil {
+		b.fail(fmt.Errorf("%s: %w", prop.name, err))
+	}
+}
+
+// alarmProperty учитывает только первое напоминание относительно начала события.
+func (b *eventBuilder) alarmProperty(prop property) {
+	if prop.name != "TRIGGER" || b.notification != nil {
+		return
+	}
+	if prop.params["VALUE"] == "DATE-TIME" || prop.params["RELATED"] == "END" {
+		return
+	}
+	d, err := parseDuration(prop.value)
+	if err != nil {
+		b.fail(fmt.Errorf("TRIGGER: %w", err))
+		return
+	}
+	if d > 0 {
+		return
+	}
+	notification := -d
+	b.notification = &notification
+}
+
+func (b *eventBuilder) entry() Entry {
+	entry := Entry{UID: b.uid, Err: b.err}
+	if entry.Err == nil && b.event.Start.IsZero() {
+		entry.Err = errors.New("no DTSTART")
+	}
+	if entry.Err != nil {
+		entry.Event = b.event
+		return entry
+	}
+
+	switch {
+	case b.hasStop:
+	case b.duration != nil:
+		b.event.Stop = b.event.Start.Add(*b.duration)
+	case b.isDate:
+		b.event.Stop = b.event.Start.AddDate(0, 0, 1)
+	default:
+		b.event.Stop = b.event.Start
+	}
+	if b.event.Stop.Before(b.event.Start) {
+		entry.Err = errors.New("DTEND before DTSTART")
+	}
+
+	// DTSTART без времени - событие на весь день
+	b.event.AllDay = b.isDate
+	b.event.Notification = b.notification
+	recurrence, err := storage.NewRecurrence(b.rrule, b.exceptions)
+	if err != nil {
+		entry.Err = fmt.Errorf("RRULE: %w", err)
+	}
+	b.event.Recurrence = recurrence
+
+	entry.Event = b.event
+	return entry
+}
+
+func isDateValue(value string, params map[string]string) bool {
+	return params["VALUE"] == "DATE" || len(value) == len(dateFormat)
+}
+
+func parseTime(value string, params map[string]string, loc *time.Location) (time.Time, error) {
+	if tzid, ok := params["TZID"]; ok {
+		var err error
+		loc, err = time.LoadLocation(strings.TrimPrefix(tzid, "/"))
+		if err != nil {
+			return time.Time{}, fmt.Errorf("unknown time zone %q", tzid)
+		}
+	}
+
+	switch {
+	case isDateValue(value, params):
+		return time.ParseInLocation(dateFormat, value, loc)
+	case strings.HasSuffix(value, "Z"):
+		return time.Parse(dateTimeFormat, value)
+	default:
+		return time.ParseInLocation(strings.TrimSuffix(dateTimeFormat, "Z"), value, loc)
+	}
+}
+
+var durationRe = regexp.MustCompile(`^([+-])?P(?:(\d+)W)?(?:(\d+)D)?(?:T(?:(\d+)H)?(?:(\d+)M)?(?:(\d+)S)?)?$`)
+
+// parseDuration разбирает длительность вида "-P1DT2H3M4S" или "P2W".
+func parseDuration(value string) (time.Duration, error) {
+	match := durationRe.FindStringSubmatch(value)
+	if match == nil || value == "P" || strings.HasSuffix(value, "T") {
+		return 0, fmt.Errorf("invalid duration %q", value)
+	}
+
+	units := []time.Duration{7 * 24 * time.Hour, 24 * time.Hour, time.Hour, time.Minute, time.Second}
+	var result time.Duration
+	for i, unit := range units {
+		if match[i+2] == "" {
+			continue
+		}
+		n, err := strconv.Atoi(match[i+2])
+		if err != nil {
+			return 0, fmt.Errorf("invalid duration %q", value)
+		}
+		result += time.Duration(n) * unit
+	}
+	if match[1] == "-" {
+		result = -result
+	}
+	return result, nil
+}
+
+var textUnescaper = strings.NewReplacer(
+	`\\`, `\`,
+	`\;`, ";",
+	`\,`, ",",
+	`\n`, "\n",
+	`\N`, "\n",
+)
+
+func unescapeText(s string) string {
+	return textUnescaper.Replace(s)
+}
@@ -0,0 +1,165 @@
+package ical
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/anfilat/otus-go/hw12_13_14_15_calendar/internal/storage"
+)
+
+const (
+	dateTimeFormat = "20060102T150405Z"
+	dateFormat     = "20060102"
+	// максимальная длина строки в октетах без CRLF
+	lineLength = 75
+)
+
+// Encode записывает события в формате iCalendar (RFC 5545).
+// now используется как DTSTAMP.
+func Encode(w io.Writer, events []storage.Event, now time.Time) error {
+	bw := bufio.NewWriter(w)
+	e := encoder{w: bw}
+
+	e.line("BEGIN:VCALENDAR")
+	e.line("VERSION:2.0")
+	e.line("PRODID:-//otus-go//calendar//RU")
+	for _, event := range events {
+		e.event(event, now)
+	}
+	e.line("END:VCALENDAR")
+
+	if e.err != nil {
+		return e.err
+	}
+	return bw.Flush()
+}
+
+type encoder struct {
+	w   *bufio.Writer
+	err error
+}
+
+func (e *encoder) event(event storage.Event, now time.Time) {
+	e.line("BEGIN:VEVENT")
+	e.line("UID:" + formatUID(event.ID))
+	e.line("DTSTAMP:" + formatDateTime(now))
+	format, dateParam := formatDateTime, ""
+	if event.AllDay {
+		format, dateParam = formatDate, ";VALUE=DATE"
+	}
+	e.line("DTSTART" + dateParam + ":" + format(event.Start))
+	e.line("DTEND" + dateParam + ":" + format(event.Stop))
+	e.line("SUMMARY:" + escapeText(event.Title))
+	if event.Description != "" {
+		e.line("DESCRIPTION:" + escapeText(event.Description))
+	}
+	if r := event.Recurrence; r != nil {
+		e.line("RRULE:" + r.RRule())
+		if len(r.Exceptions) > 0 {
+			dates := make([]string, 0, len(r.Exceptions))
+			for _, date := range r.Exceptions {
+				dates = append(dates, format(date))
+			}
+			e.line("EXDATE" + dateParam + ":" + strings.Join(dates, ","))
+		}
+	}
+	if event.Notification != nil {
+		e.line("BEGIN:VALARM")
+		e.line("ACTION:DISPLAY")
+		e.line("DESCRIPTION:" + escapeText(event.Title))
+		e.line("TRIGGER:" + formatDuration(-*event.Notification))
+		e.line("END:VALARM")
+	}
+	e.line("END:VEVENT")
+}
+
+// line записывает строку, сворачивая ее по 75 октетов и не разрывая символы UTF-8.
+func (e *encoder) line(s string) {
+	if e.err != nil {
+		return
+	}
+
+	limit := lineLength
+	for len(s) > limit {
+		cut := limit
+		for cut > 0 && !utf8.RuneStart(s[cut]) {
+			cut--
+		}
+		e.write(s[:cut] + "\r\n ")
+		s = s[cut:]
+		// пробел в начале строки продолжения входит в ее длину
+		limit = lineLength - 1
+	}
+	e.write(s + "\r\n")
+}
+
+func (e *encoder) write(s string) {
+	if e.err != nil {
+		return
+	}
+	_, e.err = e.w.WriteString(s)
+}
+
+func formatUID(id int) string {
+	return strconv.Itoa(id) + "@calendar"
+}
+
+func formatDateTime(t time.Time) string {
+	return t.UTC().Format(dateTimeFormat)
+}
+
+// formatDate форматирует дату события на весь день, хранящуюся как полночь UTC.
+func formatDate(t time.Time) string {
+	return t.UTC().Format(dateFormat)
+}
+
+var textEscaper = strings.NewReplacer(
+	`\`, `\\`,
+	";", `\;`,
+	",", `\,`,
+	"\r\n", `\n`,
+	"\n", `\n`,
+)
+
+func escapeText(s string) string {
+	return textEscaper.Replace(s)
+}
+
+// formatDuration форматирует длительность в виде "-P1DT2H3M4S".
+func formatDuration(d time.Duration) string {
+	var b strings.Builder
+	if d < 0 {
+		b.WriteByte('-')
+		d = -d
+	}
+	b.WriteByte('P')
+
+	days := d / (24 * time.Hour)
+	d -= days * 24 * time.Hour
+	if days > 0 {
+		fmt.Fprintf(&b, "%dD", days)
+	}
+	if d > 0 || days == 0 {
+		b.WriteByte('T')
+		hours := d / time.Hour
+		d -= hours * time.Hour
+		minutes := d / time.Minute
+		d -= minutes * time.Minute
+		seconds := d / time.Second
+		if hours > 0 {
+			fmt.Fprintf(&b, "%dH", hours)
+		}
+		if minutes > 0 {
+			fmt.Fprintf(&b, "%dM", minutes)
+		}
+		if seconds > 0 || (hours == 0 && minutes == 0) {
+			fmt.Fprintf(&b, "%dS", seconds)
+		}
+	}
+	return b.String()
+}
@@ -0,0 +1,196 @@
+package ical
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/anfilat/otus-go/hw12_13_14_15_calendar/internal/storage"
+)
+
+func TestEncodeDecode(t *testing.T) {
+	notification := 90 * time.Minute
+	recurrence, err := storage.NewRecurrence(
+		"FREQ=WEEKLY;BYDAY=MO,WE;COUNT=10",
+		[]time.Time{time.Date(2021, 2, 3, 10, 0, 0, 0, time.UTC)},
+	)
+	require.NoError(t, err)
+	events := []storage.Event{
+		{
+			ID:           1,
+			Title:        "Планёрка; обсуждение, итоги",
+			Start:        time.Date(2021, 2, 1, 10, 0, 0, 0, time.UTC),
+			Stop:         time.Date(2021, 2, 1, 10, 30, 0, 0, time.UTC),
+			Description:  strings.Repeat("Очень длинное описание\\события\n", 5),
+			UserID:       1,
+			Notification: &notification,
+			Recurrence:   recurrence,
+		},
+		{
+			ID:    2,
+			Title: "Обед",
+			Start: time.Date(2021, 2, 1, 13, 0, 0, 0, time.UTC),
+			Stop:  time.Date(2021, 2, 1, 14, 0, 0, 0, time.UTC),
+		},
+		{
+			ID:     3,
+			Title:  "Конференция",
+			Start:  time.Date(2021, 2, 10, 0, 0, 0, 0, time.UTC),
+			Stop:   time.Date(2021, 2, 13, 0, 0, 0, 0, time.UTC),
+			AllDay: true,
+		},
+	}
+
+	var buf bytes.Buffer
+	err = Encode(&buf, events, time.Date(2021, 1, 1, 0, 0, 0, 0, time.UTC))
+	require.NoError(t, err)
+
+	for _, line := range strings.Split(buf.String(), "\r\n") {
+		require.LessOrEqual(t, len(line), lineLength)
+	}
+	require.Contains(t, buf.String(), "TRIGGER:-PT1H30M\r\n")
+	require.Contains(t, buf.String(), "EXDATE:20210203T100000Z\r\n")
+	require.Contains(t, buf.String(), "DTSTART;VALUE=DATE:20210210\r\nDTEND;VALUE=DATE:20210213\r\n")
+
+	entries, err := Decode(&buf, nil)
+	require.NoError(t, err)
+	require.Equal(t, 3, len(entries))
+	for i, entry := range entries {
+		require.NoError(t, entry.Err)
+		event := events[i]
+		require.Equal(t, formatUID(event.ID), entry.UID)
+		require.Equal(t, event.Title, entry.Event.Title)
+		require.Equal(t, event.Description, entry.Event.Description)
+		require.Equal(t, event.AllDay, entry.Event.AllDay)
+		if event.AllDay {
+			// даты без времени читаются в местном часовом поясе
+			require.Equal(t, event.Start, storage.Date(entry.Event.Start))
+			require.Equal(t, event.Stop, storage.Date(entry.Event.Stop))
+		} else {
+			require.True(t, event.Start.Equal(entry.Event.Start))
+			require.True(t, event.Stop.Equal(entry.Event.Stop))
+		}
+		require.Equal(t, event.Notification, entry.Event.Notification)
+		if event.Recurrence == nil {
+			require.Nil(t, entry.Event.Recurrence)
+		} else {
+			require.Equal(t, event.Recurrence.RRule(), entry.Event.Recurrence.RRule())
+			require.Equal(t, 1, len(entry.Event.Recurrence.Exceptions))
+			require.True(t, event.Recurrence.Exceptions[0].Equal(entry.Event.Recurrence.Exceptions[0]))
+		}
+	}
+}
+
+const foreignCalendar = "BEGIN:VCALENDAR\r\n" +
+	"VERSION:2.0\r\n" +
+	"PRODID:-//Other//Calendar//EN\r\n" +
+	"BEGIN:VEVENT\r\n" +
+	"UID:with-tzid\r\n" +
+	"DTSTART;TZID=Europe/Moscow:20210201T100000\r\n" +
+	"DURATION:PT45M\r\n" +
+	"SUMMARY:Stand-up\r\n" +
+	"RRULE:FREQ=DAILY;BYDAY=MO,TU,WE,TH,FR\r\n" +
+	"EXDATE;TZID=Europe/Moscow:20210202T100000,20210203T100000\r\n" +
+	"BEGIN:VALARM\r\n" +
+	"TRIGGER;RELATED=START:-PT10M\r\n" +
+	"ACTION:DISPLAY\r\n" +
+	"END:VALARM\r\n" +
+	"END:VEVENT\r\n" +
+	"BEGIN:VEVENT\r\n" +
+	"UID:all-day\r\n" +
+	"DTSTART;VALUE=DATE:20210210\r\n" +
+	"SUMMARY:Конфе\r\n" +
+	" ренция\r\n" +
+	"END:VEVENT\r\n" +
+	"BEGIN:VEVENT\r\n" +
+	"UID:floating\r\n" +
+	"DTSTART:20210205T090000\r\n" +
+	"DTEND:20210205T100000\r\n" +
+	"SUMMARY:Floating\r\n" +
+	"END:VEVENT\r\n" +
+	"BEGIN:VEVENT\r\n" +
+	"UID:no-start\r\n" +
+	"SUMMARY:Broken\r\n" +
+	"END:VEVENT\r\n" +
+	"BEGIN:VEVENT\r\n" +
+	"UID:bad-rule\r\n" +
+	"DTSTART:20210201T100000Z\r\n" +
+	"RRULE:FREQ=HOURLY\r\n" +
+	"END:VEVENT\r\n" +
+	"END:VCALENDAR\r\n"
+
+func TestDecode(t *testing.T) {
+	moscow, err := time.LoadLocation("Europe/Moscow")
+	require.NoError(t, err)
+	entries, err := Decode(strings.NewReader(foreignCalendar), moscow)
+	require.NoError(t, err)
+	require.Equal(t, 5, len(entries))
+
+	entry := entries[0]
+	require.NoError(t, entry.Err)
+	require.Equal(t, "with-tzid", entry.UID)
+	require.False(t, entry.Event.AllDay)
+	require.True(t, time.Date(2021, 2, 1, 7, 0, 0, 0, time.UTC).Equal(entry.Event.Start))
+	require.Equal(t, 45*time.Minute, entry.Event.Stop.Sub(entry.Event.Start))
+	require.Equal(t, 10*time.Minute, *entry.Event.Notification)
+	require.Equal(t, "FREQ=DAILY;BYDAY=MO,TU,WE,TH,FR", entry.Event.Recurrence.RRule())
+	require.Equal(t, 2, len(entry.Event.Recurrence.Exceptions))
+	require.True(t, time.Date(2021, 2, 3, 10, 0, 0, 0, moscow).Equal(entry.Event.Recurrence.Exceptions[1]))
+
+	entry = entries[1]
+	require.NoError(t, entry.Err)
+	require.Equal(t, "Конференция", entry.Event.Title)
+	require.True(t, entry.Event.AllDay)
+	require.Equal(t, 24*time.Hour, entry.Event.Stop.Sub(entry.Event.Start))
+	require.Nil(t, entry.Event.Notification)
+
+	// время без TZID - в поясе пользователя
+	entry = entries[2]
+	require.NoError(t, entry.Err)
+	require.True(t, time.Date(2021, 2, 5, 9, 0, 0, 0, moscow).Equal(entry.Event.Start))
+	require.Equal(t, time.Hour, entry.Event.Stop.Sub(entry.Event.Start))
+
+	require.Error(t, entries[3].Err)
+	require.Equal(t, "no-start", entries[3].UID)
+	require.Error(t, entries[4].Err)
+
+	// без пояса пользователя - в UTC
+	entries, err = Decode(strings.NewReader(foreignCalendar), nil)
+	require.NoError(t, err)
+	require.True(t, time.Date(2021, 2, 5, 9, 0, 0, 0, time.UTC).Equal(entries[2].Event.Start))
+}
+
+func TestDecodeFail(t *testing.T) {
+	_, err := Decode(strings.NewReader("Hello, world\n"), nil)
+	require.Equal(t, ErrNoCalendar, err)
+}
+
+func TestDuration(t *testing.T) {
+	tests := []struct {
+		value    string
+		duration time.Duration
+	}{
+		{"PT0S", 0},
+		{"-PT15M", -15 * time.Minute},
+		{"P1DT2H3M4S", 26*time.Hour + 3*time.Minute + 4*time.Second},
+		{"-P2D", -48 * time.Hour},
+	}
+	for _, tt := range tests {
+		require.Equal(t, tt.value, formatDuration(tt.duration))
+		d, err := parseDuration(tt.value)
+		require.NoError(t, err)
+		require.Equal(t, tt.duration, d)
+	}
+
+	d, err := parseDuration("P1W")
+	require.NoError(t, err)
+	require.Equal(t, 7*24*time.Hour, d)
+
+	for _, value := range []string{"", "P", "PT", "1H", "PT1H2D"} {
+		_, err := parseDuration(value)
+		require.Error(t, err, value)
+	}
+}
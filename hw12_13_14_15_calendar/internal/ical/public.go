@@ -0,0 +1,19 @@
+package ical
+
+import (
+	"errors"
+
+	"github.com/anfilat/otus-go/hw12_13_14_15_calendar/internal/storage"
+)
+
+// Entry - событие VEVENT из импортируемого календаря. Если событие не удалось
+// разобрать, Err содержит причину, а Event заполнен частично.
+type Entry struct {
+	UID   string
+	Event storage.Event
+	Err   error
+}
+
+const ContentType = "text/calendar; charset=utf-8"
+
+var ErrNoCalendar = errors.New("no VCALENDAR in data")
@@ -1,25 +1,49 @@
 package logger
 
 import (
+	"crypto/rand"
+	"encoding/hex"
+
 	"github.com/sirupsen/logrus"
 )
 
 type logger struct {
-	logger *logrus.Logger
+	entry *logrus.Entry
 }
 
+type ctxKey struct{}
+
 func (l logger) Debug(args ...interface{}) {
-	l.logger.Debug(args...)
+	l.entry.Debug(args...)
 }
 
 func (l logger) Info(args ...interface{}) {
-	l.logger.Info(args...)
+	l.entry.Info(args...)
+}
+
+func (l logger) Warn(args ...interface{}) {
+	l.entry.Warn(args...)
 }
 
 func (l logger) Error(args ...interface{}) {
-	l.logger.Error(args...)
+	l.entry.Error(args...)
 }
 
 func (l logger) Fatal(args ...interface{}) {
-	l.logger.Fatal(args...)
+	l.entry.Fatal(args...)
+}
+
+func (l logger) WithField(key string, value interface{}) Logger {
+	return logger{entry: l.entry.WithField(key, value)}
+}
+
+func (l logger) WithFields(fields Fields) Logger {
+	return logger{entry: l.entry.WithFields(logrus.Fields(fields))}
+}
+
+func newRequestID() string {
+	var id [8]byte
+	// ошибка crypto/rand означает неработающий источник случайности, тогда ID просто нулевой
+	_, _ = rand.Read(id[:])
+	return hex.EncodeToString(id[:])
 }
@@ -0,0 +1,90 @@
+package logger
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestJSONFields(t *testing.T) {
+	var buf bytes.Buffer
+	logg, err := New(Options{Format: FormatJSON}, &buf)
+	require.NoError(t, err)
+
+	logg.WithField(RequestIDField, "abc").WithFields(Fields{UserIDField: 1, "event_id": 2}).Info("event created")
+
+	var record map[string]interface{}
+	require.NoError(t, json.Unmarshal(buf.Bytes(), &record))
+	require.Equal(t, "info", record["level"])
+	require.Equal(t, "event created", record["msg"])
+	require.Equal(t, "abc", record[RequestIDField])
+	require.Equal(t, float64(1), record[UserIDField])
+	require.Equal(t, float64(2), record["event_id"])
+}
+
+func TestWarnLevel(t *testing.T) {
+	var buf bytes.Buffer
+	logg, err := New(Options{Level: "warn"}, &buf)
+	require.NoError(t, err)
+
+	logg.Info("skipped")
+	logg.Warn("retrying")
+	logg.Error("failed")
+
+	out := buf.String()
+	require.NotContains(t, out, "skipped")
+	require.Contains(t, out, "level=warning msg=retrying")
+	require.Contains(t, out, "level=error msg=failed")
+}
+
+func TestOptionsErrors(t *testing.T) {
+	_, err := New(Options{Format: "xml"}, ioutil.Discard)
+	require.True(t, errors.Is(err, ErrUnknownFormat))
+
+	_, err = New(Options{Level: "loud"}, ioutil.Discard)
+	require.Error(t, err)
+}
+
+func TestContext(t *testing.T) {
+	var buf bytes.Buffer
+	logg, _ := New(Options{}, &buf)
+	requestLogger := logg.WithField(RequestIDField, "abc")
+
+	ctx := WithContext(context.Background(), requestLogger)
+	FromContext(ctx, logg).Info("from request")
+	FromContext(context.Background(), logg).Info("without request")
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	require.Len(t, lines, 2)
+	require.Contains(t, lines[0], "request_id=abc")
+	require.NotContains(t, lines[1], "request_id")
+}
+
+func TestFile(t *testing.T) {
+	dir, err := ioutil.TempDir("", "logger")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+	file := filepath.Join(dir, "logs", "log.log")
+
+	logg, err := New(Options{File: file, MaxSize: 1, MaxBackups: 1}, nil)
+	require.NoError(t, err)
+	logg.Info("to file")
+
+	data, err := ioutil.ReadFile(file)
+	require.NoError(t, err)
+	require.Contains(t, string(data), "msg=\"to file\"")
+}
+
+func TestNewRequestID(t *testing.T) {
+	id := NewRequestID()
+	require.Len(t, id, 16)
+	require.NotEqual(t, id, NewRequestID())
+}
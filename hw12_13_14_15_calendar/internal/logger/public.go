@@ -1,52 +1,115 @@
 package logger
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"io"
 	"os"
 	"path/filepath"
 
 	"github.com/sirupsen/logrus"
+	"gopkg.in/natefinch/lumberjack.v2"
 )
 
 type Logger interface {
 	Debug(args ...interface{})
 	Info(args ...interface{})
+	Warn(args ...interface{})
 	Error(args ...interface{})
 	Fatal(args ...interface{})
+	// WithField и WithFields возвращают логгер, добавляющий поля к каждой записи.
+	WithField(key string, value interface{}) Logger
+	WithFields(fields Fields) Logger
 }
 
-func New(logLevel string, output io.Writer, fileName string) (Logger, error) {
+type Fields map[string]interface{}
+
+// форматы записей
+const (
+	FormatText = "text"
+	FormatJSON = "json"
+)
+
+// имена полей записей о запросах
+const (
+	RequestIDField = "request_id"
+	UserIDField    = "user_id"
+)
+
+var ErrUnknownFormat = errors.New("unknown log format")
+
+type Options struct {
+	Level string
+	// FormatText, если пусто
+	Format string
+	File   string
+	// ротация файла: размер в мегабайтах (100, если 0), сколько дней и
+	// сколько старых файлов хранить (без ограничения, если 0)
+	MaxSize    int
+	MaxAge     int
+	MaxBackups int
+}
+
+// New создает логгер, пишущий в output, а если он не задан - в файл options.File или stderr.
+func New(options Options, output io.Writer) (Logger, error) {
 	log := logrus.New()
 
 	result := logger{
-		logger: log,
+		entry: logrus.NewEntry(log),
 	}
 
-	if logLevel != "" {
-		level, err := logrus.ParseLevel(logLevel)
+	if options.Level != "" {
+		level, err := logrus.ParseLevel(options.Level)
 		if err != nil {
 			return result, fmt.Errorf("failed to parse log level: %w", err)
 		}
 		log.SetLevel(level)
 	}
 
+	switch options.Format {
+	case "", FormatText:
+	case FormatJSON:
+		log.SetFormatter(&logrus.JSONFormatter{})
+	default:
+		return result, fmt.Errorf("%w: %q", ErrUnknownFormat, options.Format)
+	}
+
 	if output != nil {
 		log.SetOutput(output)
-	} else if fileName != "" {
-		fileName, err := filepath.Abs(fileName)
+	} else if options.File != "" {
+		fileName, err := filepath.Abs(options.File)
 		if err != nil {
 			return result, fmt.Errorf("failed to open log file: %w", err)
 		}
 		if err = os.MkdirAll(filepath.Dir(fileName), 0775); err != nil {
 			return result, fmt.Errorf("failed to open log file: %w", err)
 		}
-		file, err := os.OpenFile(fileName, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0666)
-		if err != nil {
-			return result, fmt.Errorf("failed to open log file: %w", err)
-		}
-		log.SetOutput(file)
+		log.SetOutput(&lumberjack.Logger{
+			Filename:   fileName,
+			MaxSize:    options.MaxSize,
+			MaxAge:     options.MaxAge,
+			MaxBackups: options.MaxBackups,
+		})
 	}
 
 	return result, nil
 }
+
+// WithContext возвращает контекст с логгером запроса.
+func WithContext(ctx context.Context, logger Logger) context.Context {
+	return context.WithValue(ctx, ctxKey{}, logger)
+}
+
+// FromContext возвращает логгер запроса, а если его нет в контексте - fallback.
+func FromContext(ctx context.Context, fallback Logger) Logger {
+	if logger, ok := ctx.Value(ctxKey{}).(Logger); ok {
+		return logger
+	}
+	return fallback
+}
+
+// NewRequestID возвращает случайный ID для запроса, пришедшего без ID.
+func NewRequestID() string {
+	return newRequestID()
+}
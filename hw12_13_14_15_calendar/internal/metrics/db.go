@@ -0,0 +1,83 @@
+package metrics
+
+import (
+	"database/sql"
+	"sync"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// dbStats отдает статистику пула соединений текущей базы данных.
+// Хранилище может переподключаться, поэтому коллектор один, а база заменяется через SetDB.
+type dbStats struct {
+	mu sync.Mutex
+	db *sql.DB
+
+	maxOpen           *prometheus.Desc
+	open              *prometheus.Desc
+	inUse             *prometheus.Desc
+	idle              *prometheus.Desc
+	waitCount         *prometheus.Desc
+	waitDuration      *prometheus.Desc
+	maxIdleClosed     *prometheus.Desc
+	maxLifetimeClosed *prometheus.Desc
+}
+
+var dbCollector = newDBStats()
+
+func init() {
+	prometheus.MustRegister(dbCollector)
+}
+
+// SetDB задает базу данных, статистика пула которой попадает в метрики, nil - никакой.
+func SetDB(sqlDB *sql.DB) {
+	dbCollector.mu.Lock()
+	defer dbCollector.mu.Unlock()
+	dbCollector.db = sqlDB
+}
+
+func newDBStats() *dbStats {
+	desc := func(name, help string) *prometheus.Desc {
+		return prometheus.NewDesc(prometheus.BuildFQName(namespace, "db", name), help, nil, nil)
+	}
+	return &dbStats{
+		maxOpen:           desc("max_open_connections", "Maximum number of open connections to the database."),
+		open:              desc("open_connections", "The number of established connections both in use and idle."),
+		inUse:             desc("in_use_connections", "The number of connections currently in use."),
+		idle:              desc("idle_connections", "The number of idle connections."),
+		waitCount:         desc("wait_count_total", "The total number of connections waited for."),
+		waitDuration:      desc("wait_duration_seconds_total", "The total time blocked waiting for a new connection."),
+		maxIdleClosed:     desc("max_idle_closed_total", "The total number of connections closed due to SetMaxIdleConns."),
+		maxLifetimeClosed: desc("max_lifetime_closed_total", "The total number of connections closed due to SetConnMaxLifetime."),
+	}
+}
+
+func (c *dbStats) Describe(ch chan<- *prometheus.Desc) {
+	ch <- c.maxOpen
+	ch <- c.open
+	ch <- c.inUse
+	ch <- c.idle
+	ch <- c.waitCount
+	ch <- c.waitDuration
+	ch <- c.maxIdleClosed
+	ch <- c.maxLifetimeClosed
+}
+
+func (c *dbStats) Collect(ch chan<- prometheus.Metric) {
+	c.mu.Lock()
+	sqlDB := c.db
+	c.mu.Unlock()
+	if sqlDB == nil {
+		return
+	}
+
+	stats := sqlDB.Stats()
+	ch <- prometheus.MustNewConstMetric(c.maxOpen, prometheus.GaugeValue, float64(stats.MaxOpenConnections))
+	ch <- prometheus.MustNewConstMetric(c.open, prometheus.GaugeValue, float64(stats.OpenConnections))
+	ch <- prometheus.MustNewConstMetric(c.inUse, prometheus.GaugeValue, float64(stats.InUse))
+	ch <- prometheus.MustNewConstMetric(c.idle, prometheus.GaugeValue, float64(stats.Idle))
+	ch <- prometheus.MustNewConstMetric(c.waitCount, prometheus.CounterValue, float64(stats.WaitCount))
+	ch <- prometheus.MustNewConstMetric(c.waitDuration, prometheus.CounterValue, stats.WaitDuration.Seconds())
+	ch <- prometheus.MustNewConstMetric(c.maxIdleClosed, prometheus.CounterValue, float64(stats.MaxIdleClosed))
+	ch <- prometheus.MustNewConstMetric(c.maxLifetimeClosed, prometheus.CounterValue, float64(stats.MaxLifetimeClosed))
+}
@@ -0,0 +1,99 @@
+package metrics
+
+import (
+	"strconv"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+const namespace = "calendar"
+
+var (
+	httpRequests = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: "http",
+		Name:      "requests_total",
+		Help:      "Number of HTTP requests by server, route, method and status code.",
+	}, []string{"server", "route", "method", "code"})
+
+	httpDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Subsystem: "http",
+		Name:      "request_duration_seconds",
+		Help:      "HTTP request latencies by server, route and method.",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"server", "route", "method"})
+
+	httpErrors = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: "http",
+		Name:      "errors_total",
+		Help:      "Number of HTTP requests answered with status 4xx or 5xx by server, route and method.",
+	}, []string{"server", "route", "method"})
+
+	grpcRequests = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: "grpc",
+		Name:      "requests_total",
+		Help:      "Number of gRPC calls by method and status code.",
+	}, []string{"method", "code"})
+
+	grpcDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Subsystem: "grpc",
+		Name:      "request_duration_seconds",
+		Help:      "gRPC call latencies by method.",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"method"})
+
+	grpcErrors = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: "grpc",
+		Name:      "errors_total",
+		Help:      "Number of gRPC calls finished with a status other than OK by method.",
+	}, []string{"method"})
+
+	storageDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Subsystem: "storage",
+		Name:      "operation_duration_seconds",
+		Help:      "Storage operation latencies by backend and operation.",
+		Buckets:   []float64{.0001, .0005, .001, .005, .01, .025, .05, .1, .25, .5, 1, 2.5},
+	}, []string{"backend", "operation"})
+
+	storageErrors = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: "storage",
+		Name:      "errors_total",
+		Help:      "Number of failed storage operations by backend and operation.",
+	}, []string{"backend", "operation"})
+)
+
+// ObserveHTTP учитывает HTTP запрос к маршруту route сервера server (http или gateway),
+// завершившийся со статусом code.
+func ObserveHTTP(server, route, method string, code int, duration time.Duration) {
+	httpRequests.WithLabelValues(server, route, method, strconv.Itoa(code)).Inc()
+	httpDuration.WithLabelValues(server, route, method).Observe(duration.Seconds())
+	if code >= 400 {
+		httpErrors.WithLabelValues(server, route, method).Inc()
+	}
+}
+
+// ObserveGRPC учитывает вызов gRPC метода, завершившийся с кодом code.
+func ObserveGRPC(method, code string, duration time.Duration) {
+	grpcRequests.WithLabelValues(method, code).Inc()
+	grpcDuration.WithLabelValues(method).Observe(duration.Seconds())
+	if code != "OK" {
+		grpcErrors.WithLabelValues(method).Inc()
+	}
+}
+
+// ObserveStorage учитывает операцию хранилища backend.
+func ObserveStorage(backend, operation string, err error, duration time.Duration) {
+	storageDuration.WithLabelValues(backend, operation).Observe(duration.Seconds())
+	if err != nil {
+		storageErrors.WithLabelValues(backend, operation).Inc()
+	}
+}
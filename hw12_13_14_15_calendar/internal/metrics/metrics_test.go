@@ -0,0 +1,36 @@
+package metrics
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/require"
+)
+
+func TestObserveHTTP(t *testing.T) {
+	ObserveHTTP("http", "/api/create", "POST", 200, time.Millisecond)
+	ObserveHTTP("http", "/api/create", "POST", 400, time.Millisecond)
+
+	require.Equal(t, 1.0, testutil.ToFloat64(httpRequests.WithLabelValues("http", "/api/create", "POST", "200")))
+	require.Equal(t, 1.0, testutil.ToFloat64(httpRequests.WithLabelValues("http", "/api/create", "POST", "400")))
+	require.Equal(t, 1.0, testutil.ToFloat64(httpErrors.WithLabelValues("http", "/api/create", "POST")))
+}
+
+func TestObserveGRPC(t *testing.T) {
+	ObserveGRPC("/Create", "OK", time.Millisecond)
+	ObserveGRPC("/Create", "InvalidArgument", time.Millisecond)
+
+	require.Equal(t, 1.0, testutil.ToFloat64(grpcRequests.WithLabelValues("/Create", "OK")))
+	require.Equal(t, 1.0, testutil.ToFloat64(grpcRequests.WithLabelValues("/Create", "InvalidArgument")))
+	require.Equal(t, 1.0, testutil.ToFloat64(grpcErrors.WithLabelValues("/Create")))
+}
+
+func TestObserveStorage(t *testing.T) {
+	ObserveStorage("memory", "get", nil, time.Millisecond)
+	ObserveStorage("memory", "get", errors.New("failed"), time.Millisecond)
+
+	require.Equal(t, 1.0, testutil.ToFloat64(storageErrors.WithLabelValues("memory", "get")))
+	require.Equal(t, 1, testutil.CollectAndCount(storageDuration))
+}
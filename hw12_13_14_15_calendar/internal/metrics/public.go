@@ -0,0 +1,14 @@
+// Package metrics содержит метрики Prometheus календаря.
+// Метрики регистрируются в реестре по умолчанию и отдаются обработчиком Handler.
+package metrics
+
+import (
+	"net/http"
+
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+// Handler отдает метрики в формате Prometheus.
+func Handler() http.Handler {
+	return promhttp.Handler()
+}
@@ -0,0 +1,87 @@
+package migrator
+
+import (
+	"bufio"
+	"fmt"
+	"io/fs"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+const (
+	upAnnotation   = "-- +goose Up"
+	downAnnotation = "-- +goose Down"
+)
+
+func load(fsys fs.FS) ([]Migration, error) {
+	names, err := fs.Glob(fsys, "*.sql")
+	if err != nil {
+		return nil, err
+	}
+
+	result := make([]Migration, 0, len(names))
+	versions := make(map[int64]string, len(names))
+	for _, name := range names {
+		data, err := fs.ReadFile(fsys, name)
+		if err != nil {
+			return nil, err
+		}
+		migration, err := parse(name, string(data))
+		if err != nil {
+			return nil, err
+		}
+		if other, ok := versions[migration.Version]; ok {
+			return nil, fmt.Errorf("%w: %s and %s have the same version", ErrInvalidMigration, other, name)
+		}
+		versions[migration.Version] = name
+		result = append(result, migration)
+	}
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].Version < result[j].Version
+	})
+	return result, nil
+}
+
+// parse разбирает файл миграции. Секции Up и Down выполняются целиком, поэтому
+// аннотации goose StatementBegin/StatementEnd не нужны и пропускаются как комментарии.
+func parse(name, data string) (Migration, error) {
+	base := strings.TrimSuffix(path.Base(name), ".sql")
+	parts := strings.SplitN(base, "_", 2)
+	version, err := strconv.ParseInt(parts[0], 10, 64)
+	if err != nil || version <= 0 {
+		return Migration{}, fmt.Errorf("%w: %s: no version in the name", ErrInvalidMigration, name)
+	}
+	migration := Migration{Version: version, Name: base}
+
+	var up, down strings.Builder
+	var section *strings.Builder
+	scanner := bufio.NewScanner(strings.NewReader(data))
+	for scanner.Scan() {
+		line := scanner.Text()
+		switch strings.TrimSpace(line) {
+		case upAnnotation:
+			section = &up
+			continue
+		case downAnnotation:
+			section = &down
+			continue
+		}
+		if section == nil {
+			continue
+		}
+		section.WriteString(line)
+		section.WriteString("\n")
+	}
+	if err := scanner.Err(); err != nil {
+		return Migration{}, fmt.Errorf("%s: %w", name, err)
+	}
+
+	migration.Up = strings.TrimSpace(up.String())
+	migration.Down = strings.TrimSpace(down.String())
+	if migration.Up == "" {
+		return Migration{}, fmt.Errorf("%w: %s: no up section", ErrInvalidMigration, name)
+	}
+	return migration, nil
+}
@@ -0,0 +1,87 @@
+package migrator
+
+import (
+	"errors"
+	"testing"
+	"testing/fstest"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/anfilat/otus-go/hw12_13_14_15_calendar/migrations"
+)
+
+func TestLoad(t *testing.T) {
+	fsys := fstest.MapFS{
+		"20210102000000_second.sql": {Data: []byte(
+			"-- +goose Up\n" +
+				"-- +goose StatementBegin\n" +
+				"CREATE TABLE b (id int);\n" +
+				"CREATE TABLE c (id int);\n" +
+				"-- +goose StatementEnd\n",
+		)},
+		"20210101000000_first.sql": {Data: []byte(
+			"-- header comment\n" +
+				"-- +goose Up\n" +
+				"CREATE TABLE a (id int);\n" +
+				"\n" +
+				"-- +goose Down\n" +
+				"DROP TABLE a;\n",
+		)},
+		"README.md": {Data: []byte("not a migration")},
+	}
+
+	result, err := load(fsys)
+	require.NoError(t, err)
+	require.Equal(t, 2, len(result))
+
+	require.Equal(t, int64(20210101000000), result[0].Version)
+	require.Equal(t, "20210101000000_first", result[0].Name)
+	require.Equal(t, "CREATE TABLE a (id int);", result[0].Up)
+	require.Equal(t, "DROP TABLE a;", result[0].Down)
+
+	require.Equal(t, int64(20210102000000), result[1].Version)
+	require.Contains(t, result[1].Up, "CREATE TABLE c (id int);")
+	require.Equal(t, "", result[1].Down)
+}
+
+func TestLoadFail(t *testing.T) {
+	tests := []struct {
+		name string
+		fsys fstest.MapFS
+	}{
+		{"no version", fstest.MapFS{"first.sql": {Data: []byte("-- +goose Up\nSELECT 1;\n")}}},
+		{"no up", fstest.MapFS{"1_first.sql": {Data: []byte("-- +goose Down\nSELECT 1;\n")}}},
+		{"same version", fstest.MapFS{
+			"1_first.sql":  {Data: []byte("-- +goose Up\nSELECT 1;\n")},
+			"01_again.sql": {Data: []byte("-- +goose Up\nSELECT 1;\n")},
+		}},
+	}
+	for _, tt := range tests {
+		_, err := load(tt.fsys)
+		require.True(t, errors.Is(err, ErrInvalidMigration), tt.name)
+	}
+}
+
+func TestEmbedded(t *testing.T) {
+	result, err := load(migrations.FS)
+	require.NoError(t, err)
+	require.NotEqual(t, 0, len(result))
+	for _, migration := range result {
+		require.NotEqual(t, "", migration.Down, migration.Name)
+	}
+}
+
+func TestEmbeddedSQLite(t *testing.T) {
+	postgres, err := load(migrations.FS)
+	require.NoError(t, err)
+	result, err := load(migrations.SQLite)
+	require.NoError(t, err)
+
+	// у обоих диалектов одни и те же версии схемы
+	require.Equal(t, len(postgres), len(result))
+	for i, migration := range result {
+		require.Equal(t, postgres[i].Version, migration.Version)
+		require.Equal(t, postgres[i].Name, migration.Name)
+		require.NotEqual(t, "", migration.Down, migration.Name)
+	}
+}
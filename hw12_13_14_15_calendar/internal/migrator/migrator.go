@@ -0,0 +1,209 @@
+package migrator
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+)
+
+// ключ advisory lock, не дающий нескольким процессам мигрировать одновременно
+const lockID = 7_243_117_001
+
+type migrator struct {
+	db         *sql.DB
+	dialect    Dialect
+	migrations []Migration
+}
+
+type applied map[int64]time.Time
+
+func (m *migrator) Up(ctx context.Context) ([]Migration, error) {
+	versions, err := m.applied(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var result []Migration
+	for _, migration := range m.migrations {
+		if _, ok := versions[migration.Version]; ok {
+			continue
+		}
+		done, err := m.apply(ctx, migration)
+		if err != nil {
+			return result, err
+		}
+		if done {
+			result = append(result, migration)
+		}
+	}
+	return result, nil
+}
+
+// apply применяет миграцию в транзакции. Возвращает false, если ее уже применил другой процесс.
+func (m *migrator) apply(ctx context.Context, migration Migration) (bool, error) {
+	return m.inTx(ctx, func(tx *sql.Tx) (bool, error) {
+		var count int
+		query := `SELECT count(*) FROM goose_db_version WHERE version_id = $1 AND is_applied`
+		if err := tx.QueryRowContext(ctx, query, migration.Version).Scan(&count); err != nil {
+			return false, fmt.Errorf("db query: %w", err)
+		}
+		if count > 0 {
+			return false, nil
+		}
+
+		if _, err := tx.ExecContext(ctx, migration.Up); err != nil {
+			return false, fmt.Errorf("migration %s: %w", migration.Name, err)
+		}
+		query = `INSERT INTO goose_db_version (version_id, is_applied) VALUES ($1, TRUE)`
+		if _, err := tx.ExecContext(ctx, query, migration.Version); err != nil {
+			return false, fmt.Errorf("db exec: %w", err)
+		}
+		return true, nil
+	})
+}
+
+func (m *migrator) Down(ctx context.Context) (Migration, error) {
+	var result Migration
+	_, err := m.inTx(ctx, func(tx *sql.Tx) (bool, error) {
+		var version int64
+		query := `SELECT coalesce(max(version_id), 0) FROM goose_db_version WHERE is_applied AND version_id > 0`
+		if err := tx.QueryRowContext(ctx, query).Scan(&version); err != nil {
+			return false, fmt.Errorf("db query: %w", err)
+		}
+		if version == 0 {
+			return false, ErrNoApplied
+		}
+
+		migration, ok := m.find(version)
+		if !ok {
+			return false, fmt.Errorf("%w: no file for applied version %d", ErrInvalidMigration, version)
+		}
+		if migration.Down != "" {
+			if _, err := tx.ExecContext(ctx, migration.Down); err != nil {
+				return false, fmt.Errorf("migration %s: %w", migration.Name, err)
+			}
+		}
+		query = `DELETE FROM goose_db_version WHERE version_id = $1`
+		if _, err := tx.ExecContext(ctx, query, version); err != nil {
+			return false, fmt.Errorf("db exec: %w", err)
+		}
+		result = migration
+		return true, nil
+	})
+	return result, err
+}
+
+func (m *migrator) Status(ctx context.Context) ([]Status, error) {
+	versions, err := m.applied(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	result := make([]Status, 0, len(m.migrations))
+	for _, migration := range m.migrations {
+		appliedAt, ok := versions[migration.Version]
+		result = append(result, Status{
+			Migration: migration,
+			Applied:   ok,
+			AppliedAt: appliedAt,
+		})
+	}
+	return result, nil
+}
+
+// applied возвращает примененные версии, создавая при необходимости таблицу версий.
+func (m *migrator) applied(ctx context.Context) (applied, error) {
+	query := `
+		CREATE TABLE IF NOT EXISTS goose_db_version (
+			id serial PRIMARY KEY,
+			version_id bigint NOT NULL,
+			is_applied boolean NOT NULL,
+			tstamp timestamp DEFAULT now()
+		)
+	`
+	if m.dialect == SQLite {
+		query = `
+			CREATE TABLE IF NOT EXISTS goose_db_version (
+				id INTEGER PRIMARY KEY AUTOINCREMENT,
+				version_id INTEGER NOT NULL,
+				is_applied INTEGER NOT NULL,
+				tstamp TIMESTAMP DEFAULT (datetime('now'))
+			)
+		`
+	}
+	if _, err := m.db.ExecContext(ctx, query); err != nil {
+		return nil, fmt.Errorf("db exec: %w", err)
+	}
+
+	// последняя запись о версии определяет, применена ли она
+	query = `
+		SELECT version_id, is_applied, tstamp
+		FROM goose_db_version
+		WHERE version_id > 0
+		ORDER BY id
+	`
+	//nolint:rowserrcheck
+	rows, err := m.db.QueryContext(ctx, query)
+	if err != nil {
+		return nil, fmt.Errorf("db query: %w", err)
+	}
+	defer rows.Close()
+
+	result := make(applied)
+	for rows.Next() {
+		var version int64
+		var isApplied bool
+		var tstamp sql.NullTime
+		if err := rows.Scan(&version, &isApplied, &tstamp); err != nil {
+			return nil, fmt.Errorf("db scan: %w", err)
+		}
+		if isApplied {
+			result[version] = tstamp.Time
+		} else {
+			delete(result, version)
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("db rows: %w", err)
+	}
+	return result, nil
+}
+
+func (m *migrator) find(version int64) (Migration, bool) {
+	for _, migration := range m.migrations {
+		if migration.Version == version {
+			return migration, true
+		}
+	}
+	return Migration{}, false
+}
+
+// inTx выполняет fn в транзакции под advisory lock. Транзакция фиксируется, если fn вернула true.
+// SQLite сама не дает двум транзакциям писать в базу одновременно, для нее блокировки нет.
+func (m *migrator) inTx(ctx context.Context, fn func(tx *sql.Tx) (bool, error)) (result bool, resultErr error) {
+	tx, err := m.db.BeginTx(ctx, nil)
+	if err != nil {
+		return false, fmt.Errorf("db begin: %w", err)
+	}
+	defer func() {
+		if !result || resultErr != nil {
+			//nolint:errcheck
+			tx.Rollback()
+		}
+	}()
+
+	if m.dialect == Postgres {
+		if _, err := tx.ExecContext(ctx, `SELECT pg_advisory_xact_lock($1)`, lockID); err != nil {
+			return false, fmt.Errorf("db lock: %w", err)
+		}
+	}
+	result, err = fn(tx)
+	if err != nil || !result {
+		return result, err
+	}
+	if err := tx.Commit(); err != nil {
+		return false, fmt.Errorf("db commit: %w", err)
+	}
+	return true, nil
+}
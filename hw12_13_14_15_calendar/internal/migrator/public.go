@@ -0,0 +1,56 @@
+package migrator
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"io/fs"
+	"time"
+)
+
+type Migrator interface {
+	// Up применяет все непримененные миграции и возвращает их.
+	Up(ctx context.Context) ([]Migration, error)
+	// Down откатывает последнюю примененную миграцию и возвращает ее.
+	Down(ctx context.Context) (Migration, error)
+	Status(ctx context.Context) ([]Status, error)
+}
+
+// Migration - миграция из файла <version>_<name>.sql в формате goose.
+type Migration struct {
+	Version int64
+	Name    string
+	Up      string
+	Down    string
+}
+
+type Status struct {
+	Migration
+	Applied   bool
+	AppliedAt time.Time
+}
+
+// Dialect - диалект SQL базы данных, в которой хранятся версии миграций.
+type Dialect int
+
+const (
+	Postgres Dialect = iota
+	SQLite
+)
+
+var ErrInvalidMigration = errors.New("invalid migration")
+var ErrNoApplied = errors.New("no applied migrations")
+
+// New загружает миграции из fsys. Примененные версии хранятся в таблице goose_db_version,
+// совместимой с goose.
+func New(db *sql.DB, dialect Dialect, fsys fs.FS) (Migrator, error) {
+	migrations, err := load(fsys)
+	if err != nil {
+		return nil, err
+	}
+	return &migrator{
+		db:         db,
+		dialect:    dialect,
+		migrations: migrations,
+	}, nil
+}
@@ -0,0 +1,7 @@
+package filequeue
+
+import "github.com/anfilat/otus-go/hw12_13_14_15_calendar/internal/queue"
+
+func New() queue.Queue {
+	return &fileQueue{}
+}
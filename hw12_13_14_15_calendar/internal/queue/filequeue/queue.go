@@ -0,0 +1,202 @@
+package filequeue
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/anfilat/otus-go/hw12_13_14_15_calendar/internal/queue"
+)
+
+// Очередь в каталоге: каждое сообщение - отдельный json файл.
+// Файл сначала пишется в tmp и затем переименовывается в ready,
+// поэтому читатель никогда не увидит недописанное сообщение.
+// Получатель забирает сообщение переименованием в processing,
+// после обработки файл удаляется, возвращается в ready или переносится в dead.
+const (
+	tmpDir        = "tmp"
+	readyDir      = "ready"
+	processingDir = "processing"
+	deadDir       = "dead"
+)
+
+const pollInterval = 500 * time.Millisecond
+
+type fileQueue struct {
+	mu  sync.Mutex
+	dir string
+	seq int
+}
+
+func (q *fileQueue) Connect(_ context.Context, connect string) error {
+	dir, err := filepath.Abs(connect)
+	if err != nil {
+		return fmt.Errorf("failed to open queue dir: %w", err)
+	}
+	for _, name := range []string{tmpDir, readyDir, processingDir, deadDir} {
+		if err := os.MkdirAll(filepath.Join(dir, name), 0775); err != nil {
+			return fmt.Errorf("failed to open queue dir: %w", err)
+		}
+	}
+	q.dir = dir
+	return nil
+}
+
+func (q *fileQueue) Close(_ context.Context) error {
+	return nil
+}
+
+func (q *fileQueue) Publish(_ context.Context, notification queue.Notification) error {
+	data, err := json.Marshal(notification)
+	if err != nil {
+		return fmt.Errorf("queue marshal: %w", err)
+	}
+
+	name := q.newName()
+	tmpName := filepath.Join(q.dir, tmpDir, name)
+	if err := ioutil.WriteFile(tmpName, data, 0664); err != nil {
+		return fmt.Errorf("queue write: %w", err)
+	}
+	if err := os.Rename(tmpName, filepath.Join(q.dir, readyDir, name)); err != nil {
+		return fmt.Errorf("queue write: %w", err)
+	}
+	return nil
+}
+
+// Consume предполагает одного получателя на каталог: сообщения, оставшиеся
+// в processing после аварийной остановки, возвращаются в очередь.
+func (q *fileQueue) Consume(ctx context.Context) (<-chan queue.Delivery, error) {
+	if err := q.requeueProcessing(); err != nil {
+		return nil, err
+	}
+
+	result := make(chan queue.Delivery)
+
+	go func() {
+		defer close(result)
+
+		for {
+			names, err := q.readyNames()
+			if err != nil {
+				names = nil
+			}
+			for _, name := range names {
+				d, ok := q.take(name)
+				if !ok {
+					continue
+				}
+				select {
+				case <-ctx.Done():
+					_ = d.Nack()
+					return
+				case result <- d:
+				}
+			}
+
+			select {
+			case <-ctx.Done():
+				return
+			case <-time.After(pollInterval):
+			}
+		}
+	}()
+
+	return result, nil
+}
+
+func (q *fileQueue) requeueProcessing() error {
+	files, err := ioutil.ReadDir(filepath.Join(q.dir, processingDir))
+	if err != nil {
+		return fmt.Errorf("queue read: %w", err)
+	}
+	for _, file := range files {
+		err := os.Rename(filepath.Join(q.dir, processingDir, file.Name()), filepath.Join(q.dir, readyDir, file.Name()))
+		if err != nil {
+			return fmt.Errorf("queue requeue: %w", err)
+		}
+	}
+	return nil
+}
+
+// ReadDir возвращает имена отсортированными, то есть в порядке публикации.
+func (q *fileQueue) readyNames() ([]string, error) {
+	files, err := ioutil.ReadDir(filepath.Join(q.dir, readyDir))
+	if err != nil {
+		return nil, err
+	}
+	names := make([]string, 0, len(files))
+	for _, file := range files {
+		names = append(names, file.Name())
+	}
+	return names, nil
+}
+
+// take забирает сообщение в обработку. Поврежденные сообщения сразу переносятся в dead.
+func (q *fileQueue) take(name string) (*delivery, bool) {
+	processingName := filepath.Join(q.dir, processingDir, name)
+	if err := os.Rename(filepath.Join(q.dir, readyDir, name), processingName); err != nil {
+		return nil, false
+	}
+
+	d := &delivery{queue: q, name: name}
+	data, err := ioutil.ReadFile(processingName)
+	if err == nil {
+		err = json.Unmarshal(data, &d.notification)
+	}
+	if err != nil {
+		_ = d.DeadLetter()
+		return nil, false
+	}
+	return d, true
+}
+
+// имена упорядочены по времени публикации.
+func (q *fileQueue) newName() string {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	q.seq++
+	return fmt.Sprintf("%020d-%d-%06d.json", time.Now().UnixNano(), os.Getpid(), q.seq)
+}
+
+type delivery struct {
+	queue        *fileQueue
+	name         string
+	notification queue.Notification
+}
+
+func (d *delivery) Notification() queue.Notification {
+	return d.notification
+}
+
+func (d *delivery) Ack() error {
+	err := os.Remove(d.path(processingDir))
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return fmt.Errorf("queue ack: %w", err)
+	}
+	return nil
+}
+
+func (d *delivery) Nack() error {
+	if err := os.Rename(d.path(processingDir), d.path(readyDir)); err != nil {
+		return fmt.Errorf("queue nack: %w", err)
+	}
+	return nil
+}
+
+func (d *delivery) DeadLetter() error {
+	if err := os.Rename(d.path(processingDir), d.path(deadDir)); err != nil {
+		return fmt.Errorf("queue dead letter: %w", err)
+	}
+	return nil
+}
+
+func (d *delivery) path(dir string) string {
+	return filepath.Join(d.queue.dir, dir, d.name)
+}
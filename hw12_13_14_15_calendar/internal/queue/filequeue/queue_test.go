@@ -0,0 +1,109 @@
+package filequeue
+
+import (
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/anfilat/otus-go/hw12_13_14_15_calendar/internal/queue"
+)
+
+func TestPublish(t *testing.T) {
+	ctx := context.Background()
+	dir, err := ioutil.TempDir("", "queue")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	q := New()
+	require.NoError(t, q.Connect(ctx, dir))
+	defer q.Close(ctx)
+
+	notifications := []queue.Notification{
+		{EventID: 1, Title: "first", Date: time.Now().Round(0), UserID: 1},
+		{EventID: 2, Title: "second", Date: time.Now().Round(0), UserID: 2},
+	}
+	for _, n := range notifications {
+		require.NoError(t, q.Publish(ctx, n))
+	}
+
+	files, err := ioutil.ReadDir(filepath.Join(dir, readyDir))
+	require.NoError(t, err)
+	require.Equal(t, 2, len(files))
+	for i, file := range files {
+		data, err := ioutil.ReadFile(filepath.Join(dir, readyDir, file.Name()))
+		require.NoError(t, err)
+		n := queue.Notification{}
+		require.NoError(t, json.Unmarshal(data, &n))
+		require.Equal(t, notifications[i].EventID, n.EventID)
+		require.Equal(t, notifications[i].Title, n.Title)
+		require.True(t, notifications[i].Date.Equal(n.Date))
+	}
+
+	files, err = ioutil.ReadDir(filepath.Join(dir, tmpDir))
+	require.NoError(t, err)
+	require.Equal(t, 0, len(files))
+}
+
+func TestConsume(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	dir, err := ioutil.TempDir("", "queue")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	q := New()
+	require.NoError(t, q.Connect(ctx, dir))
+	defer q.Close(ctx)
+
+	for i := 1; i <= 3; i++ {
+		require.NoError(t, q.Publish(ctx, queue.Notification{EventID: i, Title: "event", Date: time.Now(), UserID: 1}))
+	}
+
+	deliveries, err := q.Consume(ctx)
+	require.NoError(t, err)
+
+	d := <-deliveries
+	require.Equal(t, 1, d.Notification().EventID)
+	require.NoError(t, d.Ack())
+
+	d = <-deliveries
+	require.Equal(t, 2, d.Notification().EventID)
+	require.NoError(t, d.DeadLetter())
+
+	d = <-deliveries
+	require.Equal(t, 3, d.Notification().EventID)
+	require.NoError(t, d.Nack())
+
+	// возвращенное сообщение доставляется повторно
+	d = <-deliveries
+	require.Equal(t, 3, d.Notification().EventID)
+
+	cancel()
+	for range deliveries {
+	}
+
+	requireFiles(t, dir, readyDir, 0)
+	requireFiles(t, dir, processingDir, 1)
+	requireFiles(t, dir, deadDir, 1)
+
+	// сообщение, оставшееся в обработке, возвращается в очередь при следующем запуске
+	ctx, cancel = context.WithCancel(context.Background())
+	defer cancel()
+	deliveries, err = q.Consume(ctx)
+	require.NoError(t, err)
+	d = <-deliveries
+	require.Equal(t, 3, d.Notification().EventID)
+	require.NoError(t, d.Ack())
+}
+
+func requireFiles(t *testing.T, dir, name string, count int) {
+	files, err := ioutil.ReadDir(filepath.Join(dir, name))
+	require.NoError(t, err)
+	require.Equal(t, count, len(files))
+}
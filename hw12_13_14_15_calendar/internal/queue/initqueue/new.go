@@ -0,0 +1,20 @@
+package initqueue
+
+import (
+	"context"
+
+	"github.com/anfilat/otus-go/hw12_13_14_15_calendar/internal/queue"
+	"github.com/anfilat/otus-go/hw12_13_14_15_calendar/internal/queue/filequeue"
+	"github.com/anfilat/otus-go/hw12_13_14_15_calendar/internal/queue/memoryqueue"
+)
+
+func New(ctx context.Context, inmem bool, connect string) (queue.Queue, error) {
+	var q queue.Queue
+	if inmem {
+		q = memoryqueue.New()
+	} else {
+		q = filequeue.New()
+	}
+	err := q.Connect(ctx, connect)
+	return q, err
+}
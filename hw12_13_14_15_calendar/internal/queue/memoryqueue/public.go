@@ -0,0 +1,9 @@
+package memoryqueue
+
+import "github.com/anfilat/otus-go/hw12_13_14_15_calendar/internal/queue"
+
+func New() queue.Queue {
+	return &memQueue{
+		signal: make(chan struct{}, 1),
+	}
+}
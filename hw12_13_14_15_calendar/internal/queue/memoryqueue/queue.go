@@ -0,0 +1,110 @@
+package memoryqueue
+
+import (
+	"context"
+	"sync"
+
+	"github.com/anfilat/otus-go/hw12_13_14_15_calendar/internal/queue"
+)
+
+type memQueue struct {
+	mu     sync.Mutex
+	data   []queue.Notification
+	dead   []queue.Notification
+	signal chan struct{}
+}
+
+func (q *memQueue) Connect(_ context.Context, _ string) error {
+	return nil
+}
+
+func (q *memQueue) Close(_ context.Context) error {
+	return nil
+}
+
+func (q *memQueue) Publish(_ context.Context, notification queue.Notification) error {
+	q.push(notification)
+	return nil
+}
+
+func (q *memQueue) Consume(ctx context.Context) (<-chan queue.Delivery, error) {
+	result := make(chan queue.Delivery)
+
+	go func() {
+		defer close(result)
+
+		for {
+			notification, ok := q.pop()
+			if !ok {
+				select {
+				case <-ctx.Done():
+					return
+				case <-q.signal:
+					continue
+				}
+			}
+
+			select {
+			case <-ctx.Done():
+				q.push(notification)
+				return
+			case result <- &delivery{queue: q, notification: notification}:
+			}
+		}
+	}()
+
+	return result, nil
+}
+
+func (q *memQueue) push(notification queue.Notification) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	q.data = append(q.data, notification)
+	select {
+	case q.signal <- struct{}{}:
+	default:
+	}
+}
+
+func (q *memQueue) pop() (queue.Notification, bool) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	if len(q.data) == 0 {
+		return queue.Notification{}, false
+	}
+	notification := q.data[0]
+	q.data = q.data[1:]
+	return notification, true
+}
+
+func (q *memQueue) pushDead(notification queue.Notification) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	q.dead = append(q.dead, notification)
+}
+
+type delivery struct {
+	queue        *memQueue
+	notification queue.Notification
+}
+
+func (d *delivery) Notification() queue.Notification {
+	return d.notification
+}
+
+func (d *delivery) Ack() error {
+	return nil
+}
+
+func (d *delivery) Nack() error {
+	d.queue.push(d.notification)
+	return nil
+}
+
+func (d *delivery) DeadLetter() error {
+	d.queue.pushDead(d.notification)
+	return nil
+}
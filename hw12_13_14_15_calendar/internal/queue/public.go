@@ -0,0 +1,45 @@
+package queue
+
+import (
+	"context"
+	"time"
+)
+
+type Queue interface {
+	Base
+	Producer
+	Consumer
+}
+
+type Base interface {
+	Connect(ctx context.Context, connect string) error
+	Close(ctx context.Context) error
+}
+
+type Producer interface {
+	Publish(ctx context.Context, notification Notification) error
+}
+
+type Consumer interface {
+	// Consume отдает сообщения, пока не будет отменен ctx. После отмены канал закрывается.
+	Consume(ctx context.Context) (<-chan Delivery, error)
+}
+
+// Delivery - полученное сообщение. Пока не вызван один из методов, сообщение
+// считается находящимся в обработке и другим получателям не отдается.
+type Delivery interface {
+	Notification() Notification
+	// Ack удаляет успешно обработанное сообщение из очереди.
+	Ack() error
+	// Nack возвращает сообщение в очередь.
+	Nack() error
+	// DeadLetter убирает сообщение из очереди в список необработанных.
+	DeadLetter() error
+}
+
+type Notification struct {
+	EventID int
+	Title   string
+	Date    time.Time
+	UserID  int
+}
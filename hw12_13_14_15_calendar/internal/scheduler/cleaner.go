@@ -0,0 +1,63 @@
+package scheduler
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/anfilat/otus-go/hw12_13_14_15_calendar/internal/logger"
+)
+
+type cleaner struct {
+	logger    logger.Logger
+	purger    Purger
+	retention Retention
+}
+
+func (c *cleaner) Run(ctx context.Context) {
+	ticker := time.NewTicker(c.retention.Interval)
+	defer ticker.Stop()
+
+	c.clean(ctx, time.Now())
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case now := <-ticker.C:
+			c.clean(ctx, now)
+		}
+	}
+}
+
+func (c *cleaner) clean(ctx context.Context, now time.Time) {
+	if err := c.Clean(ctx, now); err != nil {
+		c.logger.Error(err)
+	}
+}
+
+// Clean удаляет события, закончившиеся раньше, чем retention.Age до now.
+func (c *cleaner) Clean(ctx context.Context, now time.Time) error {
+	before := now.Add(-c.retention.Age)
+
+	if c.retention.DryRun {
+		events, err := c.purger.ListBefore(ctx, before)
+		if err != nil {
+			return fmt.Errorf("list old events: %w", err)
+		}
+		for _, event := range events {
+			c.logger.Info(fmt.Sprintf("dry run: would remove event %d %q ended at %s",
+				event.ID, event.Title, event.Stop.Format(time.RFC3339)))
+		}
+		c.logger.Info("dry run: would remove old events: ", len(events))
+		return nil
+	}
+
+	count, err := c.purger.DeleteBefore(ctx, before)
+	if err != nil {
+		return fmt.Errorf("delete old events: %w", err)
+	}
+	if count > 0 {
+		c.logger.Info("removed old events: ", count)
+	}
+	return nil
+}
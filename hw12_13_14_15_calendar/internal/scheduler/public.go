@@ -0,0 +1,92 @@
+package scheduler
+
+import (
+	"context"
+	"time"
+
+	"github.com/anfilat/otus-go/hw12_13_14_15_calendar/internal/logger"
+	"github.com/anfilat/otus-go/hw12_13_14_15_calendar/internal/queue"
+	"github.com/anfilat/otus-go/hw12_13_14_15_calendar/internal/storage"
+)
+
+// State хранит прогресс отправки уведомлений между запусками планировщика,
+// чтобы после перезапуска уведомления не отправлялись повторно.
+type State interface {
+	Load() (NotifyState, error)
+	Save(state NotifyState) error
+}
+
+// NotifyState - конец полностью обработанного окна и уведомления следующего окна,
+// отправленные до ошибки. При повторе окна они пропускаются.
+type NotifyState struct {
+	Watermark time.Time
+	Sent      []Sent
+}
+
+// Sent - отправленное уведомление о повторении события, начинающемся в Date.
+type Sent struct {
+	EventID int
+	Date    time.Time
+}
+
+type Scheduler interface {
+	Run(ctx context.Context)
+	Notify(ctx context.Context, now time.Time) error
+}
+
+func New(
+	logger logger.Logger,
+	storage storage.Events,
+	producer queue.Producer,
+	state State,
+	interval time.Duration,
+) Scheduler {
+	return &scheduler{
+		logger:   logger,
+		storage:  storage,
+		producer: producer,
+		state:    state,
+		interval: interval,
+	}
+}
+
+// Cleaner периодически удаляет старые события.
+type Cleaner interface {
+	Run(ctx context.Context)
+	Clean(ctx context.Context, now time.Time) error
+}
+
+// Retention задает очистку старых событий: удаляются события, закончившиеся
+// раньше, чем Age назад. При DryRun события только перечисляются в логе.
+type Retention struct {
+	Age      time.Duration
+	Interval time.Duration
+	DryRun   bool
+}
+
+// Purger удаляет старые события. Его реализует app.App, который публикует
+// удаления в ленту изменений, и storage.Events.
+type Purger interface {
+	ListBefore(ctx context.Context, date time.Time) ([]storage.Event, error)
+	DeleteBefore(ctx context.Context, date time.Time) (int, error)
+}
+
+// NewCleaner создает очистку старых событий. Лента изменений календаря видит только
+// изменения своего процесса, поэтому очистка работает в процессе календаря.
+func NewCleaner(logger logger.Logger, purger Purger, retention Retention) Cleaner {
+	return &cleaner{
+		logger:    logger,
+		purger:    purger,
+		retention: retention,
+	}
+}
+
+// NewFileState хранит состояние в json файле path.
+func NewFileState(path string) State {
+	return &fileState{path: path}
+}
+
+// NewMemoryState хранит состояние в памяти, оно теряется при перезапуске.
+func NewMemoryState() State {
+	return &memoryState{}
+}
@@ -0,0 +1,110 @@
+package scheduler
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/anfilat/otus-go/hw12_13_14_15_calendar/internal/logger"
+	"github.com/anfilat/otus-go/hw12_13_14_15_calendar/internal/queue"
+	"github.com/anfilat/otus-go/hw12_13_14_15_calendar/internal/storage"
+)
+
+type scheduler struct {
+	logger   logger.Logger
+	storage  storage.Events
+	producer queue.Producer
+	state    State
+	interval time.Duration
+	// прогресс отправки, загружается из state при первом запуске
+	notifyState *NotifyState
+}
+
+func (s *scheduler) Run(ctx context.Context) {
+	ticker := time.NewTicker(s.interval)
+	defer ticker.Stop()
+
+	s.notify(ctx, time.Now())
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case now := <-ticker.C:
+			s.notify(ctx, now)
+		}
+	}
+}
+
+func (s *scheduler) notify(ctx context.Context, now time.Time) {
+	if err := s.Notify(ctx, now); err != nil {
+		s.logger.Error(err)
+	}
+}
+
+// Notify отправляет в очередь уведомления о событиях, время уведомления которых
+// попадает в окно с предыдущего запуска до now. При ошибке окно не сдвигается,
+// и следующий запуск повторит отправку, пропустив уже отправленные уведомления.
+func (s *scheduler) Notify(ctx context.Context, now time.Time) error {
+	if s.notifyState == nil {
+		state, err := s.state.Load()
+		if err != nil {
+			return fmt.Errorf("load scheduler state: %w", err)
+		}
+		s.notifyState = &state
+	}
+	state := s.notifyState
+
+	if state.Watermark.IsZero() {
+		// первый запуск; начало окна запоминается, чтобы повтор после ошибки начался с него же
+		state.Watermark = now.Add(-s.interval)
+	}
+	from := state.Watermark
+
+	events, err := s.storage.ListToNotify(ctx, from, now)
+	if err != nil {
+		return fmt.Errorf("list events to notify: %w", err)
+	}
+	sent := make(map[sentKey]struct{}, len(state.Sent))
+	for _, item := range state.Sent {
+		sent[newSentKey(item.EventID, item.Date)] = struct{}{}
+	}
+	count := 0
+	for _, event := range events {
+		if _, ok := sent[newSentKey(event.ID, event.Start)]; ok {
+			continue
+		}
+		err := s.producer.Publish(ctx, queue.Notification{
+			EventID: event.ID,
+			Title:   event.Title,
+			Date:    event.Start,
+			UserID:  event.UserID,
+		})
+		if err != nil {
+			return fmt.Errorf("publish notification: %w", err)
+		}
+		count++
+		state.Sent = append(state.Sent, Sent{EventID: event.ID, Date: event.Start})
+		if err := s.state.Save(*state); err != nil {
+			return fmt.Errorf("save scheduler state: %w", err)
+		}
+	}
+	if count > 0 {
+		s.logger.Info("sent notifications: ", count)
+	}
+
+	*state = NotifyState{Watermark: now}
+	if err := s.state.Save(*state); err != nil {
+		return fmt.Errorf("save scheduler state: %w", err)
+	}
+	return nil
+}
+
+type sentKey struct {
+	eventID int
+	date    int64
+}
+
+// newSentKey не зависит от часового пояса date, в котором его вернуло хранилище.
+func newSentKey(eventID int, date time.Time) sentKey {
+	return sentKey{eventID: eventID, date: date.UnixNano()}
+}
@@ -0,0 +1,233 @@
+package scheduler_test
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/suite"
+
+	"github.com/anfilat/otus-go/hw12_13_14_15_calendar/internal/logger"
+	"github.com/anfilat/otus-go/hw12_13_14_15_calendar/internal/queue"
+	"github.com/anfilat/otus-go/hw12_13_14_15_calendar/internal/scheduler"
+	"github.com/anfilat/otus-go/hw12_13_14_15_calendar/internal/storage"
+	"github.com/anfilat/otus-go/hw12_13_14_15_calendar/internal/storage/initstorage"
+)
+
+type producer struct {
+	mu   sync.Mutex
+	data []queue.Notification
+	// сколько сообщений принять до ошибки, 0 - без ошибок
+	failAfter int
+}
+
+func (p *producer) Publish(_ context.Context, notification queue.Notification) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.failAfter > 0 && len(p.data) >= p.failAfter {
+		return errors.New("queue is unavailable")
+	}
+	p.data = append(p.data, notification)
+	return nil
+}
+
+type SchedulerTest struct {
+	suite.Suite
+	buf      *bytes.Buffer
+	logg     logger.Logger
+	db       storage.Storage
+	producer *producer
+	state    scheduler.State
+	sched    scheduler.Scheduler
+	cleaner  scheduler.Cleaner
+}
+
+func (s *SchedulerTest) SetupTest() {
+	ctx := context.Background()
+
+	s.buf = &bytes.Buffer{}
+	s.logg, _ = logger.New(logger.Options{}, s.buf)
+
+	dbDriver, dbConnect := initstorage.DriverPostgres, os.Getenv("PQ_TEST")
+	if sqliteConnect := os.Getenv("SQLITE_TEST"); sqliteConnect != "" {
+		dbDriver, dbConnect = initstorage.DriverSQLite, sqliteConnect
+	}
+	s.db, _ = initstorage.New(ctx, dbConnect == "", dbDriver, dbConnect)
+	_ = s.db.DeleteAll(ctx)
+
+	s.producer = &producer{}
+	s.state = scheduler.NewMemoryState()
+	s.sched = s.NewScheduler()
+	s.cleaner = s.NewCleaner(false)
+}
+
+func (s *SchedulerTest) TearDownTest() {
+	ctx := context.Background()
+	_ = s.db.DeleteAll(ctx)
+	_ = s.db.Close(ctx)
+}
+
+func (s *SchedulerTest) NewScheduler() scheduler.Scheduler {
+	return scheduler.New(s.logg, s.db, s.producer, s.state, time.Minute)
+}
+
+func (s *SchedulerTest) NewCleaner(dryRun bool) scheduler.Cleaner {
+	return scheduler.NewCleaner(s.logg, s.db, scheduler.Retention{
+		Age:      365 * 24 * time.Hour,
+		Interval: time.Hour,
+		DryRun:   dryRun,
+	})
+}
+
+func (s *SchedulerTest) AddEvent(title string, start time.Time, notification *time.Duration) int {
+	id, err := s.db.Create(context.Background(), storage.Event{
+		Title:        title,
+		Start:        start,
+		Stop:         start.Add(time.Hour),
+		UserID:       1,
+		Notification: notification,
+	})
+	s.Require().NoError(err)
+	return id
+}
+
+func (s *SchedulerTest) TestNotify() {
+	ctx := context.Background()
+	now := time.Date(2021, 2, 1, 12, 0, 0, 0, time.UTC)
+	hour := time.Hour
+	zero := time.Duration(0)
+
+	id1 := s.AddEvent("через час, уведомить за час", now.Add(time.Hour-30*time.Second), &hour)
+	id2 := s.AddEvent("сейчас, уведомить в момент начала", now.Add(-10*time.Second), &zero)
+	s.AddEvent("без уведомления", now.Add(-10*time.Second), nil)
+	s.AddEvent("уведомление было раньше", now.Add(time.Hour-2*time.Minute), &hour)
+	id3 := s.AddEvent("уведомление в следующем окне", now.Add(time.Hour+30*time.Second), &hour)
+
+	err := s.sched.Notify(ctx, now)
+	s.Require().NoError(err)
+	s.Require().Equal(2, len(s.producer.data))
+	s.Require().Equal(id2, s.producer.data[0].EventID)
+	s.Require().Equal(id1, s.producer.data[1].EventID)
+	s.Require().Equal("через час, уведомить за час", s.producer.data[1].Title)
+	s.Require().Equal(1, s.producer.data[1].UserID)
+	s.Require().Equal(now.Add(time.Hour-30*time.Second).Unix(), s.producer.data[1].Date.Unix())
+
+	// следующее окно начинается с конца предыдущего
+	err = s.sched.Notify(ctx, now.Add(time.Minute))
+	s.Require().NoError(err)
+	s.Require().Equal(3, len(s.producer.data))
+	s.Require().Equal(id3, s.producer.data[2].EventID)
+}
+
+// Повтор окна после ошибки не отправляет уже отправленные уведомления.
+func (s *SchedulerTest) TestNotifyRetry() {
+	ctx := context.Background()
+	now := time.Date(2021, 2, 1, 12, 0, 0, 0, time.UTC)
+	zero := time.Duration(0)
+
+	id1 := s.AddEvent("первое", now.Add(-20*time.Second), &zero)
+	id2 := s.AddEvent("второе", now.Add(-10*time.Second), &zero)
+
+	s.producer.failAfter = 1
+	s.Require().Error(s.sched.Notify(ctx, now))
+	s.Require().Equal(1, len(s.producer.data))
+
+	s.producer.failAfter = 0
+	s.Require().NoError(s.sched.Notify(ctx, now.Add(time.Minute)))
+	s.Require().Equal(2, len(s.producer.data))
+	s.Require().Equal(id1, s.producer.data[0].EventID)
+	s.Require().Equal(id2, s.producer.data[1].EventID)
+}
+
+// После перезапуска планировщик продолжает с конца обработанного окна.
+func (s *SchedulerTest) TestNotifyRestart() {
+	ctx := context.Background()
+	now := time.Date(2021, 2, 1, 12, 0, 0, 0, time.UTC)
+	zero := time.Duration(0)
+	s.state = scheduler.NewFileState(filepath.Join(s.T().TempDir(), "state.json"))
+
+	s.AddEvent("в первом окне", now.Add(-10*time.Second), &zero)
+	id := s.AddEvent("между запусками", now.Add(5*time.Minute), &zero)
+
+	s.Require().NoError(s.NewScheduler().Notify(ctx, now))
+	s.Require().Equal(1, len(s.producer.data))
+
+	// новый процесс запускается позже, окно начинается с now
+	s.Require().NoError(s.NewScheduler().Notify(ctx, now.Add(10*time.Minute)))
+	s.Require().Equal(2, len(s.producer.data))
+	s.Require().Equal(id, s.producer.data[1].EventID)
+
+	s.Require().NoError(s.NewScheduler().Notify(ctx, now.Add(11*time.Minute)))
+	s.Require().Equal(2, len(s.producer.data))
+}
+
+func (s *SchedulerTest) TestNotifyRecurring() {
+	ctx := context.Background()
+	now := time.Date(2021, 2, 1, 12, 0, 0, 0, time.UTC)
+	hour := time.Hour
+
+	recurrence, err := storage.NewRecurrence("FREQ=DAILY", nil)
+	s.Require().NoError(err)
+	id, err := s.db.Create(ctx, storage.Event{
+		Title:        "ежедневно",
+		Start:        now.AddDate(0, 0, -3).Add(time.Hour - 30*time.Second),
+		Stop:         now.AddDate(0, 0, -3).Add(2 * time.Hour),
+		UserID:       1,
+		Notification: &hour,
+		Recurrence:   recurrence,
+	})
+	s.Require().NoError(err)
+
+	err = s.sched.Notify(ctx, now)
+	s.Require().NoError(err)
+	s.Require().Equal(1, len(s.producer.data))
+	s.Require().Equal(id, s.producer.data[0].EventID)
+	s.Require().Equal(now.Add(time.Hour-30*time.Second).Unix(), s.producer.data[0].Date.Unix())
+
+	// бесконечное повторение не удаляется
+	err = s.cleaner.Clean(ctx, now.AddDate(5, 0, 0))
+	s.Require().NoError(err)
+	s.Require().Equal(1, len(s.GetAll()))
+}
+
+func (s *SchedulerTest) TestClean() {
+	ctx := context.Background()
+	now := time.Date(2021, 2, 1, 12, 0, 0, 0, time.UTC)
+
+	s.AddEvent("давно", now.AddDate(-2, 0, 0), nil)
+	// закончилось ровно год назад
+	s.AddEvent("год назад", now.Add(-365*24*time.Hour-time.Hour), nil)
+	s.AddEvent("закончилось позже", now.Add(-365*24*time.Hour-30*time.Minute), nil)
+	s.AddEvent("недавно", now.AddDate(0, -1, 0), nil)
+
+	// при dry run ничего не удаляется
+	err := s.NewCleaner(true).Clean(ctx, now)
+	s.Require().NoError(err)
+	s.Require().Equal(4, len(s.GetAll()))
+	s.Require().Equal(1, strings.Count(s.buf.String(), `would remove event 1 \"давно\"`))
+	s.Require().Equal(0, strings.Count(s.buf.String(), "год назад"))
+	s.Require().Contains(s.buf.String(), "would remove old events: 1")
+
+	err = s.cleaner.Clean(ctx, now)
+	s.Require().NoError(err)
+	events := s.GetAll()
+	s.Require().Equal(3, len(events))
+	s.Require().Equal("год назад", events[0].Title)
+}
+
+func (s *SchedulerTest) GetAll() []storage.Event {
+	data, err := s.db.ListAll(context.Background())
+	s.Require().NoError(err)
+	return data
+}
+
+func TestSchedulerTest(t *testing.T) {
+	suite.Run(t, new(SchedulerTest))
+}
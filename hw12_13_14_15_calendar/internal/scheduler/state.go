@@ -0,0 +1,69 @@
+package scheduler
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"sync"
+)
+
+type fileState struct {
+	path string
+}
+
+func (s *fileState) Load() (NotifyState, error) {
+	var state NotifyState
+	data, err := ioutil.ReadFile(s.path)
+	if errors.Is(err, os.ErrNotExist) {
+		return state, nil
+	}
+	if err != nil {
+		return state, fmt.Errorf("state read: %w", err)
+	}
+	if err := json.Unmarshal(data, &state); err != nil {
+		return state, fmt.Errorf("state unmarshal: %w", err)
+	}
+	return state, nil
+}
+
+// Save пишет состояние во временный файл и переименовывает его,
+// чтобы при сбое остался целым прежний файл.
+func (s *fileState) Save(state NotifyState) error {
+	data, err := json.Marshal(state)
+	if err != nil {
+		return fmt.Errorf("state marshal: %w", err)
+	}
+	tmpName := s.path + ".tmp"
+	if err := ioutil.WriteFile(tmpName, data, 0o664); err != nil {
+		return fmt.Errorf("state write: %w", err)
+	}
+	if err := os.Rename(tmpName, s.path); err != nil {
+		return fmt.Errorf("state write: %w", err)
+	}
+	return nil
+}
+
+type memoryState struct {
+	mu    sync.Mutex
+	state NotifyState
+}
+
+func (s *memoryState) Load() (NotifyState, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.state, nil
+}
+
+func (s *memoryState) Save(state NotifyState) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.state = NotifyState{
+		Watermark: state.Watermark,
+		Sent:      append([]Sent(nil), state.Sent...),
+	}
+	return nil
+}
@@ -0,0 +1,31 @@
+package sender
+
+import (
+	"context"
+	"time"
+
+	"github.com/anfilat/otus-go/hw12_13_14_15_calendar/internal/logger"
+	"github.com/anfilat/otus-go/hw12_13_14_15_calendar/internal/queue"
+	"github.com/anfilat/otus-go/hw12_13_14_15_calendar/internal/sink"
+)
+
+type Sender interface {
+	Run(ctx context.Context) error
+}
+
+// Retry задает повторные попытки доставки: пауза между попытками
+// начинается с MinBackoff и удваивается, но не превышает MaxBackoff.
+type Retry struct {
+	Attempts   int
+	MinBackoff time.Duration
+	MaxBackoff time.Duration
+}
+
+func New(logger logger.Logger, consumer queue.Consumer, sink sink.Sink, retry Retry) Sender {
+	return &sender{
+		logger:   logger,
+		consumer: consumer,
+		sink:     sink,
+		retry:    retry,
+	}
+}
@@ -0,0 +1,79 @@
+package sender
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/anfilat/otus-go/hw12_13_14_15_calendar/internal/logger"
+	"github.com/anfilat/otus-go/hw12_13_14_15_calendar/internal/queue"
+	"github.com/anfilat/otus-go/hw12_13_14_15_calendar/internal/sink"
+)
+
+type sender struct {
+	logger   logger.Logger
+	consumer queue.Consumer
+	sink     sink.Sink
+	retry    Retry
+}
+
+func (s *sender) Run(ctx context.Context) error {
+	deliveries, err := s.consumer.Consume(ctx)
+	if err != nil {
+		return fmt.Errorf("consume: %w", err)
+	}
+
+	for d := range deliveries {
+		s.handle(ctx, d)
+	}
+	return nil
+}
+
+// handle подтверждает сообщение только после успешной доставки.
+// При остановке сообщение возвращается в очередь, а после исчерпания попыток
+// переносится в dead letter.
+func (s *sender) handle(ctx context.Context, d queue.Delivery) {
+	notification := d.Notification()
+
+	err := s.send(ctx, notification)
+	switch {
+	case err == nil:
+		if err := d.Ack(); err != nil {
+			s.logger.Error(err)
+		}
+	case ctx.Err() != nil:
+		if err := d.Nack(); err != nil {
+			s.logger.Error(err)
+		}
+	default:
+		s.logger.WithField("event_id", notification.EventID).Error("notification is dead: ", err)
+		if err := d.DeadLetter(); err != nil {
+			s.logger.Error(err)
+		}
+	}
+}
+
+func (s *sender) send(ctx context.Context, notification queue.Notification) error {
+	backoff := s.retry.MinBackoff
+	for attempt := 1; ; attempt++ {
+		err := s.sink.Send(ctx, notification)
+		if err == nil || attempt >= s.retry.Attempts {
+			return err
+		}
+		s.logger.WithFields(logger.Fields{
+			"event_id": notification.EventID,
+			"attempt":  attempt,
+		}).Warn("notification is not sent, retrying: ", err)
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(backoff):
+		}
+
+		backoff *= 2
+		if backoff > s.retry.MaxBackoff {
+			backoff = s.retry.MaxBackoff
+		}
+	}
+}
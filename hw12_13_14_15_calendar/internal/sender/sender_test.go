@@ -0,0 +1,146 @@
+package sender_test
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/anfilat/otus-go/hw12_13_14_15_calendar/internal/logger"
+	"github.com/anfilat/otus-go/hw12_13_14_15_calendar/internal/queue"
+	"github.com/anfilat/otus-go/hw12_13_14_15_calendar/internal/sender"
+)
+
+type delivery struct {
+	notification queue.Notification
+	result       chan string
+}
+
+func (d *delivery) Notification() queue.Notification {
+	return d.notification
+}
+
+func (d *delivery) Ack() error {
+	d.result <- "ack"
+	return nil
+}
+
+func (d *delivery) Nack() error {
+	d.result <- "nack"
+	return nil
+}
+
+func (d *delivery) DeadLetter() error {
+	d.result <- "dead"
+	return nil
+}
+
+type consumer struct {
+	deliveries chan queue.Delivery
+}
+
+func (c *consumer) Consume(_ context.Context) (<-chan queue.Delivery, error) {
+	return c.deliveries, nil
+}
+
+// sink возвращает ошибку первые fails раз.
+type sink struct {
+	mu    sync.Mutex
+	fails int
+	calls int
+}
+
+func (s *sink) Send(ctx context.Context, _ queue.Notification) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.calls++
+	if s.fails < 0 || s.calls <= s.fails {
+		return errors.New("sink is down")
+	}
+	return ctx.Err()
+}
+
+func (s *sink) Close() error {
+	return nil
+}
+
+func (s *sink) Calls() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.calls
+}
+
+var retry = sender.Retry{
+	Attempts:   3,
+	MinBackoff: time.Millisecond,
+	MaxBackoff: 2 * time.Millisecond,
+}
+
+func runSender(ctx context.Context, out *sink, retry sender.Retry) (*consumer, chan error) {
+	var buf bytes.Buffer
+	logg, _ := logger.New(logger.Options{}, &buf)
+
+	in := &consumer{deliveries: make(chan queue.Delivery)}
+	done := make(chan error, 1)
+	go func() {
+		done <- sender.New(logg, in, out, retry).Run(ctx)
+	}()
+	return in, done
+}
+
+func send(in *consumer) chan string {
+	d := &delivery{
+		notification: queue.Notification{EventID: 1, Title: "event", Date: time.Now(), UserID: 1},
+		result:       make(chan string, 1),
+	}
+	in.deliveries <- d
+	return d.result
+}
+
+func TestSender(t *testing.T) {
+	tests := []struct {
+		name   string
+		fails  int
+		result string
+		calls  int
+	}{
+		{"delivered", 0, "ack", 1},
+		{"delivered after retry", 2, "ack", 3},
+		{"dead letter", -1, "dead", 3},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			out := &sink{fails: tt.fails}
+			in, done := runSender(context.Background(), out, retry)
+
+			require.Equal(t, tt.result, <-send(in))
+			require.Equal(t, tt.calls, out.Calls())
+
+			close(in.deliveries)
+			require.NoError(t, <-done)
+		})
+	}
+}
+
+func TestSenderStop(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	out := &sink{fails: -1}
+	in, done := runSender(ctx, out, sender.Retry{
+		Attempts:   3,
+		MinBackoff: time.Hour,
+		MaxBackoff: time.Hour,
+	})
+
+	result := send(in)
+	cancel()
+	require.Equal(t, "nack", <-result)
+
+	close(in.deliveries)
+	require.NoError(t, <-done)
+}
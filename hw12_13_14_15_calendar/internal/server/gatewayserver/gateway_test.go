@@ -0,0 +1,226 @@
+package gatewayserver
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/suite"
+
+	"github.com/anfilat/otus-go/hw12_13_14_15_calendar/internal/app"
+	"github.com/anfilat/otus-go/hw12_13_14_15_calendar/internal/logger"
+	"github.com/anfilat/otus-go/hw12_13_14_15_calendar/internal/storage"
+	"github.com/anfilat/otus-go/hw12_13_14_15_calendar/internal/storage/initstorage"
+)
+
+type GatewayTest struct {
+	suite.Suite
+	ts   *httptest.Server
+	app  app.App
+	db   storage.Storage
+	logs *bytes.Buffer
+}
+
+func (s *GatewayTest) SetupTest() {
+	ctx := context.Background()
+
+	s.logs = &bytes.Buffer{}
+	logg, _ := logger.New(logger.Options{Format: logger.FormatJSON}, s.logs)
+
+	dbDriver, dbConnect := initstorage.DriverPostgres, os.Getenv("PQ_TEST")
+	if sqliteConnect := os.Getenv("SQLITE_TEST"); sqliteConnect != "" {
+		dbDriver, dbConnect = initstorage.DriverSQLite, sqliteConnect
+	}
+	s.db, _ = initstorage.New(ctx, dbConnect == "", dbDriver, dbConnect)
+
+	s.app = app.New(logg, s.db)
+
+	s.ts = httptest.NewServer(newServer(s.app, logg).handler)
+
+	_ = s.app.DeleteAll(ctx, 0)
+}
+
+func (s *GatewayTest) TearDownTest() {
+	ctx := context.Background()
+	s.ts.Close()
+	_ = s.app.DeleteAll(ctx, 0)
+	_ = s.db.Close(ctx)
+}
+
+// Do выполняет запрос к шлюзу и разбирает JSON ответа в result.
+func (s *GatewayTest) Do(userID int, method, path string, body interface{}, result interface{}) int {
+	var data []byte
+	if body != nil {
+		data, _ = json.Marshal(body)
+	}
+	req, err := http.NewRequest(method, s.ts.URL+path, bytes.NewReader(data))
+	s.Require().NoError(err)
+	if userID != 0 {
+		req.Header.Set(UserIDHeader, strconv.Itoa(userID))
+	}
+	res, err := http.DefaultClient.Do(req)
+	s.Require().NoError(err)
+	defer res.Body.Close()
+
+	data, err = ioutil.ReadAll(res.Body)
+	s.Require().NoError(err)
+	if result != nil && res.StatusCode == http.StatusOK {
+		s.Require().NoError(json.Unmarshal(data, result))
+	}
+	return res.StatusCode
+}
+
+func (s *GatewayTest) NewEvent() map[string]interface{} {
+	start := time.Now().Add(2 * time.Hour).Truncate(time.Second)
+	return map[string]interface{}{
+		"title":        "some event",
+		"start":        start.Format(time.RFC3339),
+		"stop":         start.Add(time.Hour).Format(time.RFC3339),
+		"description":  "the event",
+		"notification": "3600s",
+	}
+}
+
+type gatewayEvent struct {
+	ID      int `json:"id"`
+	Title   string
+	Start   time.Time
+	UserID  int `json:"userId"`
+	Version int
+}
+
+type createResult struct {
+	ID      int `json:"id"`
+	Version int
+}
+
+func (s *GatewayTest) TestCRUD() {
+	event := s.NewEvent()
+
+	created := createResult{}
+	code := s.Do(1, http.MethodPost, "/v1/events", event, &created)
+	s.Require().Equal(http.StatusOK, code)
+	s.Require().NotEqual(0, created.ID)
+	s.Require().Equal(storage.InitialVersion, created.Version)
+	path := "/v1/events/" + strconv.Itoa(created.ID)
+
+	got := gatewayEvent{}
+	code = s.Do(1, http.MethodGet, path, nil, &got)
+	s.Require().Equal(http.StatusOK, code)
+	s.Require().Equal(created.ID, got.ID)
+	s.Require().Equal("some event", got.Title)
+	s.Require().Equal(1, got.UserID)
+
+	event["title"] = "new title"
+	event["version"] = created.Version
+	code = s.Do(1, http.MethodPut, path, event, nil)
+	s.Require().Equal(http.StatusOK, code)
+
+	var list struct {
+		Events []gatewayEvent
+	}
+	query := url.Values{}
+	query.Set("date", event["start"].(string))
+	code = s.Do(1, http.MethodGet, "/v1/events/day?"+query.Encode(), nil, &list)
+	s.Require().Equal(http.StatusOK, code)
+	s.Require().Equal(1, len(list.Events))
+	s.Require().Equal("new title", list.Events[0].Title)
+	s.Require().Equal(2, list.Events[0].Version)
+
+	code = s.Do(1, http.MethodDelete, path, nil, nil)
+	s.Require().Equal(http.StatusOK, code)
+	code = s.Do(1, http.MethodGet, path, nil, nil)
+	s.Require().Equal(http.StatusNotFound, code)
+}
+
+func (s *GatewayTest) TestDeleteAll() {
+	s.Require().Equal(http.StatusOK, s.Do(1, http.MethodPost, "/v1/events", s.NewEvent(), nil))
+	s.Require().Equal(http.StatusOK, s.Do(2, http.MethodPost, "/v1/events", s.NewEvent(), nil))
+
+	s.Require().Equal(http.StatusOK, s.Do(1, http.MethodDelete, "/v1/events", nil, nil))
+
+	var list struct {
+		Events []gatewayEvent
+	}
+	s.Require().Equal(http.StatusOK, s.Do(1, http.MethodGet, "/v1/events/all", nil, &list))
+	s.Require().Equal(0, len(list.Events))
+	s.Require().Equal(http.StatusOK, s.Do(2, http.MethodGet, "/v1/events/all", nil, &list))
+	s.Require().Equal(1, len(list.Events))
+	s.Require().Equal(2, list.Events[0].UserID)
+}
+
+func (s *GatewayTest) TestListRange() {
+	event := s.NewEvent()
+	s.Require().Equal(http.StatusOK, s.Do(1, http.MethodPost, "/v1/events", event, nil))
+
+	var page struct {
+		Events     []gatewayEvent
+		NextCursor string
+	}
+	start, _ := time.Parse(time.RFC3339, event["start"].(string))
+	query := url.Values{}
+	query.Set("from", start.Add(-time.Hour).Format(time.RFC3339))
+	query.Set("to", start.Add(2*time.Hour).Format(time.RFC3339))
+	query.Set("search", "some")
+	code := s.Do(1, http.MethodGet, "/v1/events?"+query.Encode(), nil, &page)
+	s.Require().Equal(http.StatusOK, code)
+	s.Require().Equal(1, len(page.Events))
+	s.Require().Equal("", page.NextCursor)
+}
+
+func (s *GatewayTest) TestErrors() {
+	event := s.NewEvent()
+
+	s.Require().Equal(http.StatusUnauthorized, s.Do(0, http.MethodPost, "/v1/events", event, nil))
+
+	created := createResult{}
+	s.Require().Equal(http.StatusOK, s.Do(1, http.MethodPost, "/v1/events", event, &created))
+	path := "/v1/events/" + strconv.Itoa(created.ID)
+
+	s.Require().Equal(http.StatusForbidden, s.Do(2, http.MethodGet, path, nil, nil))
+
+	// повтор со старой версией
+	event["version"] = created.Version
+	s.Require().Equal(http.StatusOK, s.Do(1, http.MethodPut, path, event, nil))
+	s.Require().Equal(http.StatusConflict, s.Do(1, http.MethodPut, path, event, nil))
+}
+
+// Запросы шлюза пишутся в лог с ID запроса и пользователя, как и запросы gRPC сервера.
+func (s *GatewayTest) TestRequestLogger() {
+	data, _ := json.Marshal(s.NewEvent())
+	req, err := http.NewRequest(http.MethodPost, s.ts.URL+"/v1/events", bytes.NewReader(data))
+	s.Require().NoError(err)
+	req.Header.Set(UserIDHeader, "1")
+	req.Header.Set(RequestIDHeader, "abc")
+	res, err := http.DefaultClient.Do(req)
+	s.Require().NoError(err)
+	res.Body.Close()
+	s.Require().Equal(http.StatusOK, res.StatusCode)
+	s.Require().Equal("abc", res.Header.Get(RequestIDHeader))
+
+	var record map[string]interface{}
+	for _, line := range strings.Split(strings.TrimSpace(s.logs.String()), "\n") {
+		record = nil
+		s.Require().NoError(json.Unmarshal([]byte(line), &record))
+		if record["uri"] == "/v1/events" {
+			break
+		}
+	}
+	s.Require().Equal("/v1/events", record["uri"])
+	s.Require().Equal("abc", record[logger.RequestIDField])
+	s.Require().Equal(float64(1), record[logger.UserIDField])
+	s.Require().Equal(float64(http.StatusOK), record["status"])
+}
+
+func TestGatewayTest(t *testing.T) {
+	suite.Run(t, new(GatewayTest))
+}
@@ -0,0 +1,25 @@
+package gatewayserver
+
+import (
+	"net/http"
+	"net/http/httptest"
+
+	"github.com/anfilat/otus-go/hw12_13_14_15_calendar/internal/metrics"
+)
+
+func (s *GatewayTest) TestMetrics() {
+	s.Require().Equal(http.StatusOK, s.Do(1, http.MethodPost, "/v1/events", s.NewEvent(), nil))
+	s.Require().Equal(http.StatusNotFound, s.Do(1, http.MethodGet, "/v1/events/123", nil, nil))
+	s.Require().Equal(http.StatusNotFound, s.Do(1, http.MethodGet, "/v1/unknown/123", nil, nil))
+
+	rec := httptest.NewRecorder()
+	metrics.Handler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	body := rec.Body.String()
+
+	s.Require().Contains(body, `calendar_http_requests_total{code="200",method="POST",route="/v1/events",server="gateway"}`)
+	s.Require().Contains(body, `calendar_http_request_duration_seconds_count{method="POST",route="/v1/events",server="gateway"}`)
+	// по шаблону пути, без ID события
+	s.Require().Contains(body, `calendar_http_errors_total{method="GET",route="/v1/events/{id}",server="gateway"}`)
+	s.Require().Contains(body, `calendar_http_errors_total{method="GET",route="unmatched",server="gateway"}`)
+	s.Require().NotContains(body, `route="/v1/unknown/123"`)
+}
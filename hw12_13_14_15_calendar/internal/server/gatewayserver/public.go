@@ -0,0 +1,26 @@
+package gatewayserver
+
+import (
+	"context"
+
+	"github.com/anfilat/otus-go/hw12_13_14_15_calendar/internal/app"
+	"github.com/anfilat/otus-go/hw12_13_14_15_calendar/internal/logger"
+	"github.com/anfilat/otus-go/hw12_13_14_15_calendar/internal/server/middleware"
+)
+
+// UserIDHeader - заголовок запроса с ID пользователя, передается сервису в метаданных x-user-id.
+const UserIDHeader = middleware.UserIDHeader
+
+// RequestIDHeader - заголовок с ID запроса. Если клиент его не передал, ID создается шлюзом.
+const RequestIDHeader = middleware.RequestIDHeader
+
+type Server interface {
+	Start(addr string) error
+	Stop(ctx context.Context) error
+}
+
+// NewServer создает REST/JSON шлюз к gRPC сервису календаря.
+// Маршруты задаются HTTP аннотациями в api/EventService.proto.
+func NewServer(app app.App, logger logger.Logger) Server {
+	return newServer(app, logger)
+}
@@ -0,0 +1,66 @@
+package gatewayserver
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/grpc-ecosystem/grpc-gateway/v2/runtime"
+	"google.golang.org/genproto/googleapis/api/annotations"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/protobuf/proto"
+
+	"github.com/anfilat/otus-go/hw12_13_14_15_calendar/internal/server/grpcserver"
+	"github.com/anfilat/otus-go/hw12_13_14_15_calendar/internal/server/middleware"
+)
+
+// routeTemplates - шаблоны путей HTTP аннотаций по полным именам gRPC методов,
+// например "/event.Calendar/Get": "/v1/events/{id}".
+var routeTemplates = loadRouteTemplates()
+
+func loadRouteTemplates() map[string]string {
+	templates := make(map[string]string)
+	services := grpcserver.File_EventService_proto.Services()
+	for i := 0; i < services.Len(); i++ {
+		service := services.Get(i)
+		methods := service.Methods()
+		for j := 0; j < methods.Len(); j++ {
+			method := methods.Get(j)
+			rule, ok := proto.GetExtension(method.Options(), annotations.E_Http).(*annotations.HttpRule)
+			if !ok || rule == nil {
+				continue
+			}
+			templates["/"+string(service.FullName())+"/"+string(method.Name())] = rulePath(rule)
+		}
+	}
+	return templates
+}
+
+func rulePath(rule *annotations.HttpRule) string {
+	switch pattern := rule.GetPattern().(type) {
+	case *annotations.HttpRule_Get:
+		return pattern.Get
+	case *annotations.HttpRule_Put:
+		return pattern.Put
+	case *annotations.HttpRule_Post:
+		return pattern.Post
+	case *annotations.HttpRule_Delete:
+		return pattern.Delete
+	case *annotations.HttpRule_Patch:
+		return pattern.Patch
+	case *annotations.HttpRule_Custom:
+		return pattern.Custom.GetPath()
+	}
+	return ""
+}
+
+// annotateRoute передает общим middleware шаблон пути маршрута, а не путь запроса,
+// чтобы ID событий не попадали в метки метрик и имена спанов.
+// Мультиплексор шлюза вызывает ее, когда маршрут уже найден.
+func annotateRoute(ctx context.Context, _ *http.Request) metadata.MD {
+	if method, ok := runtime.RPCMethod(ctx); ok {
+		if template, ok := routeTemplates[method]; ok {
+			middleware.SetRoute(ctx, template)
+		}
+	}
+	return nil
+}
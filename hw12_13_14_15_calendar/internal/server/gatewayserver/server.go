@@ -0,0 +1,81 @@
+package gatewayserver
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/textproto"
+	"time"
+
+	"github.com/grpc-ecosystem/grpc-gateway/v2/runtime"
+
+	"github.com/anfilat/otus-go/hw12_13_14_15_calendar/internal/app"
+	"github.com/anfilat/otus-go/hw12_13_14_15_calendar/internal/logger"
+	"github.com/anfilat/otus-go/hw12_13_14_15_calendar/internal/server/grpcserver"
+	"github.com/anfilat/otus-go/hw12_13_14_15_calendar/internal/server/middleware"
+)
+
+type server struct {
+	app     app.App
+	logger  logger.Logger
+	srv     *http.Server
+	handler http.Handler
+}
+
+func newServer(app app.App, logger logger.Logger) *server {
+	mux := runtime.NewServeMux(
+		runtime.WithIncomingHeaderMatcher(headerMatcher),
+		runtime.WithMetadata(annotateRoute),
+	)
+	// вызовы идут напрямую в сервис, без сетевого соединения с gRPC сервером,
+	// поэтому перехватчики gRPC сервера не работают и их заменяют общие middleware HTTP сервера.
+	// RegisterCalendarHandlerServer ошибок не возвращает
+	_ = grpcserver.RegisterCalendarHandlerServer(context.Background(), mux, grpcserver.NewService(app))
+
+	// метки server у шлюза и HTTP сервера разные, потому что маршруты у них пересекаются
+	handler := middleware.Handler(mux, middleware.Options{
+		Server:  "gateway",
+		Service: "calendar-gateway",
+		Message: "gateway request",
+		Logger:  logger,
+	})
+
+	return &server{
+		app:     app,
+		logger:  logger,
+		handler: handler,
+	}
+}
+
+func (s *server) Start(addr string) error {
+	s.srv = &http.Server{
+		Addr:         addr,
+		Handler:      s.handler,
+		WriteTimeout: time.Second * 15,
+		ReadTimeout:  time.Second * 15,
+		IdleTimeout:  time.Second * 60,
+	}
+	s.logger.Info("starting gateway server on ", addr)
+	err := s.srv.ListenAndServe()
+	if errors.Is(err, http.ErrServerClosed) {
+		return nil
+	}
+	return err
+}
+
+func (s *server) Stop(ctx context.Context) error {
+	err := s.srv.Shutdown(ctx)
+	if err != nil {
+		return fmt.Errorf("gateway shutdown: %w", err)
+	}
+	return nil
+}
+
+// headerMatcher кроме стандартных заголовков передает сервису ID пользователя.
+func headerMatcher(key string) (string, bool) {
+	if textproto.CanonicalMIMEHeaderKey(key) == UserIDHeader {
+		return grpcserver.UserIDMetadata, true
+	}
+	return runtime.DefaultHeaderMatcher(key)
+}
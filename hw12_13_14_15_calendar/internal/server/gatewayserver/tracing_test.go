@@ -0,0 +1,68 @@
+package gatewayserver
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/propagation"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// Спаны приложения становятся дочерними для спана запроса к шлюзу.
+func (s *GatewayTest) TestTraceparent() {
+	provider := otel.GetTracerProvider()
+	defer otel.SetTracerProvider(provider)
+	exporter := tracetest.NewInMemoryExporter()
+	otel.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSyncer(exporter)))
+	otel.SetTextMapPropagator(propagation.TraceContext{})
+
+	const traceID = "4bf92f3577b34da6a3ce929d0e0e4736"
+	data, _ := json.Marshal(s.NewEvent())
+	req, err := http.NewRequest(http.MethodPost, s.ts.URL+"/v1/events", bytes.NewReader(data))
+	s.Require().NoError(err)
+	req.Header.Set(UserIDHeader, "1")
+	req.Header.Set("traceparent", "00-"+traceID+"-00f067aa0ba902b7-01")
+	res, err := http.DefaultClient.Do(req)
+	s.Require().NoError(err)
+	res.Body.Close()
+	s.Require().Equal(http.StatusOK, res.StatusCode)
+
+	var server, create *sdktrace.SpanSnapshot
+	for _, span := range exporter.GetSpans() {
+		switch span.Name {
+		case "POST /v1/events":
+			server = span
+		case "app.Create":
+			create = span
+		}
+	}
+	s.Require().NotNil(server)
+	s.Require().NotNil(create)
+
+	s.Require().Equal(trace.SpanKindServer, server.SpanKind)
+	s.Require().Equal(traceID, server.SpanContext.TraceID().String())
+	s.Require().Equal(server.SpanContext.SpanID(), create.Parent.SpanID())
+}
+
+// Имена спанов строятся по шаблону пути, чтобы в них не попадали ID событий.
+func (s *GatewayTest) TestSpanName() {
+	provider := otel.GetTracerProvider()
+	defer otel.SetTracerProvider(provider)
+	exporter := tracetest.NewInMemoryExporter()
+	otel.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSyncer(exporter)))
+
+	s.Require().Equal(http.StatusNotFound, s.Do(1, http.MethodGet, "/v1/events/123", nil, nil))
+	s.Require().Equal(http.StatusNotFound, s.Do(1, http.MethodGet, "/v1/unknown/123", nil, nil))
+
+	var names []string
+	for _, span := range exporter.GetSpans() {
+		if span.SpanKind == trace.SpanKindServer {
+			names = append(names, span.Name)
+		}
+	}
+	s.Require().Equal([]string{"GET /v1/events/{id}", "GET unmatched"}, names)
+}
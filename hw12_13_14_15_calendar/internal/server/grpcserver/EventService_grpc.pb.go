@@ -20,9 +20,20 @@ type CalendarClient interface {
 	Create(ctx context.Context, in *Event, opts ...grpc.CallOption) (*CreateResult, error)
 	Update(ctx context.Context, in *Event, opts ...grpc.CallOption) (*UpdateResult, error)
 	Delete(ctx context.Context, in *DeleteRequest, opts ...grpc.CallOption) (*DeleteResult, error)
+	Get(ctx context.Context, in *GetRequest, opts ...grpc.CallOption) (*Event, error)
+	ListAll(ctx context.Context, in *ListAllRequest, opts ...grpc.CallOption) (*ListResult, error)
+	DeleteAll(ctx context.Context, in *DeleteAllRequest, opts ...grpc.CallOption) (*DeleteResult, error)
 	ListDay(ctx context.Context, in *ListRequest, opts ...grpc.CallOption) (*ListResult, error)
 	ListWeek(ctx context.Context, in *ListRequest, opts ...grpc.CallOption) (*ListResult, error)
 	ListMonth(ctx context.Context, in *ListRequest, opts ...grpc.CallOption) (*ListResult, error)
+	ListRange(ctx context.Context, in *ListRangeRequest, opts ...grpc.CallOption) (*ListRangeResult, error)
+	FreeBusy(ctx context.Context, in *FreeBusyRequest, opts ...grpc.CallOption) (*FreeBusyResult, error)
+	Export(ctx context.Context, in *ExportRequest, opts ...grpc.CallOption) (*ExportResult, error)
+	Import(ctx context.Context, in *ImportRequest, opts ...grpc.CallOption) (*ImportResult, error)
+	GetSettings(ctx context.Context, in *GetSettingsRequest, opts ...grpc.CallOption) (*Settings, error)
+	UpdateSettings(ctx context.Context, in *Settings, opts ...grpc.CallOption) (*Settings, error)
+	// поток изменений событий пользователя, шлюзом не поддерживается
+	Watch(ctx context.Context, in *WatchRequest, opts ...grpc.CallOption) (Calendar_WatchClient, error)
 }
 
 type calendarClient struct {
@@ -60,6 +71,33 @@ func (c *calendarClient) Delete(ctx context.Context, in *DeleteRequest, opts ...
 	return out, nil
 }
 
+func (c *calendarClient) Get(ctx context.Context, in *GetRequest, opts ...grpc.CallOption) (*Event, error) {
+	out := new(Event)
+	err := c.cc.Invoke(ctx, "/event.Calendar/Get", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *calendarClient) ListAll(ctx context.Context, in *ListAllRequest, opts ...grpc.CallOption) (*ListResult, error) {
+	out := new(ListResult)
+	err := c.cc.Invoke(ctx, "/event.Calendar/ListAll", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *calendarClient) DeleteAll(ctx context.Context, in *DeleteAllRequest, opts ...grpc.CallOption) (*DeleteResult, error) {
+	out := new(DeleteResult)
+	err := c.cc.Invoke(ctx, "/event.Calendar/DeleteAll", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
 func (c *calendarClient) ListDay(ctx context.Context, in *ListRequest, opts ...grpc.CallOption) (*ListResult, error) {
 	out := new(ListResult)
 	err := c.cc.Invoke(ctx, "/event.Calendar/ListDay", in, out, opts...)
@@ -87,6 +125,92 @@ func (c *calendarClient) ListMonth(ctx context.Context, in *ListRequest, opts ..
 	return out, nil
 }
 
+func (c *calendarClient) ListRange(ctx context.Context, in *ListRangeRequest, opts ...grpc.CallOption) (*ListRangeResult, error) {
+	out := new(ListRangeResult)
+	err := c.cc.Invoke(ctx, "/event.Calendar/ListRange", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *calendarClient) FreeBusy(ctx context.Context, in *FreeBusyRequest, opts ...grpc.CallOption) (*FreeBusyResult, error) {
+	out := new(FreeBusyResult)
+	err := c.cc.Invoke(ctx, "/event.Calendar/FreeBusy", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *calendarClient) Export(ctx context.Context, in *ExportRequest, opts ...grpc.CallOption) (*ExportResult, error) {
+	out := new(ExportResult)
+	err := c.cc.Invoke(ctx, "/event.Calendar/Export", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *calendarClient) Import(ctx context.Context, in *ImportRequest, opts ...grpc.CallOption) (*ImportResult, error) {
+	out := new(ImportResult)
+	err := c.cc.Invoke(ctx, "/event.Calendar/Import", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *calendarClient) GetSettings(ctx context.Context, in *GetSettingsRequest, opts ...grpc.CallOption) (*Settings, error) {
+	out := new(Settings)
+	err := c.cc.Invoke(ctx, "/event.Calendar/GetSettings", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *calendarClient) UpdateSettings(ctx context.Context, in *Settings, opts ...grpc.CallOption) (*Settings, error) {
+	out := new(Settings)
+	err := c.cc.Invoke(ctx, "/event.Calendar/UpdateSettings", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *calendarClient) Watch(ctx context.Context, in *WatchRequest, opts ...grpc.CallOption) (Calendar_WatchClient, error) {
+	stream, err := c.cc.NewStream(ctx, &_Calendar_serviceDesc.Streams[0], "/event.Calendar/Watch", opts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &calendarWatchClient{stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+type Calendar_WatchClient interface {
+	Recv() (*EventChange, error)
+	grpc.ClientStream
+}
+
+type calendarWatchClient struct {
+	grpc.ClientStream
+}
+
+func (x *calendarWatchClient) Recv() (*EventChange, error) {
+	m := new(EventChange)
+	if err := x.ClientStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
 // CalendarServer is the server API for Calendar service.
 // All implementations must embed UnimplementedCalendarServer
 // for forward compatibility
@@ -94,9 +218,20 @@ type CalendarServer interface {
 	Create(context.Context, *Event) (*CreateResult, error)
 	Update(context.Context, *Event) (*UpdateResult, error)
 	Delete(context.Context, *DeleteRequest) (*DeleteResult, error)
+	Get(context.Context, *GetRequest) (*Event, error)
+	ListAll(context.Context, *ListAllRequest) (*ListResult, error)
+	DeleteAll(context.Context, *DeleteAllRequest) (*DeleteResult, error)
 	ListDay(context.Context, *ListRequest) (*ListResult, error)
 	ListWeek(context.Context, *ListRequest) (*ListResult, error)
 	ListMonth(context.Context, *ListRequest) (*ListResult, error)
+	ListRange(context.Context, *ListRangeRequest) (*ListRangeResult, error)
+	FreeBusy(context.Context, *FreeBusyRequest) (*FreeBusyResult, error)
+	Export(context.Context, *ExportRequest) (*ExportResult, error)
+	Import(context.Context, *ImportRequest) (*ImportResult, error)
+	GetSettings(context.Context, *GetSettingsRequest) (*Settings, error)
+	UpdateSettings(context.Context, *Settings) (*Settings, error)
+	// поток изменений событий пользователя, шлюзом не поддерживается
+	Watch(*WatchRequest, Calendar_WatchServer) error
 	mustEmbedUnimplementedCalendarServer()
 }
 
@@ -113,6 +248,15 @@ func (UnimplementedCalendarServer) Update(context.Context, *Event) (*UpdateResul
 func (UnimplementedCalendarServer) Delete(context.Context, *DeleteRequest) (*DeleteResult, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method Delete not implemented")
 }
+func (UnimplementedCalendarServer) Get(context.Context, *GetRequest) (*Event, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Get not implemented")
+}
+func (UnimplementedCalendarServer) ListAll(context.Context, *ListAllRequest) (*ListResult, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ListAll not implemented")
+}
+func (UnimplementedCalendarServer) DeleteAll(context.Context, *DeleteAllRequest) (*DeleteResult, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method DeleteAll not implemented")
+}
 func (UnimplementedCalendarServer) ListDay(context.Context, *ListRequest) (*ListResult, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method ListDay not implemented")
 }
@@ -122,6 +266,27 @@ func (UnimplementedCalendarServer) ListWeek(context.Context, *ListRequest) (*Lis
 func (UnimplementedCalendarServer) ListMonth(context.Context, *ListRequest) (*ListResult, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method ListMonth not implemented")
 }
+func (UnimplementedCalendarServer) ListRange(context.Context, *ListRangeRequest) (*ListRangeResult, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ListRange not implemented")
+}
+func (UnimplementedCalendarServer) FreeBusy(context.Context, *FreeBusyRequest) (*FreeBusyResult, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method FreeBusy not implemented")
+}
+func (UnimplementedCalendarServer) Export(context.Context, *ExportRequest) (*ExportResult, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Export not implemented")
+}
+func (UnimplementedCalendarServer) Import(context.Context, *ImportRequest) (*ImportResult, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Import not implemented")
+}
+func (UnimplementedCalendarServer) GetSettings(context.Context, *GetSettingsRequest) (*Settings, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetSettings not implemented")
+}
+func (UnimplementedCalendarServer) UpdateSettings(context.Context, *Settings) (*Settings, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method UpdateSettings not implemented")
+}
+func (UnimplementedCalendarServer) Watch(*WatchRequest, Calendar_WatchServer) error {
+	return status.Errorf(codes.Unimplemented, "method Watch not implemented")
+}
 func (UnimplementedCalendarServer) mustEmbedUnimplementedCalendarServer() {}
 
 // UnsafeCalendarServer may be embedded to opt out of forward compatibility for this service.
@@ -189,6 +354,60 @@ func _Calendar_Delete_Handler(srv interface{}, ctx context.Context, dec func(int
 	return interceptor(ctx, in, info, handler)
 }
 
+func _Calendar_Get_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(CalendarServer).Get(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/event.Calendar/Get",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(CalendarServer).Get(ctx, req.(*GetRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Calendar_ListAll_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ListAllRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(CalendarServer).ListAll(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/event.Calendar/ListAll",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(CalendarServer).ListAll(ctx, req.(*ListAllRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Calendar_DeleteAll_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(DeleteAllRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(CalendarServer).DeleteAll(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/event.Calendar/DeleteAll",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(CalendarServer).DeleteAll(ctx, req.(*DeleteAllRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
 func _Calendar_ListDay_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
 	in := new(ListRequest)
 	if err := dec(in); err != nil {
@@ -243,6 +462,135 @@ func _Calendar_ListMonth_Handler(srv interface{}, ctx context.Context, dec func(
 	return interceptor(ctx, in, info, handler)
 }
 
+func _Calendar_ListRange_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ListRangeRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(CalendarServer).ListRange(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/event.Calendar/ListRange",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(CalendarServer).ListRange(ctx, req.(*ListRangeRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Calendar_FreeBusy_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(FreeBusyRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(CalendarServer).FreeBusy(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/event.Calendar/FreeBusy",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(CalendarServer).FreeBusy(ctx, req.(*FreeBusyRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Calendar_Export_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ExportRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(CalendarServer).Export(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/event.Calendar/Export",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(CalendarServer).Export(ctx, req.(*ExportRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Calendar_Import_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ImportRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(CalendarServer).Import(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/event.Calendar/Import",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(CalendarServer).Import(ctx, req.(*ImportRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Calendar_GetSettings_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetSettingsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(CalendarServer).GetSettings(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/event.Calendar/GetSettings",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(CalendarServer).GetSettings(ctx, req.(*GetSettingsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Calendar_UpdateSettings_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(Settings)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(CalendarServer).UpdateSettings(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/event.Calendar/UpdateSettings",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(CalendarServer).UpdateSettings(ctx, req.(*Settings))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Calendar_Watch_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(WatchRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(CalendarServer).Watch(m, &calendarWatchServer{stream})
+}
+
+type Calendar_WatchServer interface {
+	Send(*EventChange) error
+	grpc.ServerStream
+}
+
+type calendarWatchServer struct {
+	grpc.ServerStream
+}
+
+func (x *calendarWatchServer) Send(m *EventChange) error {
+	return x.ServerStream.SendMsg(m)
+}
+
 var _Calendar_serviceDesc = grpc.ServiceDesc{
 	ServiceName: "event.Calendar",
 	HandlerType: (*CalendarServer)(nil),
@@ -259,6 +607,18 @@ var _Calendar_serviceDesc = grpc.ServiceDesc{
 			MethodName: "Delete",
 			Handler:    _Calendar_Delete_Handler,
 		},
+		{
+			MethodName: "Get",
+			Handler:    _Calendar_Get_Handler,
+		},
+		{
+			MethodName: "ListAll",
+			Handler:    _Calendar_ListAll_Handler,
+		},
+		{
+			MethodName: "DeleteAll",
+			Handler:    _Calendar_DeleteAll_Handler,
+		},
 		{
 			MethodName: "ListDay",
 			Handler:    _Calendar_ListDay_Handler,
@@ -271,7 +631,37 @@ var _Calendar_serviceDesc = grpc.ServiceDesc{
 			MethodName: "ListMonth",
 			Handler:    _Calendar_ListMonth_Handler,
 		},
+		{
+			MethodName: "ListRange",
+			Handler:    _Calendar_ListRange_Handler,
+		},
+		{
+			MethodName: "FreeBusy",
+			Handler:    _Calendar_FreeBusy_Handler,
+		},
+		{
+			MethodName: "Export",
+			Handler:    _Calendar_Export_Handler,
+		},
+		{
+			MethodName: "Import",
+			Handler:    _Calendar_Import_Handler,
+		},
+		{
+			MethodName: "GetSettings",
+			Handler:    _Calendar_GetSettings_Handler,
+		},
+		{
+			MethodName: "UpdateSettings",
+			Handler:    _Calendar_UpdateSettings_Handler,
+		},
+	},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "Watch",
+			Handler:       _Calendar_Watch_Handler,
+			ServerStreams: true,
+		},
 	},
-	Streams:  []grpc.StreamDesc{},
 	Metadata: "EventService.proto",
 }
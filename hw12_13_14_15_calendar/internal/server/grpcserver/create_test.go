@@ -5,6 +5,8 @@ import (
 	"testing"
 
 	"github.com/stretchr/testify/suite"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
 )
 
 type GRPCCreateTest struct {
@@ -31,7 +33,7 @@ func (s *GRPCCreateTest) TestCreate() {
 	}
 	for _, tt := range tests {
 		s.T().Run(tt.name, func(t *testing.T) {
-			ctx := context.Background()
+			ctx := s.UserContext(1)
 
 			createRes, err := s.client.Create(ctx, tt.event)
 			s.Require().NoError(err)
@@ -42,11 +44,39 @@ func (s *GRPCCreateTest) TestCreate() {
 			s.Require().Equal(1, len(listRes.Events))
 			s.EqualEvents(tt.event, listRes.Events[0])
 
-			_ = s.app.DeleteAll(context.Background())
+			_ = s.app.DeleteAll(context.Background(), 0)
 		})
 	}
 }
 
+func (s *GRPCCreateTest) TestGet() {
+	event := s.NewCommonEvent()
+	id := s.AddEvent(event)
+
+	ctx := s.UserContext(1)
+	res, err := s.client.Get(ctx, &GetRequest{Id: id})
+	s.Require().NoError(err)
+	s.Require().Equal(id, res.Id)
+	s.EqualEvents(event, res)
+
+	_, err = s.client.Get(ctx, &GetRequest{Id: id + 1})
+	s.Require().Equal(codes.NotFound, status.Code(err))
+}
+
+func (s *GRPCCreateTest) TestCreateFail() {
+	ctx := s.UserContext(1)
+	event := s.NewCommonEvent()
+	s.AddEvent(event)
+
+	_, err := s.client.Create(ctx, event)
+	s.Require().Equal(codes.FailedPrecondition, status.Code(err))
+
+	event = s.NewCommonEvent()
+	event.Title = ""
+	_, err = s.client.Create(ctx, event)
+	s.Require().Equal(codes.InvalidArgument, status.Code(err))
+}
+
 func TestGRPCCreateTest(t *testing.T) {
 	suite.Run(t, new(GRPCCreateTest))
 }
@@ -1,10 +1,11 @@
 package grpcserver
 
 import (
-	"context"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/suite"
+	"google.golang.org/protobuf/types/known/timestamppb"
 )
 
 type GRPCDeleteTest struct {
@@ -15,11 +16,35 @@ func (s *GRPCDeleteTest) TestDelete() {
 	event := s.NewCommonEvent()
 	id := s.AddEvent(event)
 
-	ctx := context.Background()
+	ctx := s.UserContext(1)
 	_, err := s.client.Delete(ctx, &DeleteRequest{Id: id})
 	s.Require().NoError(err)
 }
 
+func (s *GRPCDeleteTest) TestDeleteAll() {
+	event := s.NewCommonEvent()
+	s.AddEvent(event)
+	other := s.NewCommonEvent()
+	other.Start = timestamppb.New(event.Stop.AsTime().Add(time.Hour))
+	other.Stop = timestamppb.New(event.Stop.AsTime().Add(2 * time.Hour))
+	s.AddEvent(other)
+	event.UserId = 2
+	s.AddEvent(event)
+
+	ctx := s.UserContext(1)
+	_, err := s.client.DeleteAll(ctx, &DeleteAllRequest{})
+	s.Require().NoError(err)
+	res, err := s.client.ListAll(ctx, &ListAllRequest{})
+	s.Require().NoError(err)
+	s.Require().Equal(0, len(res.Events))
+
+	// события других пользователей остаются
+	res, err = s.client.ListAll(s.UserContext(2), &ListAllRequest{})
+	s.Require().NoError(err)
+	s.Require().Equal(1, len(res.Events))
+	s.EqualEvents(event, res.Events[0])
+}
+
 func TestGRPCDeleteTest(t *testing.T) {
 	suite.Run(t, new(GRPCDeleteTest))
 }
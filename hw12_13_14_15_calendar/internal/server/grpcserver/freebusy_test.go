@@ -0,0 +1,55 @@
+package grpcserver
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/suite"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/durationpb"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+type FreeBusyTest struct {
+	SuiteTest
+}
+
+func (s *FreeBusyTest) TestFreeBusy() {
+	event1 := s.NewCommonEvent()
+	s.AddEvent(event1)
+	event2 := s.NewCommonEvent()
+	event2.UserId = 2
+	event2.Start = timestamppb.New(event1.Stop.AsTime().Add(time.Hour))
+	event2.Stop = timestamppb.New(event2.Start.AsTime().Add(time.Hour))
+	s.AddEvent(event2)
+
+	res, err := s.client.FreeBusy(s.UserContext(1), &FreeBusyRequest{
+		UserIds:  []int32{1, 2},
+		From:     event1.Start,
+		To:       timestamppb.New(event2.Stop.AsTime().Add(time.Hour)),
+		Duration: durationpb.New(30 * time.Minute),
+		Limit:    1,
+	})
+	s.Require().NoError(err)
+	s.Require().Equal(2, len(res.Busy))
+	s.Require().Equal(int32(2), res.Busy[1].UserId)
+	s.Require().Equal(1, len(res.Busy[1].Busy))
+	s.Require().Equal(1, len(res.Free))
+	s.Require().Equal(event1.Stop.AsTime().Unix(), res.Free[0].Start.AsTime().Unix())
+	s.Require().Equal(event2.Start.AsTime().Unix(), res.Free[0].Stop.AsTime().Unix())
+}
+
+func (s *FreeBusyTest) TestFreeBusyFail() {
+	now := time.Now()
+	_, err := s.client.FreeBusy(s.UserContext(1), &FreeBusyRequest{
+		From:     timestamppb.New(now),
+		To:       timestamppb.New(now.Add(time.Hour)),
+		Duration: durationpb.New(time.Hour),
+	})
+	s.Require().Equal(codes.InvalidArgument, status.Code(err))
+}
+
+func TestFreeBusyTest(t *testing.T) {
+	suite.Run(t, new(FreeBusyTest))
+}
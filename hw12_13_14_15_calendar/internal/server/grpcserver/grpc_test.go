@@ -5,6 +5,7 @@ import (
 	"context"
 	"net"
 	"os"
+	"strconv"
 	"time"
 
 	"google.golang.org/protobuf/types/known/durationpb"
@@ -12,6 +13,7 @@ import (
 
 	"github.com/stretchr/testify/suite"
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
 	"google.golang.org/grpc/test/bufconn"
 
 	"github.com/anfilat/otus-go/hw12_13_14_15_calendar/internal/app"
@@ -35,17 +37,20 @@ func (s *SuiteTest) SetupTest() {
 	ctx := context.Background()
 
 	var buf bytes.Buffer
-	s.logg, _ = logger.New("", &buf, "")
+	s.logg, _ = logger.New(logger.Options{}, &buf)
 
-	dbConnect := os.Getenv("PQ_TEST")
-	s.db, _ = initstorage.New(ctx, dbConnect == "", dbConnect)
+	dbDriver, dbConnect := initstorage.DriverPostgres, os.Getenv("PQ_TEST")
+	if sqliteConnect := os.Getenv("SQLITE_TEST"); sqliteConnect != "" {
+		dbDriver, dbConnect = initstorage.DriverSQLite, sqliteConnect
+	}
+	s.db, _ = initstorage.New(ctx, dbConnect == "", dbDriver, dbConnect)
 
 	s.app = app.New(s.logg, s.db)
 
 	s.conn, _ = grpc.DialContext(ctx, "", grpc.WithInsecure(), grpc.WithContextDialer(dialer(s)))
 	s.client = NewCalendarClient(s.conn)
 
-	_ = s.app.DeleteAll(ctx)
+	_ = s.app.DeleteAll(ctx, 0)
 }
 
 func dialer(s *SuiteTest) func(context.Context, string) (net.Conn, error) {
@@ -69,7 +74,7 @@ func (s *SuiteTest) TearDownTest() {
 	_ = s.conn.Close()
 	s.grpcSrv.GracefulStop()
 	_ = s.listener.Close()
-	_ = s.app.DeleteAll(ctx)
+	_ = s.app.DeleteAll(ctx, 0)
 	_ = s.db.Close(ctx)
 }
 
@@ -86,6 +91,7 @@ func (s *SuiteTest) NewCommonEvent() *Event {
 		Description:  "the event",
 		UserId:       1,
 		Notification: durationpb.New(notification),
+		Version:      storage.InitialVersion,
 	}
 }
 
@@ -102,8 +108,12 @@ func (s *SuiteTest) EqualEvents(event1, event2 *Event) {
 	}
 }
 
+func (s *SuiteTest) UserContext(userID int) context.Context {
+	return metadata.AppendToOutgoingContext(context.Background(), UserIDMetadata, strconv.Itoa(userID))
+}
+
 func (s *SuiteTest) AddEvent(event *Event) int32 {
-	ctx := context.Background()
+	ctx := s.UserContext(int(event.UserId))
 	createRes, err := s.client.Create(ctx, event)
 	s.Require().NoError(err)
 	return createRes.Id
@@ -0,0 +1,77 @@
+package grpcserver
+
+import (
+	"context"
+	"time"
+
+	"google.golang.org/grpc/codes"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/status"
+
+	"github.com/anfilat/otus-go/hw12_13_14_15_calendar/internal/health"
+)
+
+// healthWatchInterval - период проверки готовности в потоке Watch.
+const healthWatchInterval = time.Second
+
+// healthService - стандартный сервис grpc.health.v1.Health. Пустое имя сервиса
+// означает весь сервер, кроме него известен только сервис календаря.
+type healthService struct {
+	healthpb.UnimplementedHealthServer
+
+	health health.Health
+}
+
+func newHealthService(health health.Health) *healthService {
+	return &healthService{health: health}
+}
+
+func (h *healthService) Check(ctx context.Context, req *healthpb.HealthCheckRequest) (*healthpb.HealthCheckResponse, error) {
+	if !isKnownService(req.Service) {
+		return nil, status.Errorf(codes.NotFound, "unknown service %q", req.Service)
+	}
+	return &healthpb.HealthCheckResponse{Status: h.servingStatus(ctx)}, nil
+}
+
+// Watch отправляет текущее состояние и затем каждое его изменение.
+func (h *healthService) Watch(req *healthpb.HealthCheckRequest, stream healthpb.Health_WatchServer) error {
+	ctx := stream.Context()
+	if !isKnownService(req.Service) {
+		// по протоколу для неизвестного сервиса поток не закрывается
+		err := stream.Send(&healthpb.HealthCheckResponse{Status: healthpb.HealthCheckResponse_SERVICE_UNKNOWN})
+		if err != nil {
+			return err
+		}
+		<-ctx.Done()
+		return status.FromContextError(ctx.Err()).Err()
+	}
+
+	last := healthpb.HealthCheckResponse_UNKNOWN
+	ticker := time.NewTicker(healthWatchInterval)
+	defer ticker.Stop()
+	for {
+		current := h.servingStatus(ctx)
+		if current != last {
+			if err := stream.Send(&healthpb.HealthCheckResponse{Status: current}); err != nil {
+				return err
+			}
+			last = current
+		}
+		select {
+		case <-ctx.Done():
+			return status.FromContextError(ctx.Err()).Err()
+		case <-ticker.C:
+		}
+	}
+}
+
+func (h *healthService) servingStatus(ctx context.Context) healthpb.HealthCheckResponse_ServingStatus {
+	if err := h.health.Ready(ctx); err != nil {
+		return healthpb.HealthCheckResponse_NOT_SERVING
+	}
+	return healthpb.HealthCheckResponse_SERVING
+}
+
+func isKnownService(service string) bool {
+	return service == "" || service == _Calendar_serviceDesc.ServiceName
+}
@@ -0,0 +1,74 @@
+package grpcserver
+
+import (
+	"context"
+	"net"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+
+	"github.com/anfilat/otus-go/hw12_13_14_15_calendar/internal/health"
+)
+
+func newHealthClient(t *testing.T, h health.Health) healthpb.HealthClient {
+	listener := bufconn.Listen(1024 * 1024)
+	srv := grpc.NewServer()
+	healthpb.RegisterHealthServer(srv, newHealthService(h))
+	go func() {
+		_ = srv.Serve(listener)
+	}()
+
+	conn, err := grpc.DialContext(context.Background(), "", grpc.WithInsecure(),
+		grpc.WithContextDialer(func(context.Context, string) (net.Conn, error) {
+			return listener.Dial()
+		}))
+	require.NoError(t, err)
+	t.Cleanup(func() {
+		_ = conn.Close()
+		srv.Stop()
+	})
+	return healthpb.NewHealthClient(conn)
+}
+
+func TestHealthCheck(t *testing.T) {
+	ctx := context.Background()
+	h := health.New()
+	client := newHealthClient(t, h)
+
+	for _, service := range []string{"", "event.Calendar"} {
+		res, err := client.Check(ctx, &healthpb.HealthCheckRequest{Service: service})
+		require.NoError(t, err)
+		require.Equal(t, healthpb.HealthCheckResponse_SERVING, res.Status)
+	}
+
+	_, err := client.Check(ctx, &healthpb.HealthCheckRequest{Service: "unknown"})
+	require.Equal(t, codes.NotFound, status.Code(err))
+
+	h.SetNotReady()
+	res, err := client.Check(ctx, &healthpb.HealthCheckRequest{})
+	require.NoError(t, err)
+	require.Equal(t, healthpb.HealthCheckResponse_NOT_SERVING, res.Status)
+}
+
+func TestHealthWatch(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	h := health.New()
+	client := newHealthClient(t, h)
+
+	stream, err := client.Watch(ctx, &healthpb.HealthCheckRequest{})
+	require.NoError(t, err)
+	res, err := stream.Recv()
+	require.NoError(t, err)
+	require.Equal(t, healthpb.HealthCheckResponse_SERVING, res.Status)
+
+	h.SetNotReady()
+	res, err = stream.Recv()
+	require.NoError(t, err)
+	require.Equal(t, healthpb.HealthCheckResponse_NOT_SERVING, res.Status)
+}
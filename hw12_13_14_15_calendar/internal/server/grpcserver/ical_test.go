@@ -0,0 +1,49 @@
+package grpcserver
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/suite"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+type ICalTest struct {
+	SuiteTest
+}
+
+func (s *ICalTest) TestExportImport() {
+	event := s.NewCommonEvent()
+	s.AddEvent(event)
+
+	exportRes, err := s.client.Export(s.UserContext(1), &ExportRequest{})
+	s.Require().NoError(err)
+	s.Require().Contains(exportRes.Calendar, "SUMMARY:some event")
+
+	importRes, err := s.client.Import(s.UserContext(2), &ImportRequest{Calendar: exportRes.Calendar})
+	s.Require().NoError(err)
+	s.Require().Equal(1, len(importRes.Entries))
+	s.Require().Equal("", importRes.Entries[0].Error)
+	s.Require().NotEqual(int32(0), importRes.Entries[0].Id)
+
+	// у первого пользователя время занято
+	importRes, err = s.client.Import(s.UserContext(1), &ImportRequest{Calendar: exportRes.Calendar})
+	s.Require().NoError(err)
+	s.Require().Equal(1, len(importRes.Entries))
+	s.Require().NotEqual("", importRes.Entries[0].Error)
+
+	exportRes, err = s.client.Export(s.UserContext(2), &ExportRequest{From: event.Stop})
+	s.Require().NoError(err)
+	s.Require().False(strings.Contains(exportRes.Calendar, "BEGIN:VEVENT"))
+}
+
+func (s *ICalTest) TestImportFail() {
+	_, err := s.client.Import(s.UserContext(1), &ImportRequest{Calendar: "not a calendar"})
+	s.Require().Error(err)
+	s.Require().Equal(codes.InvalidArgument, status.Code(err))
+}
+
+func TestICalTest(t *testing.T) {
+	suite.Run(t, new(ICalTest))
+}
@@ -3,8 +3,12 @@ package grpcserver
 import (
 	"context"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/suite"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/timestamppb"
 )
 
 type GRPCListTest struct {
@@ -15,7 +19,7 @@ func (s *GRPCListTest) TestListDay() {
 	event := s.NewCommonEvent()
 	s.AddEvent(event)
 
-	ctx := context.Background()
+	ctx := s.UserContext(1)
 	res, err := s.client.ListDay(ctx, &ListRequest{Date: event.Start})
 	s.Require().NoError(err)
 	s.Require().Equal(1, len(res.Events))
@@ -26,7 +30,7 @@ func (s *GRPCListTest) TestListWeek() {
 	event := s.NewCommonEvent()
 	s.AddEvent(event)
 
-	ctx := context.Background()
+	ctx := s.UserContext(1)
 	res, err := s.client.ListWeek(ctx, &ListRequest{Date: event.Start})
 	s.Require().NoError(err)
 	s.Require().Equal(1, len(res.Events))
@@ -37,13 +41,158 @@ func (s *GRPCListTest) TestListMonth() {
 	event := s.NewCommonEvent()
 	s.AddEvent(event)
 
-	ctx := context.Background()
+	ctx := s.UserContext(1)
 	res, err := s.client.ListMonth(ctx, &ListRequest{Date: event.Start})
 	s.Require().NoError(err)
 	s.Require().Equal(1, len(res.Events))
 	s.EqualEvents(event, res.Events[0])
 }
 
+func (s *GRPCListTest) TestListRecurring() {
+	event := s.NewCommonEvent()
+	event.Rrule = "FREQ=DAILY;COUNT=3"
+	event.Exdates = []*timestamppb.Timestamp{
+		timestamppb.New(event.Start.AsTime().AddDate(0, 0, 1)),
+	}
+	s.AddEvent(event)
+
+	ctx := s.UserContext(1)
+	for i, count := range []int{1, 0, 1, 0} {
+		date := timestamppb.New(event.Start.AsTime().AddDate(0, 0, i))
+		res, err := s.client.ListDay(ctx, &ListRequest{Date: date})
+		s.Require().NoError(err)
+		s.Require().Equal(count, len(res.Events))
+		if count > 0 {
+			s.Require().Equal(date.AsTime().Unix(), res.Events[0].Start.AsTime().Unix())
+			s.Require().Equal(event.Rrule, res.Events[0].Rrule)
+			s.Require().Equal(1, len(res.Events[0].Exdates))
+		}
+	}
+}
+
+func (s *GRPCListTest) TestListMultiDay() {
+	day := time.Now().UTC().AddDate(0, 0, 7).Truncate(24 * time.Hour)
+	conference := s.NewCommonEvent()
+	conference.Start = timestamppb.New(day.Add(9 * time.Hour))
+	conference.Stop = timestamppb.New(day.AddDate(0, 0, 2).Add(18 * time.Hour))
+	conference.AllDay = true
+	s.AddEvent(conference)
+
+	ctx := s.UserContext(1)
+	res, err := s.client.ListDay(ctx, &ListRequest{Date: timestamppb.New(day.AddDate(0, 0, 1))})
+	s.Require().NoError(err)
+	s.Require().Equal(1, len(res.Events))
+	s.Require().True(res.Events[0].AllDay)
+	s.Require().Equal(day, res.Events[0].Start.AsTime())
+	s.Require().Equal(day.AddDate(0, 0, 3), res.Events[0].Stop.AsTime())
+
+	res, err = s.client.ListDay(ctx, &ListRequest{Date: timestamppb.New(day.AddDate(0, 0, 3))})
+	s.Require().NoError(err)
+	s.Require().Equal(0, len(res.Events))
+}
+
+func (s *GRPCListTest) TestListRange() {
+	event1 := s.NewCommonEvent()
+	s.AddEvent(event1)
+	event2 := s.NewCommonEvent()
+	event2.Title = "other event"
+	event2.Start = event1.Stop
+	event2.Stop = timestamppb.New(event2.Start.AsTime().Add(time.Hour))
+	s.AddEvent(event2)
+
+	ctx := s.UserContext(1)
+	req := &ListRangeRequest{From: event1.Start, To: event2.Stop, Desc: true, Limit: 1}
+	res, err := s.client.ListRange(ctx, req)
+	s.Require().NoError(err)
+	s.Require().Equal(1, len(res.Events))
+	s.EqualEvents(event2, res.Events[0])
+	s.Require().NotEqual("", res.NextCursor)
+
+	req.Cursor = res.NextCursor
+	res, err = s.client.ListRange(ctx, req)
+	s.Require().NoError(err)
+	s.Require().Equal(1, len(res.Events))
+	s.EqualEvents(event1, res.Events[0])
+	s.Require().Equal("", res.NextCursor)
+
+	res, err = s.client.ListRange(ctx, &ListRangeRequest{From: event1.Start, To: event2.Stop, Search: "other"})
+	s.Require().NoError(err)
+	s.Require().Equal(1, len(res.Events))
+	s.EqualEvents(event2, res.Events[0])
+}
+
+func (s *GRPCListTest) TestListTimeZone() {
+	day := time.Now().UTC().AddDate(0, 0, 7).Truncate(24 * time.Hour)
+	event := s.NewCommonEvent()
+	event.Start = timestamppb.New(day.Add(23*time.Hour + 30*time.Minute))
+	event.Stop = timestamppb.New(event.Start.AsTime().Add(20 * time.Minute))
+	s.AddEvent(event)
+
+	ctx := s.UserContext(1)
+	date := timestamppb.New(day.AddDate(0, 0, 1).Add(12 * time.Hour))
+	res, err := s.client.ListDay(ctx, &ListRequest{Date: date})
+	s.Require().NoError(err)
+	s.Require().Equal(0, len(res.Events))
+
+	res, err = s.client.ListDay(ctx, &ListRequest{Date: date, TimeZone: "Asia/Tokyo"})
+	s.Require().NoError(err)
+	s.Require().Equal(1, len(res.Events))
+	s.EqualEvents(event, res.Events[0])
+
+	_, err = s.client.UpdateSettings(ctx, &Settings{TimeZone: "Asia/Tokyo"})
+	s.Require().NoError(err)
+	res, err = s.client.ListDay(ctx, &ListRequest{Date: date})
+	s.Require().NoError(err)
+	s.Require().Equal(1, len(res.Events))
+
+	res, err = s.client.ListDay(ctx, &ListRequest{Date: date, TimeZone: "UTC"})
+	s.Require().NoError(err)
+	s.Require().Equal(0, len(res.Events))
+
+	_, err = s.client.ListDay(ctx, &ListRequest{Date: date, TimeZone: "Mars/Olympus"})
+	s.Require().Equal(codes.InvalidArgument, status.Code(err))
+}
+
+func (s *GRPCListTest) TestSettings() {
+	ctx := s.UserContext(1)
+	res, err := s.client.GetSettings(ctx, &GetSettingsRequest{})
+	s.Require().NoError(err)
+	s.Require().Equal("", res.TimeZone)
+
+	_, err = s.client.UpdateSettings(ctx, &Settings{TimeZone: "Europe/Moscow"})
+	s.Require().NoError(err)
+	res, err = s.client.GetSettings(ctx, &GetSettingsRequest{})
+	s.Require().NoError(err)
+	s.Require().Equal("Europe/Moscow", res.TimeZone)
+
+	res, err = s.client.GetSettings(s.UserContext(2), &GetSettingsRequest{})
+	s.Require().NoError(err)
+	s.Require().Equal("", res.TimeZone)
+
+	_, err = s.client.UpdateSettings(ctx, &Settings{TimeZone: "Mars/Olympus"})
+	s.Require().Equal(codes.InvalidArgument, status.Code(err))
+	// пояс сервера не принимается
+	_, err = s.client.UpdateSettings(ctx, &Settings{TimeZone: "Local"})
+	s.Require().Equal(codes.InvalidArgument, status.Code(err))
+
+	_, err = s.client.GetSettings(context.Background(), &GetSettingsRequest{})
+	s.Require().Equal(codes.Unauthenticated, status.Code(err))
+}
+
+func (s *GRPCListTest) TestListRangeFail() {
+	event := s.NewCommonEvent()
+	_, err := s.client.ListRange(s.UserContext(1), &ListRangeRequest{From: event.Stop, To: event.Start})
+	s.Require().Equal(codes.InvalidArgument, status.Code(err))
+}
+
+func (s *GRPCListTest) TestCreateFailInvalidRule() {
+	event := s.NewCommonEvent()
+	event.Rrule = "FREQ=HOURLY"
+
+	_, err := s.client.Create(s.UserContext(1), event)
+	s.Require().Equal(codes.InvalidArgument, status.Code(err))
+}
+
 func TestGRPCListTest(t *testing.T) {
 	suite.Run(t, new(GRPCListTest))
 }
@@ -2,52 +2,88 @@ package grpcserver
 
 import (
 	"context"
-	"fmt"
 	"strings"
 	"time"
 
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
 
 	"github.com/anfilat/otus-go/hw12_13_14_15_calendar/internal/logger"
 )
 
-func loggingInterceptor(logger logger.Logger) grpc.UnaryServerInterceptor {
+// loggingInterceptor кладет в контекст логгер запроса с его ID и ID пользователя
+// и после ответа пишет запись о вызове.
+func loggingInterceptor(logg logger.Logger) grpc.UnaryServerInterceptor {
 	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
 		start := time.Now()
 
-		md, ok := metadata.FromIncomingContext(ctx)
-		result, err := handler(ctx, req)
-
-		logger.Info(
-			fmt.Sprintf("%s %s %s",
-				method(info.FullMethod),
-				latency(start),
-				userAgent(md, ok),
-			))
+		requestLogger, id := newRequestLogger(ctx, logg)
+		// заголовки ответа еще не отправлены, поэтому ошибки нет
+		_ = grpc.SetHeader(ctx, metadata.Pairs(RequestIDMetadata, id))
+		result, err := handler(logger.WithContext(ctx, requestLogger), req)
 
+		logCall(ctx, requestLogger, info.FullMethod, start, err)
 		return result, err
 	}
 }
 
-func method(full string) string {
-	return full[strings.LastIndex(full, "/"):]
+func streamLoggingInterceptor(logg logger.Logger) grpc.StreamServerInterceptor {
+	return func(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		start := time.Now()
+
+		ctx := ss.Context()
+		requestLogger, id := newRequestLogger(ctx, logg)
+		_ = ss.SetHeader(metadata.Pairs(RequestIDMetadata, id))
+		err := handler(srv, &loggedStream{ss, logger.WithContext(ctx, requestLogger)})
+
+		logCall(ctx, requestLogger, info.FullMethod, start, err)
+		return err
+	}
+}
+
+// loggedStream подменяет контекст потока контекстом с логгером запроса.
+type loggedStream struct {
+	grpc.ServerStream
+	ctx context.Context
 }
 
-func latency(start time.Time) string {
-	return fmt.Sprintf("%dms", time.Since(start).Milliseconds())
+func (s *loggedStream) Context() context.Context {
+	return s.ctx
 }
 
-func userAgent(md metadata.MD, ok bool) (result string) {
-	if !ok {
-		return
+// newRequestLogger возвращает логгер запроса и ID запроса из метаданных или новый.
+func newRequestLogger(ctx context.Context, logg logger.Logger) (logger.Logger, string) {
+	md, _ := metadata.FromIncomingContext(ctx)
+	id := firstValue(md, RequestIDMetadata)
+	if id == "" {
+		id = logger.NewRequestID()
 	}
-	agents, ok := md["user-agent"]
-	if !ok {
-		return
+	requestLogger := logg.WithField(logger.RequestIDField, id)
+	if userID, err := getUserID(ctx); err == nil {
+		requestLogger = requestLogger.WithField(logger.UserIDField, userID)
 	}
-	if len(agents) == 0 {
-		return
+	return requestLogger, id
+}
+
+func logCall(ctx context.Context, requestLogger logger.Logger, fullMethod string, start time.Time, err error) {
+	md, _ := metadata.FromIncomingContext(ctx)
+	requestLogger.WithFields(logger.Fields{
+		"method":     method(fullMethod),
+		"code":       status.Code(err).String(),
+		"latency_ms": time.Since(start).Milliseconds(),
+		"user_agent": firstValue(md, "user-agent"),
+	}).Info("grpc request")
+}
+
+func method(full string) string {
+	return full[strings.LastIndex(full, "/"):]
+}
+
+func firstValue(md metadata.MD, key string) string {
+	values := md.Get(key)
+	if len(values) == 0 {
+		return ""
 	}
-	return `"` + agents[0] + `"`
+	return values[0]
 }
@@ -0,0 +1,25 @@
+package grpcserver
+
+import (
+	"context"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/status"
+
+	"github.com/anfilat/otus-go/hw12_13_14_15_calendar/internal/metrics"
+)
+
+func metricsInterceptor(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+	start := time.Now()
+	result, err := handler(ctx, req)
+	metrics.ObserveGRPC(method(info.FullMethod), status.Code(err).String(), time.Since(start))
+	return result, err
+}
+
+func streamMetricsInterceptor(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+	start := time.Now()
+	err := handler(srv, ss)
+	metrics.ObserveGRPC(method(info.FullMethod), status.Code(err).String(), time.Since(start))
+	return err
+}
@@ -1,18 +1,30 @@
-//go:generate protoc -I "/usr/local/include/" --proto_path=../../../api/ --go_out=. --go-grpc_out=. ../../../api/EventService.proto
+//go:generate protoc -I "/usr/local/include/" --proto_path=../../../api/ --go_out=. --go-grpc_out=. --grpc-gateway_out=. ../../../api/EventService.proto
 package grpcserver
 
 import (
 	"context"
 
 	"github.com/anfilat/otus-go/hw12_13_14_15_calendar/internal/app"
+	"github.com/anfilat/otus-go/hw12_13_14_15_calendar/internal/health"
 	"github.com/anfilat/otus-go/hw12_13_14_15_calendar/internal/logger"
 )
 
+// UserIDMetadata - ключ метаданных запроса с ID пользователя.
+const UserIDMetadata = "x-user-id"
+
+// RequestIDMetadata - ключ метаданных запроса и ответа с ID запроса.
+// Если клиент его не передал, ID создается сервером.
+const RequestIDMetadata = "x-request-id"
+
+// ResumeTokenMetadata - ключ заголовка ответа Watch с токеном, по которому подписка
+// возобновляется, если до обрыва не пришло ни одного изменения.
+const ResumeTokenMetadata = "x-resume-token"
+
 type Server interface {
 	Start(addr string) error
 	Stop(ctx context.Context) error
 }
 
-func NewServer(app app.App, logger logger.Logger) Server {
-	return newServer(app, logger)
+func NewServer(app app.App, health health.Health, logger logger.Logger) Server {
+	return newServer(app, health, logger)
 }
@@ -5,20 +5,24 @@ import (
 	"net"
 
 	"google.golang.org/grpc"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
 
 	"github.com/anfilat/otus-go/hw12_13_14_15_calendar/internal/app"
+	"github.com/anfilat/otus-go/hw12_13_14_15_calendar/internal/health"
 	"github.com/anfilat/otus-go/hw12_13_14_15_calendar/internal/logger"
 )
 
 type server struct {
 	app    app.App
+	health health.Health
 	logger logger.Logger
 	srv    *grpc.Server
 }
 
-func newServer(app app.App, logger logger.Logger) *server {
+func newServer(app app.App, health health.Health, logger logger.Logger) *server {
 	s := &server{
 		app:    app,
+		health: health,
 		logger: logger,
 	}
 	return s
@@ -30,14 +34,29 @@ func (s *server) Start(addr string) error {
 		return err
 	}
 
-	s.srv = grpc.NewServer(grpc.UnaryInterceptor(loggingInterceptor(s.logger)))
+	s.srv = grpc.NewServer(
+		grpc.ChainUnaryInterceptor(tracingInterceptor, loggingInterceptor(s.logger), metricsInterceptor),
+		grpc.ChainStreamInterceptor(streamTracingInterceptor, streamLoggingInterceptor(s.logger), streamMetricsInterceptor),
+	)
 	RegisterCalendarServer(s.srv, NewService(s.app))
+	healthpb.RegisterHealthServer(s.srv, newHealthService(s.health))
 
 	s.logger.Info("starting grpc server on ", addr)
 	return s.srv.Serve(lsn)
 }
 
-func (s *server) Stop(_ context.Context) error {
-	s.srv.GracefulStop()
+func (s *server) Stop(ctx context.Context) error {
+	stopped := make(chan struct{})
+	go func() {
+		s.srv.GracefulStop()
+		close(stopped)
+	}()
+
+	select {
+	case <-stopped:
+	case <-ctx.Done():
+		// потоки Watch сами не завершаются, закрываем их принудительно
+		s.srv.Stop()
+	}
 	return nil
 }
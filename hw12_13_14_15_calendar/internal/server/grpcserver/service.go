@@ -2,9 +2,13 @@ package grpcserver
 
 import (
 	"context"
+	"errors"
+	"strconv"
+	"strings"
 	"time"
 
 	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
 	"google.golang.org/grpc/status"
 	"google.golang.org/protobuf/types/known/durationpb"
 	"google.golang.org/protobuf/types/known/timestamppb"
@@ -26,38 +30,95 @@ func NewService(app app.App) *Service {
 }
 
 func (s *Service) Create(ctx context.Context, req *Event) (*CreateResult, error) {
+	userID, err := getUserID(ctx)
+	if err != nil {
+		return nil, err
+	}
+	recurrence, err := getRecurrence(req)
+	if err != nil {
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+
 	id, err := s.app.Create(
 		ctx,
-		int(req.UserId),
+		userID,
 		req.Title,
 		req.Description,
 		req.Start.AsTime(),
 		req.Stop.AsTime(),
+		req.AllDay,
 		getNotification(req),
+		recurrence,
 	)
 	if err != nil {
-		return nil, status.Error(codes.InvalidArgument, err.Error())
+		return nil, appError(err)
 	}
 
-	return &CreateResult{Id: int32(id)}, nil
+	return &CreateResult{Id: int32(id), Version: storage.InitialVersion}, nil
 }
 
 func (s *Service) Update(ctx context.Context, req *Event) (*UpdateResult, error) {
+	userID, err := getUserID(ctx)
+	if err != nil {
+		return nil, err
+	}
+	recurrence, err := getRecurrence(req)
+	if err != nil {
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+
 	change := storage.Event{
 		ID:           int(req.Id),
 		Title:        req.Title,
 		Start:        req.Start.AsTime(),
 		Stop:         req.Stop.AsTime(),
 		Description:  req.Description,
-		UserID:       int(req.UserId),
+		UserID:       userID,
 		Notification: getNotification(req),
+		Recurrence:   recurrence,
+		AllDay:       req.AllDay,
+		Version:      int(req.Version),
 	}
-	err := s.app.Update(ctx, int(req.Id), change)
+	version, err := s.app.Update(ctx, userID, int(req.Id), change)
 	if err != nil {
-		return nil, status.Error(codes.InvalidArgument, err.Error())
+		return nil, appError(err)
+	}
+
+	return &UpdateResult{Version: int32(version)}, nil
+}
+
+// getUserID возвращает пользователя из метаданных запроса.
+func getUserID(ctx context.Context) (int, error) {
+	md, _ := metadata.FromIncomingContext(ctx)
+	values := md.Get(UserIDMetadata)
+	if len(values) == 0 || values[0] == "" {
+		return 0, status.Error(codes.Unauthenticated, "no user id in metadata")
+	}
+	userID, err := strconv.Atoi(values[0])
+	if err != nil || userID <= 0 {
+		return 0, status.Errorf(codes.Unauthenticated, "invalid user id %q", values[0])
 	}
+	return userID, nil
+}
 
-	return &UpdateResult{}, nil
+// appError переводит ошибку приложения в статус gRPC.
+func appError(err error) error {
+	switch {
+	case errors.Is(err, storage.ErrNotExistsEvent):
+		return status.Error(codes.NotFound, err.Error())
+	case errors.Is(err, app.ErrDateBusy):
+		return status.Error(codes.FailedPrecondition, err.Error())
+	case errors.Is(err, storage.ErrConflict):
+		return status.Error(codes.Aborted, err.Error())
+	case errors.Is(err, app.ErrForbidden):
+		return status.Error(codes.PermissionDenied, err.Error())
+	case errors.Is(err, app.ErrTokenExpired):
+		return status.Error(codes.OutOfRange, err.Error())
+	case app.IsValidationError(err):
+		return status.Error(codes.InvalidArgument, err.Error())
+	default:
+		return status.Error(codes.Internal, err.Error())
+	}
 }
 
 func getNotification(req *Event) *time.Duration {
@@ -68,58 +129,317 @@ func getNotification(req *Event) *time.Duration {
 	return nil
 }
 
+func getRecurrence(req *Event) (*storage.Recurrence, error) {
+	exceptions := make([]time.Time, 0, len(req.Exdates))
+	for _, date := range req.Exdates {
+		exceptions = append(exceptions, date.AsTime())
+	}
+	return storage.NewRecurrence(req.Rrule, exceptions)
+}
+
 func (s *Service) Delete(ctx context.Context, req *DeleteRequest) (*DeleteResult, error) {
-	err := s.app.Delete(ctx, int(req.Id))
+	userID, err := getUserID(ctx)
 	if err != nil {
-		return nil, status.Error(codes.InvalidArgument, err.Error())
+		return nil, err
+	}
+	err = s.app.Delete(ctx, userID, int(req.Id))
+	// удаление несуществующего события не ошибка, как и в HTTP API /api/delete
+	if err != nil && !errors.Is(err, storage.ErrNotExistsEvent) {
+		return nil, appError(err)
+	}
+
+	return &DeleteResult{}, nil
+}
+
+func (s *Service) Get(ctx context.Context, req *GetRequest) (*Event, error) {
+	userID, err := getUserID(ctx)
+	if err != nil {
+		return nil, err
+	}
+	event, err := s.app.Get(ctx, userID, int(req.Id))
+	if err != nil {
+		return nil, appError(err)
+	}
+
+	return storageEventToGRPCEvent(event), nil
+}
+
+func (s *Service) ListAll(ctx context.Context, _ *ListAllRequest) (*ListResult, error) {
+	userID, err := getUserID(ctx)
+	if err != nil {
+		return nil, err
+	}
+	events, err := s.app.ListAll(ctx, userID)
+	if err != nil {
+		return nil, appError(err)
+	}
+
+	return &ListResult{Events: storageEventsToGRPCEvents(events)}, nil
+}
+
+func (s *Service) DeleteAll(ctx context.Context, _ *DeleteAllRequest) (*DeleteResult, error) {
+	userID, err := getUserID(ctx)
+	if err != nil {
+		return nil, err
+	}
+	err = s.app.DeleteAll(ctx, userID)
+	if err != nil {
+		return nil, appError(err)
 	}
 
 	return &DeleteResult{}, nil
 }
 
 func (s *Service) ListDay(ctx context.Context, req *ListRequest) (*ListResult, error) {
-	events, err := s.app.ListDay(ctx, req.Date.AsTime())
+	userID, err := getUserID(ctx)
 	if err != nil {
-		return nil, status.Error(codes.InvalidArgument, err.Error())
+		return nil, err
+	}
+	events, err := s.app.ListDay(ctx, userID, req.Date.AsTime(), req.TimeZone)
+	if err != nil {
+		return nil, appError(err)
 	}
 
 	return &ListResult{Events: storageEventsToGRPCEvents(events)}, nil
 }
 
 func (s *Service) ListWeek(ctx context.Context, req *ListRequest) (*ListResult, error) {
-	events, err := s.app.ListWeek(ctx, req.Date.AsTime())
+	userID, err := getUserID(ctx)
 	if err != nil {
-		return nil, status.Error(codes.InvalidArgument, err.Error())
+		return nil, err
+	}
+	events, err := s.app.ListWeek(ctx, userID, req.Date.AsTime(), req.TimeZone)
+	if err != nil {
+		return nil, appError(err)
 	}
 
 	return &ListResult{Events: storageEventsToGRPCEvents(events)}, nil
 }
 
 func (s *Service) ListMonth(ctx context.Context, req *ListRequest) (*ListResult, error) {
-	events, err := s.app.ListMonth(ctx, req.Date.AsTime())
+	userID, err := getUserID(ctx)
 	if err != nil {
-		return nil, status.Error(codes.InvalidArgument, err.Error())
+		return nil, err
+	}
+	events, err := s.app.ListMonth(ctx, userID, req.Date.AsTime(), req.TimeZone)
+	if err != nil {
+		return nil, appError(err)
 	}
 
 	return &ListResult{Events: storageEventsToGRPCEvents(events)}, nil
 }
 
+func (s *Service) GetSettings(ctx context.Context, _ *GetSettingsRequest) (*Settings, error) {
+	userID, err := getUserID(ctx)
+	if err != nil {
+		return nil, err
+	}
+	settings, err := s.app.GetSettings(ctx, userID)
+	if err != nil {
+		return nil, appError(err)
+	}
+
+	return &Settings{TimeZone: settings.TimeZone}, nil
+}
+
+func (s *Service) UpdateSettings(ctx context.Context, req *Settings) (*Settings, error) {
+	userID, err := getUserID(ctx)
+	if err != nil {
+		return nil, err
+	}
+	err = s.app.SaveSettings(ctx, userID, storage.UserSettings{TimeZone: req.TimeZone})
+	if err != nil {
+		return nil, appError(err)
+	}
+
+	return &Settings{TimeZone: req.TimeZone}, nil
+}
+
+func (s *Service) ListRange(ctx context.Context, req *ListRangeRequest) (*ListRangeResult, error) {
+	userID, err := getUserID(ctx)
+	if err != nil {
+		return nil, err
+	}
+	page, err := s.app.ListRange(ctx, userID, req.From.AsTime(), req.To.AsTime(), storage.Filter{
+		Search: req.Search,
+		Desc:   req.Desc,
+		Cursor: req.Cursor,
+		Limit:  int(req.Limit),
+	})
+	if err != nil {
+		return nil, appError(err)
+	}
+
+	return &ListRangeResult{Events: storageEventsToGRPCEvents(page.Events), NextCursor: page.NextCursor}, nil
+}
+
+func (s *Service) FreeBusy(ctx context.Context, req *FreeBusyRequest) (*FreeBusyResult, error) {
+	if _, err := getUserID(ctx); err != nil {
+		return nil, err
+	}
+	userIDs := make([]int, 0, len(req.UserIds))
+	for _, userID := range req.UserIds {
+		userIDs = append(userIDs, int(userID))
+	}
+	freeBusy, err := s.app.FreeBusy(
+		ctx,
+		userIDs,
+		req.From.AsTime(),
+		req.To.AsTime(),
+		req.Duration.AsDuration(),
+		int(req.Limit),
+	)
+	if err != nil {
+		return nil, appError(err)
+	}
+
+	result := &FreeBusyResult{Free: appIntervalsToGRPCIntervals(freeBusy.Free)}
+	for _, userBusy := range freeBusy.Busy {
+		result.Busy = append(result.Busy, &UserBusy{
+			UserId: int32(userBusy.UserID),
+			Busy:   appIntervalsToGRPCIntervals(userBusy.Busy),
+		})
+	}
+	return result, nil
+}
+
+func appIntervalsToGRPCIntervals(intervals []app.Interval) []*Interval {
+	result := make([]*Interval, 0, len(intervals))
+	for _, interval := range intervals {
+		result = append(result, &Interval{
+			Start: timestamppb.New(interval.Start),
+			Stop:  timestamppb.New(interval.Stop),
+		})
+	}
+	return result
+}
+
+func (s *Service) Export(ctx context.Context, req *ExportRequest) (*ExportResult, error) {
+	userID, err := getUserID(ctx)
+	if err != nil {
+		return nil, err
+	}
+	var from, to time.Time
+	if req.From != nil {
+		from = req.From.AsTime()
+	}
+	if req.To != nil {
+		to = req.To.AsTime()
+	}
+
+	var buf strings.Builder
+	err = s.app.Export(ctx, &buf, userID, from, to)
+	if err != nil {
+		return nil, appError(err)
+	}
+
+	return &ExportResult{Calendar: buf.String()}, nil
+}
+
+func (s *Service) Import(ctx context.Context, req *ImportRequest) (*ImportResult, error) {
+	userID, err := getUserID(ctx)
+	if err != nil {
+		return nil, err
+	}
+	results, err := s.app.Import(ctx, strings.NewReader(req.Calendar), userID)
+	if err != nil {
+		return nil, appError(err)
+	}
+
+	entries := make([]*ImportEntry, 0, len(results))
+	for _, item := range results {
+		entry := &ImportEntry{Uid: item.UID, Id: int32(item.ID)}
+		if item.Err != nil {
+			entry.Error = item.Err.Error()
+		}
+		entries = append(entries, entry)
+	}
+	return &ImportResult{Entries: entries}, nil
+}
+
+// Watch передает изменения событий пользователя, пока клиент не отменит запрос.
+// Если клиент не успевает читать, поток завершается с codes.Unavailable,
+// и клиенту надо переподключиться с resume_token последнего изменения.
+func (s *Service) Watch(req *WatchRequest, stream Calendar_WatchServer) error {
+	ctx := stream.Context()
+	userID, err := getUserID(ctx)
+	if err != nil {
+		return err
+	}
+	var filter app.WatchFilter
+	if req.From != nil {
+		filter.From = req.From.AsTime()
+	}
+	if req.To != nil {
+		filter.To = req.To.AsTime()
+	}
+
+	changes, head, err := s.app.Watch(ctx, userID, filter, req.ResumeToken)
+	if err != nil {
+		return appError(err)
+	}
+	// заголовки ответа сообщают клиенту, что подписка оформлена, и передают токен,
+	// с которым ее можно возобновить, если изменений до обрыва не было
+	if err := stream.SendHeader(metadata.Pairs(ResumeTokenMetadata, head)); err != nil {
+		return err
+	}
+	for change := range changes {
+		err := stream.Send(&EventChange{
+			Type:        appChangeTypeToGRPC(change.Type),
+			Event:       storageEventToGRPCEvent(change.Event),
+			ResumeToken: change.Token,
+		})
+		if err != nil {
+			return err
+		}
+	}
+	if ctx.Err() != nil {
+		return status.FromContextError(ctx.Err()).Err()
+	}
+	return status.Error(codes.Unavailable, "the client is too slow, resume with the last token")
+}
+
+func appChangeTypeToGRPC(changeType app.ChangeType) EventChange_Type {
+	switch changeType {
+	case app.ChangeCreated:
+		return EventChange_CREATED
+	case app.ChangeUpdated:
+		return EventChange_UPDATED
+	case app.ChangeDeleted:
+		return EventChange_DELETED
+	default:
+		return EventChange_TYPE_UNSPECIFIED
+	}
+}
+
 func storageEventsToGRPCEvents(events []storage.Event) []*Event {
 	resultEvents := make([]*Event, 0, len(events))
 	for _, event := range events {
-		resultEvent := &Event{
-			Id:          int32(event.ID),
-			Title:       event.Title,
-			Start:       timestamppb.New(event.Start),
-			Stop:        timestamppb.New(event.Stop),
-			Description: event.Description,
-			UserId:      int32(event.UserID),
-		}
-		if event.Notification != nil {
-			notification := *event.Notification
-			resultEvent.Notification = durationpb.New(notification)
-		}
-		resultEvents = append(resultEvents, resultEvent)
+		resultEvents = append(resultEvents, storageEventToGRPCEvent(event))
 	}
 	return resultEvents
 }
+
+func storageEventToGRPCEvent(event storage.Event) *Event {
+	resultEvent := &Event{
+		Id:          int32(event.ID),
+		Title:       event.Title,
+		Start:       timestamppb.New(event.Start),
+		Stop:        timestamppb.New(event.Stop),
+		Description: event.Description,
+		UserId:      int32(event.UserID),
+		AllDay:      event.AllDay,
+		Version:     int32(event.Version),
+	}
+	if event.Notification != nil {
+		notification := *event.Notification
+		resultEvent.Notification = durationpb.New(notification)
+	}
+	if event.Recurrence != nil {
+		resultEvent.Rrule = event.Recurrence.RRule()
+		for _, date := range event.Recurrence.Exceptions {
+			resultEvent.Exdates = append(resultEvent.Exdates, timestamppb.New(date))
+		}
+	}
+	return resultEvent
+}
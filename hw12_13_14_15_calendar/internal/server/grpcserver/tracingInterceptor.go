@@ -0,0 +1,73 @@
+package grpcserver
+
+import (
+	"context"
+	"strings"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/semconv"
+	"go.opentelemetry.io/otel/trace"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/status"
+
+	"github.com/anfilat/otus-go/hw12_13_14_15_calendar/internal/tracing"
+)
+
+// grpcStatusCodeKey - атрибут спана с кодом ответа gRPC.
+const grpcStatusCodeKey = attribute.Key("rpc.grpc.status_code")
+
+// tracingInterceptor продолжает трассировку из метаданных traceparent или начинает новую.
+func tracingInterceptor(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+	ctx, span := startServerSpan(ctx, info.FullMethod)
+	defer span.End()
+
+	result, err := handler(ctx, req)
+	setSpanStatus(span, err)
+	return result, err
+}
+
+func streamTracingInterceptor(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+	ctx, span := startServerSpan(ss.Context(), info.FullMethod)
+	defer span.End()
+
+	err := handler(srv, &tracedStream{ss, ctx})
+	setSpanStatus(span, err)
+	return err
+}
+
+// tracedStream подменяет контекст потока контекстом со спаном.
+type tracedStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+func (s *tracedStream) Context() context.Context {
+	return s.ctx
+}
+
+func startServerSpan(ctx context.Context, fullMethod string) (context.Context, trace.Span) {
+	// fullMethod имеет вид /event.Calendar/Create
+	name := strings.TrimPrefix(fullMethod, "/")
+	service, rpcMethod := name, ""
+	if i := strings.LastIndex(name, "/"); i >= 0 {
+		service, rpcMethod = name[:i], name[i+1:]
+	}
+	ctx = tracing.ExtractGRPC(ctx)
+	return tracing.Start(ctx, name,
+		trace.WithSpanKind(trace.SpanKindServer),
+		trace.WithAttributes(
+			semconv.RPCSystemGRPC,
+			semconv.RPCServiceKey.String(service),
+			semconv.RPCMethodKey.String(rpcMethod),
+		),
+	)
+}
+
+func setSpanStatus(span trace.Span, err error) {
+	code := status.Code(err)
+	span.SetAttributes(grpcStatusCodeKey.Int(int(code)))
+	if err != nil {
+		span.SetStatus(codes.Error, err.Error())
+	}
+}
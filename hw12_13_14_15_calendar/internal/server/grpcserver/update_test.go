@@ -1,11 +1,12 @@
 package grpcserver
 
 import (
-	"context"
 	"testing"
 	"time"
 
 	"github.com/stretchr/testify/suite"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
 	"google.golang.org/protobuf/types/known/timestamppb"
 )
 
@@ -20,9 +21,28 @@ func (s *GRPCUpdateTest) TestUpdate() {
 	event.Id = id
 	event.Stop = timestamppb.New(event.Stop.AsTime().Add(time.Hour))
 
-	ctx := context.Background()
+	ctx := s.UserContext(1)
+	res, err := s.client.Update(ctx, event)
+	s.Require().NoError(err)
+	s.Require().Equal(int32(2), res.Version)
+
+	listRes, err := s.client.ListDay(ctx, &ListRequest{Date: event.Start})
+	s.Require().NoError(err)
+	s.Require().Equal(1, len(listRes.Events))
+	s.Require().Equal(int32(2), listRes.Events[0].Version)
+}
+
+func (s *GRPCUpdateTest) TestUpdateFailConflict() {
+	event := s.NewCommonEvent()
+	event.Id = s.AddEvent(event)
+
+	ctx := s.UserContext(1)
 	_, err := s.client.Update(ctx, event)
 	s.Require().NoError(err)
+
+	// повтор с той же версией
+	_, err = s.client.Update(ctx, event)
+	s.Require().Equal(codes.Aborted, status.Code(err))
 }
 
 func TestGRPCUpdateTest(t *testing.T) {
@@ -0,0 +1,55 @@
+package grpcserver
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/suite"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+type UserTest struct {
+	SuiteTest
+}
+
+func (s *UserTest) TestNoUser() {
+	event := s.NewCommonEvent()
+	_, err := s.client.ListDay(context.Background(), &ListRequest{Date: event.Start})
+	s.Require().Equal(codes.Unauthenticated, status.Code(err))
+}
+
+func (s *UserTest) TestList() {
+	event := s.NewCommonEvent()
+	id := s.AddEvent(event)
+
+	other := s.NewCommonEvent()
+	other.UserId = 2
+	s.AddEvent(other)
+
+	res, err := s.client.ListDay(s.UserContext(1), &ListRequest{Date: event.Start})
+	s.Require().NoError(err)
+	s.Require().Equal(1, len(res.Events))
+	s.Require().Equal(id, res.Events[0].Id)
+}
+
+func (s *UserTest) TestForbidden() {
+	event := s.NewCommonEvent()
+	event.Id = s.AddEvent(event)
+
+	event.Title = "stolen event"
+	_, err := s.client.Update(s.UserContext(2), event)
+	s.Require().Equal(codes.PermissionDenied, status.Code(err))
+
+	_, err = s.client.Delete(s.UserContext(2), &DeleteRequest{Id: event.Id})
+	s.Require().Equal(codes.PermissionDenied, status.Code(err))
+
+	res, err := s.client.ListDay(s.UserContext(1), &ListRequest{Date: event.Start})
+	s.Require().NoError(err)
+	s.Require().Equal(1, len(res.Events))
+	s.Require().Equal("some event", res.Events[0].Title)
+}
+
+func TestUserTest(t *testing.T) {
+	suite.Run(t, new(UserTest))
+}
@@ -0,0 +1,64 @@
+package grpcserver
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/suite"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+type GRPCWatchTest struct {
+	SuiteTest
+}
+
+func (s *GRPCWatchTest) TestWatch() {
+	ctx, cancel := context.WithCancel(s.UserContext(1))
+	defer cancel()
+
+	stream, err := s.client.Watch(ctx, &WatchRequest{})
+	s.Require().NoError(err)
+	// заголовки приходят, когда подписка оформлена
+	header, err := stream.Header()
+	s.Require().NoError(err)
+	s.Require().Len(header.Get(ResumeTokenMetadata), 1)
+
+	event := s.NewCommonEvent()
+	id := s.AddEvent(event)
+	change, err := stream.Recv()
+	s.Require().NoError(err)
+	s.Require().Equal(EventChange_CREATED, change.Type)
+	s.Require().Equal(id, change.Event.Id)
+	s.EqualEvents(event, change.Event)
+	token := change.ResumeToken
+	cancel()
+
+	// изменение, пропущенное между подписками
+	_, err = s.client.Delete(s.UserContext(1), &DeleteRequest{Id: change.Event.Id})
+	s.Require().NoError(err)
+
+	ctx, cancel = context.WithCancel(s.UserContext(1))
+	defer cancel()
+	stream, err = s.client.Watch(ctx, &WatchRequest{ResumeToken: token})
+	s.Require().NoError(err)
+	change, err = stream.Recv()
+	s.Require().NoError(err)
+	s.Require().Equal(EventChange_DELETED, change.Type)
+}
+
+func (s *GRPCWatchTest) TestWatchFail() {
+	stream, err := s.client.Watch(context.Background(), &WatchRequest{})
+	s.Require().NoError(err)
+	_, err = stream.Recv()
+	s.Require().Equal(codes.Unauthenticated, status.Code(err))
+
+	stream, err = s.client.Watch(s.UserContext(1), &WatchRequest{ResumeToken: "abc"})
+	s.Require().NoError(err)
+	_, err = stream.Recv()
+	s.Require().Equal(codes.InvalidArgument, status.Code(err))
+}
+
+func TestGRPCWatchTest(t *testing.T) {
+	suite.Run(t, new(GRPCWatchTest))
+}
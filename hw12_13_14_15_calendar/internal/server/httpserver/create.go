@@ -6,10 +6,16 @@ import (
 	"net/http"
 
 	"github.com/anfilat/otus-go/hw12_13_14_15_calendar/internal/app"
+	"github.com/anfilat/otus-go/hw12_13_14_15_calendar/internal/storage"
 )
 
 func handleCreate(app app.App) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
+		userID, ok := getUserID(w, r)
+		if !ok {
+			return
+		}
+
 		body, err := ioutil.ReadAll(r.Body)
 		defer r.Body.Close()
 		if err != nil {
@@ -24,12 +30,29 @@ func handleCreate(app app.App) http.HandlerFunc {
 			return
 		}
 
-		id, err := app.Create(r.Context(), req.UserID, req.Title, req.Description, req.Start, req.Stop, req.Notification)
+		event, err := httpEventToStorageEvent(req)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		id, err := app.Create(
+			r.Context(),
+			userID,
+			event.Title,
+			event.Description,
+			event.Start,
+			event.Stop,
+			event.AllDay,
+			event.Notification,
+			event.Recurrence,
+		)
 		if err != nil {
 			http.Error(w, err.Error(), http.StatusBadRequest)
 			return
 		}
 
-		writeJSON(w, CreateResult{id})
+		w.Header().Set("ETag", formatETag(storage.InitialVersion))
+		writeJSON(w, CreateResult{ID: id, Version: storage.InitialVersion})
 	}
 }
@@ -50,7 +50,7 @@ func (s *HttpCreateTest) TestCreate() {
 			s.Require().Equal(1, len(events))
 			s.EqualEvents(tt.event, events[0])
 
-			_ = s.app.DeleteAll(context.Background())
+			_ = s.app.DeleteAll(context.Background(), 0)
 		})
 	}
 }
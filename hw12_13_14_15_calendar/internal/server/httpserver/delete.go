@@ -2,14 +2,21 @@ package httpserver
 
 import (
 	"encoding/json"
+	"errors"
 	"io/ioutil"
 	"net/http"
 
 	"github.com/anfilat/otus-go/hw12_13_14_15_calendar/internal/app"
+	"github.com/anfilat/otus-go/hw12_13_14_15_calendar/internal/storage"
 )
 
 func handleDelete(app app.App) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
+		userID, ok := getUserID(w, r)
+		if !ok {
+			return
+		}
+
 		body, err := ioutil.ReadAll(r.Body)
 		defer r.Body.Close()
 		if err != nil {
@@ -24,9 +31,10 @@ func handleDelete(app app.App) http.HandlerFunc {
 			return
 		}
 
-		err = app.Delete(r.Context(), req.ID)
-		if err != nil {
-			http.Error(w, err.Error(), http.StatusBadRequest)
+		err = app.Delete(r.Context(), userID, req.ID)
+		// в этом API удаление несуществующего события не ошибка
+		if err != nil && !errors.Is(err, storage.ErrNotExistsEvent) {
+			http.Error(w, err.Error(), appErrorStatus(err))
 			return
 		}
 
@@ -0,0 +1,57 @@
+package httpserver
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+
+	"github.com/anfilat/otus-go/hw12_13_14_15_calendar/internal/app"
+)
+
+func handleFreeBusy(app app.App) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if _, ok := getUserID(w, r); !ok {
+			return
+		}
+
+		body, err := ioutil.ReadAll(r.Body)
+		defer r.Body.Close()
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		req := FreeBusyRequest{}
+		err = json.Unmarshal(body, &req)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		freeBusy, err := app.FreeBusy(r.Context(), req.UserIDs, req.From, req.To, req.Duration, req.Limit)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		result := FreeBusyResult{
+			Busy: make([]UserBusy, 0, len(freeBusy.Busy)),
+			Free: appIntervalsToHTTPIntervals(freeBusy.Free),
+		}
+		for _, userBusy := range freeBusy.Busy {
+			result.Busy = append(result.Busy, UserBusy{
+				UserID: userBusy.UserID,
+				Busy:   appIntervalsToHTTPIntervals(userBusy.Busy),
+			})
+		}
+		writeJSON(w, result)
+	}
+}
+
+func appIntervalsToHTTPIntervals(intervals []app.Interval) []Interval {
+	result := make([]Interval, 0, len(intervals))
+	for _, interval := range intervals {
+		result = append(result, Interval{Start: interval.Start, Stop: interval.Stop})
+	}
+	return result
+}
@@ -0,0 +1,62 @@
+package httpserver
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/suite"
+)
+
+type FreeBusyTest struct {
+	SuiteTest
+}
+
+func (s *FreeBusyTest) TestFreeBusy() {
+	event1 := s.NewCommonEvent()
+	s.AddEvent(event1)
+	event2 := s.NewCommonEvent()
+	event2.UserID = 2
+	event2.Start = event1.Stop.Add(time.Hour)
+	event2.Stop = event2.Start.Add(time.Hour)
+	s.AddEvent(event2)
+
+	data, _ := json.Marshal(FreeBusyRequest{
+		UserIDs:  []int{1, 2},
+		From:     event1.Start,
+		To:       event2.Stop.Add(time.Hour),
+		Duration: 30 * time.Minute,
+	})
+	res, err := s.Call("freebusy", data)
+	s.Require().NoError(err)
+	s.Require().Equal(http.StatusOK, res.StatusCode)
+
+	body, err := ioutil.ReadAll(res.Body)
+	res.Body.Close()
+	s.Require().NoError(err)
+	result := FreeBusyResult{}
+	s.Require().NoError(json.Unmarshal(body, &result))
+
+	s.Require().Equal(2, len(result.Busy))
+	s.Require().Equal(1, len(result.Busy[0].Busy))
+	s.Require().Equal(event1.Start.Unix(), result.Busy[0].Busy[0].Start.Unix())
+	s.Require().Equal(2, result.Busy[1].UserID)
+	s.Require().Equal(2, len(result.Free))
+	s.Require().Equal(event1.Stop.Unix(), result.Free[0].Start.Unix())
+	s.Require().Equal(event2.Start.Unix(), result.Free[0].Stop.Unix())
+}
+
+func (s *FreeBusyTest) TestFreeBusyFail() {
+	now := time.Now()
+	data, _ := json.Marshal(FreeBusyRequest{UserIDs: []int{1}, From: now, To: now.Add(time.Hour)})
+	res, err := s.Call("freebusy", data)
+	s.Require().NoError(err)
+	res.Body.Close()
+	s.Require().Equal(http.StatusBadRequest, res.StatusCode)
+}
+
+func TestFreeBusyTest(t *testing.T) {
+	suite.Run(t, new(FreeBusyTest))
+}
@@ -0,0 +1,28 @@
+package httpserver
+
+import (
+	"net/http"
+
+	"github.com/anfilat/otus-go/hw12_13_14_15_calendar/internal/health"
+)
+
+const (
+	statusOk          = "ok"
+	statusUnavailable = "unavailable"
+)
+
+// handleHealthz отвечает, пока процесс жив, не проверяя зависимости.
+func handleHealthz(w http.ResponseWriter, _ *http.Request) {
+	writeJSON(w, HealthResult{Status: statusOk})
+}
+
+// handleReadyz отвечает 503, если недоступно хранилище или начата остановка.
+func handleReadyz(health health.Health) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if err := health.Ready(r.Context()); err != nil {
+			writeJSONStatus(w, http.StatusServiceUnavailable, HealthResult{Status: statusUnavailable, Error: err.Error()})
+			return
+		}
+		writeJSON(w, HealthResult{Status: statusOk})
+	}
+}
@@ -0,0 +1,56 @@
+package httpserver
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/anfilat/otus-go/hw12_13_14_15_calendar/internal/health"
+	"github.com/anfilat/otus-go/hw12_13_14_15_calendar/internal/logger"
+)
+
+func TestHealthz(t *testing.T) {
+	h := health.New()
+	h.SetNotReady()
+	ts := newHealthServer(h)
+	defer ts.Close()
+
+	// живость не зависит от готовности
+	result := getHealth(t, ts.URL+"/healthz", http.StatusOK)
+	require.Equal(t, HealthResult{Status: statusOk}, result)
+}
+
+func TestReadyz(t *testing.T) {
+	h := health.New()
+	ts := newHealthServer(h)
+	defer ts.Close()
+
+	result := getHealth(t, ts.URL+"/readyz", http.StatusOK)
+	require.Equal(t, HealthResult{Status: statusOk}, result)
+
+	h.SetNotReady()
+	result = getHealth(t, ts.URL+"/readyz", http.StatusServiceUnavailable)
+	require.Equal(t, statusUnavailable, result.Status)
+	require.Equal(t, health.ErrShuttingDown.Error(), result.Error)
+}
+
+func newHealthServer(h health.Health) *httptest.Server {
+	var buf bytes.Buffer
+	logg, _ := logger.New(logger.Options{}, &buf)
+	return httptest.NewServer(newServer(nil, h, logg).handler)
+}
+
+func getHealth(t *testing.T, url string, code int) HealthResult {
+	res, err := http.Get(url)
+	require.NoError(t, err)
+	defer res.Body.Close()
+	require.Equal(t, code, res.StatusCode)
+
+	var result HealthResult
+	require.NoError(t, json.NewDecoder(res.Body).Decode(&result))
+	return result
+}
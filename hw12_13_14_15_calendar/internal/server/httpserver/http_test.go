@@ -9,11 +9,13 @@ import (
 	"net/http"
 	"net/http/httptest"
 	"os"
+	"strconv"
 	"time"
 
 	"github.com/stretchr/testify/suite"
 
 	"github.com/anfilat/otus-go/hw12_13_14_15_calendar/internal/app"
+	"github.com/anfilat/otus-go/hw12_13_14_15_calendar/internal/health"
 	"github.com/anfilat/otus-go/hw12_13_14_15_calendar/internal/logger"
 	"github.com/anfilat/otus-go/hw12_13_14_15_calendar/internal/storage"
 	"github.com/anfilat/otus-go/hw12_13_14_15_calendar/internal/storage/initstorage"
@@ -31,27 +33,44 @@ func (s *SuiteTest) SetupTest() {
 	ctx := context.Background()
 
 	var buf bytes.Buffer
-	s.logg, _ = logger.New("", &buf, "")
+	s.logg, _ = logger.New(logger.Options{}, &buf)
 
-	dbConnect := os.Getenv("PQ_TEST")
-	s.db, _ = initstorage.New(ctx, dbConnect == "", dbConnect)
+	dbDriver, dbConnect := initstorage.DriverPostgres, os.Getenv("PQ_TEST")
+	if sqliteConnect := os.Getenv("SQLITE_TEST"); sqliteConnect != "" {
+		dbDriver, dbConnect = initstorage.DriverSQLite, sqliteConnect
+	}
+	s.db, _ = initstorage.New(ctx, dbConnect == "", dbDriver, dbConnect)
 
 	s.app = app.New(s.logg, s.db)
 
-	s.ts = httptest.NewServer(newServer(s.app, s.logg).router)
+	s.ts = httptest.NewServer(newServer(s.app, health.New(), s.logg).handler)
 
-	_ = s.app.DeleteAll(ctx)
+	_ = s.app.DeleteAll(ctx, 0)
 }
 
 func (s *SuiteTest) TearDownTest() {
 	ctx := context.Background()
 	s.ts.Close()
-	_ = s.app.DeleteAll(ctx)
+	_ = s.app.DeleteAll(ctx, 0)
 	_ = s.db.Close(ctx)
 }
 
 func (s *SuiteTest) Call(endPoint string, data []byte) (resp *http.Response, err error) {
-	return http.Post(s.ts.URL+"/api/"+endPoint, "application/json", bytes.NewReader(data))
+	return s.CallAs(1, endPoint, data)
+}
+
+func (s *SuiteTest) CallAs(userID int, endPoint string, data []byte) (resp *http.Response, err error) {
+	return s.Do(userID, http.MethodPost, endPoint, "application/json", bytes.NewReader(data))
+}
+
+func (s *SuiteTest) Do(userID int, method, endPoint, contentType string, body io.Reader) (*http.Response, error) {
+	req, err := http.NewRequest(method, s.ts.URL+"/api/"+endPoint, body)
+	s.Require().NoError(err)
+	req.Header.Set("Content-Type", contentType)
+	if userID != 0 {
+		req.Header.Set(UserIDHeader, strconv.Itoa(userID))
+	}
+	return http.DefaultClient.Do(req)
 }
 
 func (s *SuiteTest) NewCommonEvent() Event {
@@ -67,6 +86,7 @@ func (s *SuiteTest) NewCommonEvent() Event {
 		Description:  "the event",
 		UserID:       1,
 		Notification: &notification,
+		Version:      storage.InitialVersion,
 	}
 }
 
@@ -82,7 +102,7 @@ func (s *SuiteTest) EqualEvents(event1, event2 Event) {
 func (s *SuiteTest) AddEvent(event Event) int {
 	data, _ := json.Marshal(event)
 
-	res, err := http.Post(s.ts.URL+"/api/create", "application/json", bytes.NewReader(data))
+	res, err := s.CallAs(event.UserID, "create", data)
 	s.Require().NoError(err)
 	s.Require().Equal(http.StatusOK, res.StatusCode)
 	return s.readCreateId(res.Body)
@@ -0,0 +1,82 @@
+package httpserver
+
+import (
+	"bytes"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/anfilat/otus-go/hw12_13_14_15_calendar/internal/app"
+	"github.com/anfilat/otus-go/hw12_13_14_15_calendar/internal/ical"
+)
+
+// handleExport отдает события пользователя в формате iCalendar.
+// Параметры from и to (RFC 3339) необязательны.
+func handleExport(app app.App) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		userID, ok := getUserID(w, r)
+		if !ok {
+			return
+		}
+
+		query := r.URL.Query()
+		from, err := queryTime(query, "from")
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		to, err := queryTime(query, "to")
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		var buf bytes.Buffer
+		err = app.Export(r.Context(), &buf, userID, from, to)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		w.Header().Add("Content-Type", ical.ContentType)
+		w.Header().Add("Content-Disposition", `attachment; filename="calendar.ics"`)
+		//nolint:errcheck
+		w.Write(buf.Bytes())
+	}
+}
+
+// handleImport создает события пользователя из тела запроса в формате iCalendar.
+func handleImport(app app.App) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		defer r.Body.Close()
+
+		userID, ok := getUserID(w, r)
+		if !ok {
+			return
+		}
+
+		results, err := app.Import(r.Context(), r.Body, userID)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		result := make(ImportResult, 0, len(results))
+		for _, item := range results {
+			entry := ImportEntry{UID: item.UID, ID: item.ID}
+			if item.Err != nil {
+				entry.Error = item.Err.Error()
+			}
+			result = append(result, entry)
+		}
+		writeJSON(w, result)
+	}
+}
+
+func queryTime(query url.Values, name string) (time.Time, error) {
+	value := query.Get(name)
+	if value == "" {
+		return time.Time{}, nil
+	}
+	return time.Parse(time.RFC3339, value)
+}
@@ -0,0 +1,76 @@
+package httpserver
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/suite"
+
+	"github.com/anfilat/otus-go/hw12_13_14_15_calendar/internal/ical"
+)
+
+type ICalTest struct {
+	SuiteTest
+}
+
+func (s *ICalTest) TestExportImport() {
+	event := s.NewCommonEvent()
+	s.AddEvent(event)
+
+	res, err := s.Do(1, http.MethodGet, "export", "", nil)
+	s.Require().NoError(err)
+	s.Require().Equal(http.StatusOK, res.StatusCode)
+	s.Require().Equal(ical.ContentType, res.Header.Get("Content-Type"))
+	calendar, err := ioutil.ReadAll(res.Body)
+	res.Body.Close()
+	s.Require().NoError(err)
+	s.Require().Contains(string(calendar), "SUMMARY:some event")
+
+	res, err = s.Do(2, http.MethodPost, "import", ical.ContentType, strings.NewReader(string(calendar)))
+	s.Require().NoError(err)
+	s.Require().Equal(http.StatusOK, res.StatusCode)
+	result := s.readImport(res)
+	s.Require().Equal(1, len(result))
+	s.Require().Equal("", result[0].Error)
+	s.Require().NotEqual(0, result[0].ID)
+
+	// у первого пользователя время занято
+	res, err = s.Do(1, http.MethodPost, "import", ical.ContentType, strings.NewReader(string(calendar)))
+	s.Require().NoError(err)
+	s.Require().Equal(http.StatusOK, res.StatusCode)
+	result = s.readImport(res)
+	s.Require().Equal(1, len(result))
+	s.Require().NotEqual("", result[0].Error)
+}
+
+func (s *ICalTest) TestExportFail() {
+	res, err := s.Do(1, http.MethodGet, "export?from=yesterday", "", nil)
+	s.Require().NoError(err)
+	res.Body.Close()
+	s.Require().Equal(http.StatusBadRequest, res.StatusCode)
+}
+
+func (s *ICalTest) TestImportFail() {
+	res, err := s.Do(1, http.MethodPost, "import", ical.ContentType, strings.NewReader("not a calendar"))
+	s.Require().NoError(err)
+	res.Body.Close()
+	s.Require().Equal(http.StatusBadRequest, res.StatusCode)
+}
+
+func (s *ICalTest) readImport(res *http.Response) ImportResult {
+	data, err := ioutil.ReadAll(res.Body)
+	defer res.Body.Close()
+	s.Require().NoError(err)
+
+	result := ImportResult{}
+	err = json.Unmarshal(data, &result)
+	s.Require().NoError(err)
+	return result
+}
+
+func TestICalTest(t *testing.T) {
+	suite.Run(t, new(ICalTest))
+}
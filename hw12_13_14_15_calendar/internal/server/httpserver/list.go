@@ -6,6 +6,7 @@ import (
 	"net/http"
 
 	"github.com/anfilat/otus-go/hw12_13_14_15_calendar/internal/app"
+	"github.com/anfilat/otus-go/hw12_13_14_15_calendar/internal/storage"
 )
 
 func handleListDay(app app.App) http.HandlerFunc {
@@ -27,6 +28,11 @@ func handleListMonth(app app.App) http.HandlerFunc {
 }
 
 func handleList(w http.ResponseWriter, r *http.Request, fn app.ListEvents) {
+	userID, ok := getUserID(w, r)
+	if !ok {
+		return
+	}
+
 	body, err := ioutil.ReadAll(r.Body)
 	defer r.Body.Close()
 	if err != nil {
@@ -41,15 +47,58 @@ func handleList(w http.ResponseWriter, r *http.Request, fn app.ListEvents) {
 		return
 	}
 
-	events, err := fn(r.Context(), req.Date)
+	events, err := fn(r.Context(), userID, req.Date, req.TimeZone)
 	if err != nil {
 		http.Error(w, err.Error(), http.StatusBadRequest)
 		return
 	}
 
+	writeJSON(w, storageEventsToHTTPEvents(events))
+}
+
+func handleListRange(app app.App) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		userID, ok := getUserID(w, r)
+		if !ok {
+			return
+		}
+
+		body, err := ioutil.ReadAll(r.Body)
+		defer r.Body.Close()
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		req := ListRangeRequest{}
+		err = json.Unmarshal(body, &req)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		page, err := app.ListRange(r.Context(), userID, req.From, req.To, storage.Filter{
+			Search: req.Search,
+			Desc:   req.Desc,
+			Cursor: req.Cursor,
+			Limit:  req.Limit,
+		})
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		writeJSON(w, ListRangeResult{
+			Events:     storageEventsToHTTPEvents(page.Events),
+			NextCursor: page.NextCursor,
+		})
+	}
+}
+
+func storageEventsToHTTPEvents(events []storage.Event) ListResult {
 	result := make(ListResult, 0, len(events))
 	for _, event := range events {
 		result = append(result, storageEventToHTTPEvent(event))
 	}
-	writeJSON(w, result)
+	return result
 }
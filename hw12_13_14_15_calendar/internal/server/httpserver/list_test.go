@@ -2,8 +2,10 @@ package httpserver
 
 import (
 	"encoding/json"
+	"io/ioutil"
 	"net/http"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/suite"
 )
@@ -54,6 +56,163 @@ func (s *HttpListTest) TestListMonth() {
 	s.EqualEvents(event, events[0])
 }
 
+func (s *HttpListTest) TestListTimeZone() {
+	// 23:30 UTC через год - в Москве уже следующий день
+	year, month, day := time.Now().UTC().AddDate(1, 0, 0).Date()
+	event := s.NewCommonEvent()
+	event.Start = time.Date(year, month, day, 23, 30, 0, 0, time.UTC)
+	event.Stop = event.Start.Add(20 * time.Minute)
+	s.AddEvent(event)
+	nextDay := time.Date(year, month, day+1, 12, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name     string
+		timeZone string
+		status   int
+		count    int
+	}{
+		{"zone of the date", "", http.StatusOK, 0},
+		{"moscow", "Europe/Moscow", http.StatusOK, 1},
+		{"unknown zone", "Europe/Atlantis", http.StatusBadRequest, 0},
+	}
+	for _, tt := range tests {
+		data, _ := json.Marshal(ListRequest{Date: nextDay, TimeZone: tt.timeZone})
+		res, err := s.Call("listday", data)
+		s.Require().NoError(err)
+		s.Require().Equal(tt.status, res.StatusCode, tt.name)
+		if tt.status != http.StatusOK {
+			res.Body.Close()
+			continue
+		}
+		events := s.readEvents(res.Body)
+		s.Require().Equal(tt.count, len(events), tt.name)
+	}
+}
+
+func (s *HttpListTest) TestListMultiDay() {
+	year, month, day := time.Now().UTC().AddDate(1, 0, 0).Date()
+	start := time.Date(year, month, day, 0, 0, 0, 0, time.UTC)
+	// конференция на три дня, время начала и окончания отбрасывается
+	conference := s.NewCommonEvent()
+	conference.Start = start.Add(9 * time.Hour)
+	conference.Stop = start.AddDate(0, 0, 2).Add(18 * time.Hour)
+	conference.AllDay = true
+	s.AddEvent(conference)
+	// ночной переезд на следующие сутки
+	trip := s.NewCommonEvent()
+	trip.Start = start.Add(22 * time.Hour)
+	trip.Stop = start.AddDate(0, 0, 1).Add(8 * time.Hour)
+	s.AddEvent(trip)
+
+	data, _ := json.Marshal(ListRequest{Date: start.AddDate(0, 0, 1).Add(12 * time.Hour)})
+	res, err := s.Call("listday", data)
+	s.Require().NoError(err)
+	s.Require().Equal(http.StatusOK, res.StatusCode)
+	events := s.readEvents(res.Body)
+	s.Require().Equal(2, len(events))
+	s.Require().True(events[0].AllDay)
+	s.Require().Equal(start, events[0].Start.UTC())
+	s.Require().Equal(start.AddDate(0, 0, 3), events[0].Stop.UTC())
+	s.Require().False(events[1].AllDay)
+	s.Require().Equal(trip.Start.Unix(), events[1].Start.Unix())
+
+	data, _ = json.Marshal(ListRequest{Date: start.AddDate(0, 0, 3)})
+	res, err = s.Call("listday", data)
+	s.Require().NoError(err)
+	s.Require().Equal(http.StatusOK, res.StatusCode)
+	s.Require().Equal(0, len(s.readEvents(res.Body)))
+}
+
+func (s *HttpListTest) TestListRecurring() {
+	event := s.NewCommonEvent()
+	event.RRule = "FREQ=WEEKLY;COUNT=2"
+	event.ExDates = []time.Time{event.Start.AddDate(0, 0, 7)}
+	s.AddEvent(event)
+
+	data, _ := json.Marshal(ListRequest{Date: event.Start.AddDate(0, 0, 7)})
+	res, err := s.Call("listweek", data)
+	s.Require().NoError(err)
+	s.Require().Equal(http.StatusOK, res.StatusCode)
+	events := s.readEvents(res.Body)
+	s.Require().Equal(0, len(events))
+
+	data, _ = json.Marshal(ListRequest{Date: event.Start})
+	res, err = s.Call("listweek", data)
+	s.Require().NoError(err)
+	s.Require().Equal(http.StatusOK, res.StatusCode)
+	events = s.readEvents(res.Body)
+	s.Require().Equal(1, len(events))
+	s.EqualEvents(event, events[0])
+	s.Require().Equal(event.RRule, events[0].RRule)
+	s.Require().Equal(1, len(events[0].ExDates))
+	s.Require().Equal(event.ExDates[0].Unix(), events[0].ExDates[0].Unix())
+}
+
+func (s *HttpListTest) TestListRange() {
+	event1 := s.NewCommonEvent()
+	s.AddEvent(event1)
+	event2 := s.NewCommonEvent()
+	event2.Title = "other event"
+	event2.Start = event1.Stop
+	event2.Stop = event2.Start.Add(time.Hour)
+	s.AddEvent(event2)
+
+	req := ListRangeRequest{From: event1.Start, To: event2.Stop, Limit: 1}
+	data, _ := json.Marshal(req)
+	res, err := s.Call("listrange", data)
+	s.Require().NoError(err)
+	s.Require().Equal(http.StatusOK, res.StatusCode)
+	page := s.readRange(res)
+	s.Require().Equal(1, len(page.Events))
+	s.EqualEvents(event1, page.Events[0])
+	s.Require().NotEqual("", page.NextCursor)
+
+	req.Cursor = page.NextCursor
+	data, _ = json.Marshal(req)
+	res, err = s.Call("listrange", data)
+	s.Require().NoError(err)
+	page = s.readRange(res)
+	s.Require().Equal(1, len(page.Events))
+	s.EqualEvents(event2, page.Events[0])
+	s.Require().Equal("", page.NextCursor)
+
+	data, _ = json.Marshal(ListRangeRequest{From: event1.Start, To: event2.Stop, Search: "OTHER"})
+	res, err = s.Call("listrange", data)
+	s.Require().NoError(err)
+	page = s.readRange(res)
+	s.Require().Equal(1, len(page.Events))
+	s.EqualEvents(event2, page.Events[0])
+}
+
+func (s *HttpListTest) TestListRangeFail() {
+	event := s.NewCommonEvent()
+	data, _ := json.Marshal(ListRangeRequest{From: event.Stop, To: event.Start})
+	res, err := s.Call("listrange", data)
+	s.Require().NoError(err)
+	res.Body.Close()
+	s.Require().Equal(http.StatusBadRequest, res.StatusCode)
+}
+
+func (s *HttpListTest) readRange(res *http.Response) ListRangeResult {
+	data, err := ioutil.ReadAll(res.Body)
+	defer res.Body.Close()
+	s.Require().NoError(err)
+
+	result := ListRangeResult{}
+	err = json.Unmarshal(data, &result)
+	s.Require().NoError(err)
+	return result
+}
+
+func (s *HttpListTest) TestCreateFailInvalidRule() {
+	event := s.NewCommonEvent()
+	event.RRule = "FREQ=HOURLY"
+	data, _ := json.Marshal(event)
+	res, err := s.Call("create", data)
+	s.Require().NoError(err)
+	s.Require().Equal(http.StatusBadRequest, res.StatusCode)
+}
+
 func TestHttpListTest(t *testing.T) {
 	suite.Run(t, new(HttpListTest))
 }
@@ -0,0 +1,52 @@
+package httpserver
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/anfilat/otus-go/hw12_13_14_15_calendar/internal/health"
+	"github.com/anfilat/otus-go/hw12_13_14_15_calendar/internal/logger"
+)
+
+func TestRequestLogger(t *testing.T) {
+	var buf bytes.Buffer
+	logg, _ := logger.New(logger.Options{Format: logger.FormatJSON}, &buf)
+	ts := httptest.NewServer(newServer(nil, health.New(), logg).handler)
+	defer ts.Close()
+
+	req, err := http.NewRequest(http.MethodGet, ts.URL+"/healthz", nil)
+	require.NoError(t, err)
+	req.Header.Set(RequestIDHeader, "abc")
+	req.Header.Set(UserIDHeader, "7")
+	res, err := http.DefaultClient.Do(req)
+	require.NoError(t, err)
+	res.Body.Close()
+	require.Equal(t, "abc", res.Header.Get(RequestIDHeader))
+
+	res, err = http.Get(ts.URL + "/healthz")
+	require.NoError(t, err)
+	res.Body.Close()
+	generated := res.Header.Get(RequestIDHeader)
+	require.NotEmpty(t, generated)
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	require.Len(t, lines, 2)
+
+	var record map[string]interface{}
+	require.NoError(t, json.Unmarshal([]byte(lines[0]), &record))
+	require.Equal(t, "abc", record[logger.RequestIDField])
+	require.Equal(t, float64(7), record[logger.UserIDField])
+	require.Equal(t, "/healthz", record["uri"])
+	require.Equal(t, float64(http.StatusOK), record["status"])
+
+	record = nil
+	require.NoError(t, json.Unmarshal([]byte(lines[1]), &record))
+	require.Equal(t, generated, record[logger.RequestIDField])
+	require.NotContains(t, record, logger.UserIDField)
+}
@@ -0,0 +1,49 @@
+package httpserver
+
+import (
+	"io/ioutil"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/suite"
+)
+
+type HttpMetricsTest struct {
+	SuiteTest
+}
+
+func (s *HttpMetricsTest) TestMetrics() {
+	event := s.NewCommonEvent()
+	s.AddEvent(event)
+
+	res, err := s.Do(1, http.MethodGet, "v1/events/123", "", nil)
+	s.Require().NoError(err)
+	res.Body.Close()
+	s.Require().Equal(http.StatusNotFound, res.StatusCode)
+
+	res, err = s.Do(1, http.MethodGet, "unknown/123", "", nil)
+	s.Require().NoError(err)
+	res.Body.Close()
+	s.Require().Equal(http.StatusNotFound, res.StatusCode)
+
+	res, err = http.Get(s.ts.URL + "/metrics")
+	s.Require().NoError(err)
+	defer res.Body.Close()
+	s.Require().Equal(http.StatusOK, res.StatusCode)
+	data, err := ioutil.ReadAll(res.Body)
+	s.Require().NoError(err)
+	body := string(data)
+
+	s.Require().Contains(body, `calendar_http_requests_total{code="200",method="POST",route="/api/create",server="http"}`)
+	s.Require().Contains(body, `calendar_http_request_duration_seconds_count{method="POST",route="/api/create",server="http"}`)
+	// по шаблону маршрута, без ID события
+	s.Require().Contains(body, `calendar_http_errors_total{method="GET",route="/api/v1/events/{id:[0-9]+}",server="http"}`)
+	// запросы без маршрута тоже учитываются, но без пути в метке
+	s.Require().Contains(body, `calendar_http_errors_total{method="GET",route="unmatched",server="http"}`)
+	s.Require().NotContains(body, `route="/api/unknown/123"`)
+	s.Require().Contains(body, `calendar_storage_operation_duration_seconds_count{backend=`)
+}
+
+func TestHttpMetricsTest(t *testing.T) {
+	suite.Run(t, new(HttpMetricsTest))
+}
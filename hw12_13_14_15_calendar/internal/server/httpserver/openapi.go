@@ -0,0 +1,14 @@
+package httpserver
+
+import (
+	"net/http"
+
+	"github.com/anfilat/otus-go/hw12_13_14_15_calendar/api"
+)
+
+// handleOpenAPI отдает спецификацию OpenAPI из api/openapi.json.
+func handleOpenAPI(w http.ResponseWriter, _ *http.Request) {
+	w.Header().Add("Content-Type", "application/json")
+	//nolint:errcheck
+	w.Write(api.OpenAPI)
+}
@@ -0,0 +1,219 @@
+package httpserver
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"reflect"
+	"regexp"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/require"
+	"github.com/stretchr/testify/suite"
+
+	"github.com/anfilat/otus-go/hw12_13_14_15_calendar/api"
+)
+
+// openAPIDoc - часть документа OpenAPI, которую сверяет тест.
+type openAPIDoc struct {
+	Paths      map[string]map[string]json.RawMessage
+	Components struct {
+		Schemas map[string]openAPISchema
+	}
+}
+
+type openAPISchema struct {
+	Type       string
+	Ref        string `json:"$ref"`
+	Items      *openAPISchema
+	Properties map[string]openAPISchema
+}
+
+// типы, описанные в components.schemas
+var openAPITypes = map[string]interface{}{
+	"Event":            Event{},
+	"DeleteRequest":    DeleteRequest{},
+	"ListRequest":      ListRequest{},
+	"ListRangeRequest": ListRangeRequest{},
+	"FreeBusyRequest":  FreeBusyRequest{},
+	"CreateResult":     CreateResult{},
+	"UpdateResult":     UpdateResult{},
+	"OkResult":         OkResult{},
+	"ListResult":       ListResult{},
+	"ListRangeResult":  ListRangeResult{},
+	"Interval":         Interval{},
+	"UserBusy":         UserBusy{},
+	"FreeBusyResult":   FreeBusyResult{},
+	"ErrorResult":      ErrorResult{},
+	"ErrorInfo":        ErrorInfo{},
+	"ImportEntry":      ImportEntry{},
+	"ImportResult":     ImportResult{},
+	"Settings":         Settings{},
+	"HealthResult":     HealthResult{},
+}
+
+func loadOpenAPI(t *testing.T) openAPIDoc {
+	doc := openAPIDoc{}
+	require.NoError(t, json.Unmarshal(api.OpenAPI, &doc))
+	return doc
+}
+
+func TestOpenAPIRoutes(t *testing.T) {
+	doc := loadOpenAPI(t)
+
+	var routes []string
+	err := newServer(nil, nil, nil).router.Walk(func(route *mux.Route, _ *mux.Router, _ []*mux.Route) error {
+		path, err := route.GetPathTemplate()
+		if err != nil {
+			return nil
+		}
+		methods, err := route.GetMethods()
+		if err != nil {
+			return nil
+		}
+		for _, method := range methods {
+			routes = append(routes, strings.ToLower(method)+" "+openAPIPath(path))
+		}
+		return nil
+	})
+	require.NoError(t, err)
+
+	var documented []string
+	for path, item := range doc.Paths {
+		for method := range item {
+			if method != "parameters" {
+				documented = append(documented, method+" "+path)
+			}
+		}
+	}
+
+	sort.Strings(routes)
+	sort.Strings(documented)
+	require.Equal(t, routes, documented, "routes in configureRouter and paths in api/openapi.json differ")
+}
+
+var pathVarRe = regexp.MustCompile(`\{(\w+):[^}]+\}`)
+
+// openAPIPath заменяет переменные пути gorilla/mux вида {id:[0-9]+} на {id}.
+func openAPIPath(path string) string {
+	return pathVarRe.ReplaceAllString(path, "{$1}")
+}
+
+func TestOpenAPISchemas(t *testing.T) {
+	doc := loadOpenAPI(t)
+
+	var names []string
+	for name := range doc.Components.Schemas {
+		names = append(names, name)
+	}
+	var types []string
+	for name := range openAPITypes {
+		types = append(types, name)
+	}
+	sort.Strings(names)
+	sort.Strings(types)
+	require.Equal(t, types, names, "schemas in api/openapi.json differ from openAPITypes")
+
+	for name, value := range openAPITypes {
+		checkOpenAPISchema(t, name, doc.Components.Schemas[name], reflect.TypeOf(value))
+	}
+}
+
+var (
+	timeType     = reflect.TypeOf(time.Time{})
+	durationType = reflect.TypeOf(time.Duration(0))
+)
+
+func checkOpenAPISchema(t *testing.T, name string, schema openAPISchema, typ reflect.Type) {
+	if typ.Kind() == reflect.Ptr {
+		typ = typ.Elem()
+	}
+
+	if schema.Ref != "" {
+		refName := strings.TrimPrefix(schema.Ref, "#/components/schemas/")
+		value, ok := openAPITypes[refName]
+		require.True(t, ok, "%s: unknown reference %s", name, schema.Ref)
+		require.Equal(t, reflect.TypeOf(value), typ, "%s: reference %s", name, schema.Ref)
+		return
+	}
+
+	switch {
+	case typ == timeType:
+		require.Equal(t, "string", schema.Type, name)
+	case typ == durationType:
+		require.Equal(t, "integer", schema.Type, name)
+	case typ.Kind() == reflect.String:
+		require.Equal(t, "string", schema.Type, name)
+	case typ.Kind() == reflect.Int:
+		require.Equal(t, "integer", schema.Type, name)
+	case typ.Kind() == reflect.Bool:
+		require.Equal(t, "boolean", schema.Type, name)
+	case typ.Kind() == reflect.Slice:
+		require.Equal(t, "array", schema.Type, name)
+		require.NotNil(t, schema.Items, name)
+		checkOpenAPISchema(t, name+"[]", *schema.Items, typ.Elem())
+	case typ.Kind() == reflect.Struct:
+		require.Equal(t, "object", schema.Type, name)
+		fields := jsonFields(typ)
+		var goNames, specNames []string
+		for field := range fields {
+			goNames = append(goNames, field)
+		}
+		for field := range schema.Properties {
+			specNames = append(specNames, field)
+		}
+		sort.Strings(goNames)
+		sort.Strings(specNames)
+		require.Equal(t, goNames, specNames, "%s: fields of %s differ", name, typ)
+		for field, fieldType := range fields {
+			checkOpenAPISchema(t, name+"."+field, schema.Properties[field], fieldType)
+		}
+	default:
+		t.Fatalf("%s: unsupported type %s", name, typ)
+	}
+}
+
+// jsonFields возвращает поля структуры под теми именами, под которыми их кодирует encoding/json.
+func jsonFields(typ reflect.Type) map[string]reflect.Type {
+	result := make(map[string]reflect.Type)
+	for i := 0; i < typ.NumField(); i++ {
+		field := typ.Field(i)
+		if field.PkgPath != "" {
+			continue
+		}
+		name := field.Name
+		tag := strings.Split(field.Tag.Get("json"), ",")[0]
+		if tag == "-" {
+			continue
+		}
+		if tag != "" {
+			name = tag
+		}
+		result[name] = field.Type
+	}
+	return result
+}
+
+type HttpOpenAPITest struct {
+	SuiteTest
+}
+
+func (s *HttpOpenAPITest) TestServe() {
+	res, err := http.Get(s.ts.URL + "/openapi.json")
+	s.Require().NoError(err)
+	defer res.Body.Close()
+	s.Require().Equal(http.StatusOK, res.StatusCode)
+	s.Require().Equal("application/json", res.Header.Get("Content-Type"))
+
+	data, err := ioutil.ReadAll(res.Body)
+	s.Require().NoError(err)
+	s.Require().Equal(api.OpenAPI, data)
+}
+
+func TestHttpOpenAPITest(t *testing.T) {
+	suite.Run(t, new(HttpOpenAPITest))
+}
@@ -5,16 +5,24 @@ import (
 	"time"
 
 	"github.com/anfilat/otus-go/hw12_13_14_15_calendar/internal/app"
+	"github.com/anfilat/otus-go/hw12_13_14_15_calendar/internal/health"
 	"github.com/anfilat/otus-go/hw12_13_14_15_calendar/internal/logger"
+	"github.com/anfilat/otus-go/hw12_13_14_15_calendar/internal/server/middleware"
 )
 
+// UserIDHeader - заголовок запроса с ID пользователя.
+const UserIDHeader = middleware.UserIDHeader
+
+// RequestIDHeader - заголовок с ID запроса. Если клиент его не передал, ID создается сервером.
+const RequestIDHeader = middleware.RequestIDHeader
+
 type Server interface {
 	Start(addr string) error
 	Stop(ctx context.Context) error
 }
 
-func NewServer(app app.App, logger logger.Logger) Server {
-	return newServer(app, logger)
+func NewServer(app app.App, health health.Health, logger logger.Logger) Server {
+	return newServer(app, health, logger)
 }
 
 type Event struct {
@@ -25,6 +33,13 @@ type Event struct {
 	Description  string
 	UserID       int
 	Notification *time.Duration `json:"notification,omitempty"`
+	RRule        string         `json:"rrule,omitempty"`
+	ExDates      []time.Time    `json:"exdates,omitempty"`
+	// событие на весь день: из Start и Stop берутся даты, Stop - день после последнего
+	// (неполный последний день включается); такое событие не занимает время
+	AllDay bool `json:"allDay,omitempty"`
+	// версия события, при изменении ее можно передать и в заголовке If-Match
+	Version int
 }
 
 type DeleteRequest struct {
@@ -33,10 +48,36 @@ type DeleteRequest struct {
 
 type ListRequest struct {
 	Date time.Time
+	// часовой пояс IANA границ дня, недели и месяца; если не указан -
+	// пояс из настроек пользователя, а если и он не задан - пояс Date
+	TimeZone string `json:"timeZone,omitempty"`
+}
+
+type ListRangeRequest struct {
+	From   time.Time
+	To     time.Time
+	Search string `json:"search,omitempty"`
+	Desc   bool   `json:"desc,omitempty"`
+	Cursor string `json:"cursor,omitempty"`
+	Limit  int    `json:"limit,omitempty"`
+}
+
+type FreeBusyRequest struct {
+	UserIDs  []int
+	From     time.Time
+	To       time.Time
+	Duration time.Duration
+	Limit    int `json:"limit,omitempty"`
 }
 
 type CreateResult struct {
-	ID int
+	ID      int
+	Version int
+}
+
+type UpdateResult struct {
+	Ok      bool
+	Version int
 }
 
 type OkResult struct {
@@ -44,3 +85,53 @@ type OkResult struct {
 }
 
 type ListResult []Event
+
+type ListRangeResult struct {
+	Events     ListResult
+	NextCursor string `json:"nextCursor,omitempty"`
+}
+
+type Interval struct {
+	Start time.Time
+	Stop  time.Time
+}
+
+type UserBusy struct {
+	UserID int
+	Busy   []Interval
+}
+
+type FreeBusyResult struct {
+	Busy []UserBusy
+	Free []Interval
+}
+
+// ErrorResult - тело ответа REST API с ошибкой.
+type ErrorResult struct {
+	Error ErrorInfo `json:"error"`
+}
+
+type ErrorInfo struct {
+	Code    string `json:"code"`
+	Message string `json:"message"`
+}
+
+type ImportEntry struct {
+	UID   string
+	ID    int
+	Error string `json:"error,omitempty"`
+}
+
+type ImportResult []ImportEntry
+
+// Settings - настройки пользователя.
+type Settings struct {
+	// часовой пояс IANA по умолчанию для списков за день, неделю и месяц, пустой - не задан
+	TimeZone string `json:"timeZone"`
+}
+
+// HealthResult - ответ проверок /healthz и /readyz.
+type HealthResult struct {
+	Status string
+	Error  string `json:"error,omitempty"`
+}
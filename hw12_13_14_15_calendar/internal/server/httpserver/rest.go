@@ -0,0 +1,326 @@
+package httpserver
+
+import (
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+
+	"github.com/gorilla/mux"
+
+	"github.com/anfilat/otus-go/hw12_13_14_15_calendar/internal/app"
+	"github.com/anfilat/otus-go/hw12_13_14_15_calendar/internal/storage"
+)
+
+// REST API: /api/v1/events и /api/v1/events/{id}.
+// Ошибки возвращаются в теле ErrorResult.
+
+func handleListEvents(app app.App) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		userID, ok := getRestUserID(w, r)
+		if !ok {
+			return
+		}
+
+		query := r.URL.Query()
+		from, err := requiredQueryTime(query, "from")
+		if err != nil {
+			writeError(w, http.StatusBadRequest, codeBadRequest, err.Error())
+			return
+		}
+		to, err := requiredQueryTime(query, "to")
+		if err != nil {
+			writeError(w, http.StatusBadRequest, codeBadRequest, err.Error())
+			return
+		}
+		filter := storage.Filter{
+			Search: query.Get("search"),
+			Cursor: query.Get("cursor"),
+		}
+		if value := query.Get("desc"); value != "" {
+			filter.Desc, err = strconv.ParseBool(value)
+			if err != nil {
+				writeError(w, http.StatusBadRequest, codeBadRequest, "invalid parameter desc")
+				return
+			}
+		}
+		if value := query.Get("limit"); value != "" {
+			filter.Limit, err = strconv.Atoi(value)
+			if err != nil {
+				writeError(w, http.StatusBadRequest, codeBadRequest, "invalid parameter limit")
+				return
+			}
+		}
+
+		page, err := app.ListRange(r.Context(), userID, from, to, filter)
+		if err != nil {
+			writeAppError(w, err)
+			return
+		}
+
+		writeJSON(w, ListRangeResult{
+			Events:     storageEventsToHTTPEvents(page.Events),
+			NextCursor: page.NextCursor,
+		})
+	}
+}
+
+func handleListAllEvents(app app.App) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		userID, ok := getRestUserID(w, r)
+		if !ok {
+			return
+		}
+
+		events, err := app.ListAll(r.Context(), userID)
+		if err != nil {
+			writeAppError(w, err)
+			return
+		}
+
+		writeJSON(w, storageEventsToHTTPEvents(events))
+	}
+}
+
+func handleDeleteAllEvents(app app.App) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		userID, ok := getRestUserID(w, r)
+		if !ok {
+			return
+		}
+
+		err := app.DeleteAll(r.Context(), userID)
+		if err != nil {
+			writeAppError(w, err)
+			return
+		}
+
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
+
+func handleCreateEvent(app app.App) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		userID, ok := getRestUserID(w, r)
+		if !ok {
+			return
+		}
+
+		req := Event{}
+		if !readRestJSON(w, r, &req) {
+			return
+		}
+		event, err := httpEventToStorageEvent(req)
+		if err != nil {
+			writeAppError(w, err)
+			return
+		}
+
+		id, err := app.Create(
+			r.Context(),
+			userID,
+			event.Title,
+			event.Description,
+			event.Start,
+			event.Stop,
+			event.AllDay,
+			event.Notification,
+			event.Recurrence,
+		)
+		if err != nil {
+			writeAppError(w, err)
+			return
+		}
+		event, err = app.Get(r.Context(), userID, id)
+		if err != nil {
+			writeAppError(w, err)
+			return
+		}
+
+		w.Header().Set("Location", r.URL.Path+"/"+strconv.Itoa(id))
+		w.Header().Set("ETag", formatETag(event.Version))
+		writeJSONStatus(w, http.StatusCreated, storageEventToHTTPEvent(event))
+	}
+}
+
+func handleGetEvent(app app.App) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		userID, id, ok := getRestEventID(w, r)
+		if !ok {
+			return
+		}
+
+		event, err := app.Get(r.Context(), userID, id)
+		if err != nil {
+			writeAppError(w, err)
+			return
+		}
+
+		w.Header().Set("ETag", formatETag(event.Version))
+		writeJSON(w, storageEventToHTTPEvent(event))
+	}
+}
+
+// handlePutEvent заменяет событие целиком.
+func handlePutEvent(app app.App) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		userID, id, ok := getRestEventID(w, r)
+		if !ok {
+			return
+		}
+
+		req := Event{}
+		if !readRestJSON(w, r, &req) {
+			return
+		}
+		updateEvent(w, r, app, userID, id, req)
+	}
+}
+
+// handlePatchEvent изменяет только переданные поля события. Версия из If-Match или тела
+// обязательна: поля накладываются на ту версию события, которую видел клиент.
+func handlePatchEvent(calendar app.App) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		userID, id, ok := getRestEventID(w, r)
+		if !ok {
+			return
+		}
+
+		body, err := ioutil.ReadAll(r.Body)
+		defer r.Body.Close()
+		if err != nil {
+			writeError(w, http.StatusInternalServerError, codeInternal, err.Error())
+			return
+		}
+		var patch struct {
+			Version int
+		}
+		if err := json.Unmarshal(body, &patch); err != nil {
+			writeError(w, http.StatusBadRequest, codeBadRequest, err.Error())
+			return
+		}
+		version, err := getVersion(r, Event{Version: patch.Version})
+		if err != nil {
+			writeError(w, http.StatusBadRequest, codeBadRequest, "invalid If-Match header")
+			return
+		}
+		if version == 0 {
+			writeAppError(w, app.ErrNoVersion)
+			return
+		}
+
+		event, err := calendar.Get(r.Context(), userID, id)
+		if err != nil {
+			writeAppError(w, err)
+			return
+		}
+		if event.Version != version {
+			writeAppError(w, storage.ErrConflict)
+			return
+		}
+		req := storageEventToHTTPEvent(event)
+		if err := json.Unmarshal(body, &req); err != nil {
+			writeError(w, http.StatusBadRequest, codeBadRequest, err.Error())
+			return
+		}
+		req.Version = version
+		updateEvent(w, r, calendar, userID, id, req)
+	}
+}
+
+func handleDeleteEvent(app app.App) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		userID, id, ok := getRestEventID(w, r)
+		if !ok {
+			return
+		}
+
+		err := app.Delete(r.Context(), userID, id)
+		if err != nil {
+			writeAppError(w, err)
+			return
+		}
+
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
+
+func updateEvent(w http.ResponseWriter, r *http.Request, app app.App, userID, id int, req Event) {
+	change, err := httpEventToStorageEvent(req)
+	if err != nil {
+		writeAppError(w, err)
+		return
+	}
+	change.Version, err = getVersion(r, req)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, codeBadRequest, "invalid If-Match header")
+		return
+	}
+	change.ID = id
+	change.UserID = userID
+	_, err = app.Update(r.Context(), userID, id, change)
+	if err != nil {
+		writeAppError(w, err)
+		return
+	}
+	event, err := app.Get(r.Context(), userID, id)
+	if err != nil {
+		writeAppError(w, err)
+		return
+	}
+
+	w.Header().Set("ETag", formatETag(event.Version))
+	writeJSON(w, storageEventToHTTPEvent(event))
+}
+
+func getRestUserID(w http.ResponseWriter, r *http.Request) (int, bool) {
+	userID, err := parseUserID(r)
+	if err != nil {
+		writeError(w, http.StatusUnauthorized, codeUnauthorized, err.Error())
+		return 0, false
+	}
+	return userID, true
+}
+
+func getRestEventID(w http.ResponseWriter, r *http.Request) (userID int, id int, ok bool) {
+	userID, ok = getRestUserID(w, r)
+	if !ok {
+		return 0, 0, false
+	}
+	id, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		writeError(w, http.StatusBadRequest, codeBadRequest, "invalid event id")
+		return 0, 0, false
+	}
+	return userID, id, true
+}
+
+func readRestJSON(w http.ResponseWriter, r *http.Request, v interface{}) bool {
+	body, err := ioutil.ReadAll(r.Body)
+	defer r.Body.Close()
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, codeInternal, err.Error())
+		return false
+	}
+	err = json.Unmarshal(body, v)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, codeBadRequest, err.Error())
+		return false
+	}
+	return true
+}
+
+// requiredQueryTime - обязательный параметр запроса в формате RFC 3339.
+func requiredQueryTime(query url.Values, name string) (time.Time, error) {
+	if query.Get(name) == "" {
+		return time.Time{}, errors.New("no parameter " + name)
+	}
+	result, err := queryTime(query, name)
+	if err != nil {
+		return time.Time{}, errors.New("invalid parameter " + name + ": " + err.Error())
+	}
+	return result, nil
+}
@@ -0,0 +1,228 @@
+package httpserver
+
+import (
+	"bytes"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/suite"
+)
+
+type HttpRestTest struct {
+	SuiteTest
+}
+
+func (s *HttpRestTest) Rest(userID int, method, path string, v interface{}) *http.Response {
+	var data []byte
+	if v != nil {
+		data, _ = json.Marshal(v)
+	}
+	res, err := s.Do(userID, method, "v1/"+path, "application/json", bytes.NewReader(data))
+	s.Require().NoError(err)
+	return res
+}
+
+func (s *HttpRestTest) readEvent(res *http.Response) Event {
+	data, err := ioutil.ReadAll(res.Body)
+	defer res.Body.Close()
+	s.Require().NoError(err)
+
+	result := Event{}
+	s.Require().NoError(json.Unmarshal(data, &result))
+	return result
+}
+
+func (s *HttpRestTest) requireError(res *http.Response, status int, code string) {
+	data, err := ioutil.ReadAll(res.Body)
+	defer res.Body.Close()
+	s.Require().NoError(err)
+
+	s.Require().Equal(status, res.StatusCode)
+	s.Require().Equal("application/json", res.Header.Get("Content-Type"))
+	result := ErrorResult{}
+	s.Require().NoError(json.Unmarshal(data, &result))
+	s.Require().Equal(code, result.Error.Code)
+	s.Require().NotEqual("", result.Error.Message)
+}
+
+func (s *HttpRestTest) TestCreateGet() {
+	event := s.NewCommonEvent()
+
+	res := s.Rest(1, http.MethodPost, "events", event)
+	s.Require().Equal(http.StatusCreated, res.StatusCode)
+	s.Require().Equal(`"1"`, res.Header.Get("ETag"))
+	created := s.readEvent(res)
+	s.EqualEvents(event, created)
+	s.Require().Equal("/api/v1/events/"+strconv.Itoa(created.ID), res.Header.Get("Location"))
+
+	res = s.Rest(1, http.MethodGet, "events/"+strconv.Itoa(created.ID), nil)
+	s.Require().Equal(http.StatusOK, res.StatusCode)
+	s.Require().Equal(`"1"`, res.Header.Get("ETag"))
+	s.EqualEvents(event, s.readEvent(res))
+}
+
+func (s *HttpRestTest) TestList() {
+	event := s.NewCommonEvent()
+	s.AddEvent(event)
+
+	query := url.Values{}
+	query.Set("from", event.Start.Add(-time.Hour).Format(time.RFC3339))
+	query.Set("to", event.Stop.Add(time.Hour).Format(time.RFC3339))
+	query.Set("limit", "10")
+	res := s.Rest(1, http.MethodGet, "events?"+query.Encode(), nil)
+	s.Require().Equal(http.StatusOK, res.StatusCode)
+
+	data, err := ioutil.ReadAll(res.Body)
+	res.Body.Close()
+	s.Require().NoError(err)
+	result := ListRangeResult{}
+	s.Require().NoError(json.Unmarshal(data, &result))
+	s.Require().Equal(1, len(result.Events))
+	s.EqualEvents(event, result.Events[0])
+
+	// без обязательного параметра
+	query.Del("to")
+	res = s.Rest(1, http.MethodGet, "events?"+query.Encode(), nil)
+	s.requireError(res, http.StatusBadRequest, codeBadRequest)
+
+	// конец диапазона раньше начала
+	query.Set("to", event.Start.Add(-2*time.Hour).Format(time.RFC3339))
+	res = s.Rest(1, http.MethodGet, "events?"+query.Encode(), nil)
+	s.requireError(res, http.StatusUnprocessableEntity, codeValidation)
+}
+
+func (s *HttpRestTest) TestPut() {
+	event := s.NewCommonEvent()
+	event.ID = s.AddEvent(event)
+	path := "events/" + strconv.Itoa(event.ID)
+
+	event.Title = "new title"
+	res := s.Rest(1, http.MethodPut, path, event)
+	s.Require().Equal(http.StatusOK, res.StatusCode)
+	s.Require().Equal(`"2"`, res.Header.Get("ETag"))
+	updated := s.readEvent(res)
+	s.EqualEvents(event, updated)
+	s.Require().Equal(2, updated.Version)
+
+	// повтор со старой версией
+	res = s.Rest(1, http.MethodPut, path, event)
+	s.requireError(res, http.StatusConflict, codeConflict)
+}
+
+func (s *HttpRestTest) TestPatch() {
+	event := s.NewCommonEvent()
+	event.ID = s.AddEvent(event)
+	path := "events/" + strconv.Itoa(event.ID)
+
+	// без версии
+	res := s.Rest(1, http.MethodPatch, path, map[string]interface{}{"Title": "new title"})
+	s.requireError(res, http.StatusUnprocessableEntity, codeValidation)
+
+	res = s.Rest(1, http.MethodPatch, path, map[string]interface{}{"Title": "new title", "Version": 1})
+	s.Require().Equal(http.StatusOK, res.StatusCode)
+	event.Title = "new title"
+	s.EqualEvents(event, s.readEvent(res))
+
+	// поля не накладываются на версию, которую клиент не видел
+	res = s.Rest(1, http.MethodPatch, path, map[string]interface{}{"Description": "new", "Version": 1})
+	s.requireError(res, http.StatusConflict, codeConflict)
+}
+
+func (s *HttpRestTest) TestDelete() {
+	event := s.NewCommonEvent()
+	id := s.AddEvent(event)
+	path := "events/" + strconv.Itoa(id)
+
+	res := s.Rest(2, http.MethodDelete, path, nil)
+	s.requireError(res, http.StatusForbidden, codeForbidden)
+
+	res = s.Rest(1, http.MethodDelete, path, nil)
+	res.Body.Close()
+	s.Require().Equal(http.StatusNoContent, res.StatusCode)
+
+	res = s.Rest(1, http.MethodDelete, path, nil)
+	s.requireError(res, http.StatusNotFound, codeNotFound)
+
+	res = s.Rest(1, http.MethodGet, path, nil)
+	s.requireError(res, http.StatusNotFound, codeNotFound)
+}
+
+func (s *HttpRestTest) TestDeleteAll() {
+	event := s.NewCommonEvent()
+	s.AddEvent(event)
+	event.UserID = 2
+	s.AddEvent(event)
+
+	res := s.Rest(1, http.MethodDelete, "events", nil)
+	res.Body.Close()
+	s.Require().Equal(http.StatusNoContent, res.StatusCode)
+
+	res = s.Rest(1, http.MethodGet, "events/all", nil)
+	s.Require().Equal(http.StatusOK, res.StatusCode)
+	s.Require().Equal(0, len(s.readEvents(res.Body)))
+
+	// события других пользователей остаются
+	res = s.Rest(2, http.MethodGet, "events/all", nil)
+	s.Require().Equal(http.StatusOK, res.StatusCode)
+	events := s.readEvents(res.Body)
+	s.Require().Equal(1, len(events))
+	s.EqualEvents(event, events[0])
+}
+
+func (s *HttpRestTest) TestErrors() {
+	event := s.NewCommonEvent()
+	s.AddEvent(event)
+
+	res := s.Rest(1, http.MethodPost, "events", event)
+	s.requireError(res, http.StatusConflict, codeDateBusy)
+
+	event.Title = ""
+	res = s.Rest(1, http.MethodPost, "events", event)
+	s.requireError(res, http.StatusUnprocessableEntity, codeValidation)
+
+	res = s.Rest(0, http.MethodPost, "events", event)
+	s.requireError(res, http.StatusUnauthorized, codeUnauthorized)
+
+	res, err := s.Do(1, http.MethodPost, "v1/events", "application/json", bytes.NewReader([]byte("{")))
+	s.Require().NoError(err)
+	s.requireError(res, http.StatusBadRequest, codeBadRequest)
+}
+
+func (s *HttpRestTest) TestSettings() {
+	res := s.Rest(1, http.MethodGet, "settings", nil)
+	s.Require().Equal(http.StatusOK, res.StatusCode)
+	s.Require().Equal(Settings{}, s.readSettings(res))
+
+	res = s.Rest(1, http.MethodPut, "settings", Settings{TimeZone: "Europe/Moscow"})
+	s.Require().Equal(http.StatusOK, res.StatusCode)
+	s.Require().Equal(Settings{TimeZone: "Europe/Moscow"}, s.readSettings(res))
+
+	res = s.Rest(1, http.MethodGet, "settings", nil)
+	s.Require().Equal(Settings{TimeZone: "Europe/Moscow"}, s.readSettings(res))
+	res = s.Rest(2, http.MethodGet, "settings", nil)
+	s.Require().Equal(Settings{}, s.readSettings(res))
+
+	res = s.Rest(1, http.MethodPut, "settings", Settings{TimeZone: "Europe/Atlantis"})
+	s.requireError(res, http.StatusUnprocessableEntity, codeValidation)
+	res = s.Rest(0, http.MethodGet, "settings", nil)
+	s.requireError(res, http.StatusUnauthorized, codeUnauthorized)
+}
+
+func (s *HttpRestTest) readSettings(res *http.Response) Settings {
+	data, err := ioutil.ReadAll(res.Body)
+	defer res.Body.Close()
+	s.Require().NoError(err)
+
+	result := Settings{}
+	s.Require().NoError(json.Unmarshal(data, &result))
+	return result
+}
+
+func TestHttpRestTest(t *testing.T) {
+	suite.Run(t, new(HttpRestTest))
+}
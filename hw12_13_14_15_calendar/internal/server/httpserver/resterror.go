@@ -0,0 +1,46 @@
+package httpserver
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/anfilat/otus-go/hw12_13_14_15_calendar/internal/app"
+	"github.com/anfilat/otus-go/hw12_13_14_15_calendar/internal/storage"
+)
+
+// коды ошибок REST API
+const (
+	codeBadRequest   = "bad_request"
+	codeUnauthorized = "unauthorized"
+	codeForbidden    = "forbidden"
+	codeNotFound     = "not_found"
+	codeDateBusy     = "date_busy"
+	codeConflict     = "version_conflict"
+	codeTokenExpired = "token_expired"
+	codeValidation   = "validation_failed"
+	codeInternal     = "internal"
+)
+
+func writeError(w http.ResponseWriter, status int, code string, message string) {
+	writeJSONStatus(w, status, ErrorResult{Error: ErrorInfo{Code: code, Message: message}})
+}
+
+// writeAppError отвечает ошибкой приложения с соответствующим ей статусом.
+func writeAppError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, storage.ErrNotExistsEvent):
+		writeError(w, http.StatusNotFound, codeNotFound, err.Error())
+	case errors.Is(err, app.ErrDateBusy):
+		writeError(w, http.StatusConflict, codeDateBusy, err.Error())
+	case errors.Is(err, storage.ErrConflict):
+		writeError(w, http.StatusConflict, codeConflict, err.Error())
+	case errors.Is(err, app.ErrForbidden):
+		writeError(w, http.StatusForbidden, codeForbidden, err.Error())
+	case errors.Is(err, app.ErrTokenExpired):
+		writeError(w, http.StatusGone, codeTokenExpired, err.Error())
+	case app.IsValidationError(err):
+		writeError(w, http.StatusUnprocessableEntity, codeValidation, err.Error())
+	default:
+		writeError(w, http.StatusInternalServerError, codeInternal, err.Error())
+	}
+}
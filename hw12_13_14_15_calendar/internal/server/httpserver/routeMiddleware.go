@@ -0,0 +1,21 @@
+package httpserver
+
+import (
+	"net/http"
+
+	"github.com/gorilla/mux"
+
+	"github.com/anfilat/otus-go/hw12_13_14_15_calendar/internal/server/middleware"
+)
+
+// routeMiddleware передает общим middleware шаблон маршрута запроса, а не путь,
+// чтобы ID событий не попадали в метки метрик и имена спанов.
+// Маршрутизатор вызывает ее только для найденных маршрутов.
+func routeMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if template, err := mux.CurrentRoute(r).GetPathTemplate(); err == nil {
+			middleware.SetRoute(r.Context(), template)
+		}
+		next.ServeHTTP(w, r)
+	})
+}
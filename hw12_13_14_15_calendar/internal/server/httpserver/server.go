@@ -6,37 +6,57 @@ import (
 	"errors"
 	"fmt"
 	"net/http"
+	"strconv"
+	"strings"
 	"time"
 
 	"github.com/gorilla/mux"
 
 	"github.com/anfilat/otus-go/hw12_13_14_15_calendar/internal/app"
+	"github.com/anfilat/otus-go/hw12_13_14_15_calendar/internal/health"
 	"github.com/anfilat/otus-go/hw12_13_14_15_calendar/internal/logger"
+	"github.com/anfilat/otus-go/hw12_13_14_15_calendar/internal/metrics"
+	"github.com/anfilat/otus-go/hw12_13_14_15_calendar/internal/server/middleware"
 	"github.com/anfilat/otus-go/hw12_13_14_15_calendar/internal/storage"
 )
 
+const writeTimeout = time.Second * 15
+
 type server struct {
 	app    app.App
+	health health.Health
 	logger logger.Logger
 	srv    *http.Server
 	router *mux.Router
+	// router, обернутый общими middleware
+	handler http.Handler
+	// закрывается при остановке сервера, чтобы завершить потоки изменений
+	done chan struct{}
 }
 
-func newServer(app app.App, logger logger.Logger) *server {
+func newServer(app app.App, health health.Health, logger logger.Logger) *server {
 	s := &server{
 		app:    app,
+		health: health,
 		logger: logger,
 		router: mux.NewRouter(),
+		done:   make(chan struct{}),
 	}
 	s.configureRouter()
+	s.handler = middleware.Handler(s.router, middleware.Options{
+		Server:  "http",
+		Service: "calendar",
+		Message: "http request",
+		Logger:  logger,
+	})
 	return s
 }
 
 func (s *server) Start(addr string) error {
 	s.srv = &http.Server{
 		Addr:         addr,
-		Handler:      s.router,
-		WriteTimeout: time.Second * 15,
+		Handler:      s.handler,
+		WriteTimeout: writeTimeout,
 		ReadTimeout:  time.Second * 15,
 		IdleTimeout:  time.Second * 60,
 	}
@@ -49,6 +69,8 @@ func (s *server) Start(addr string) error {
 }
 
 func (s *server) Stop(ctx context.Context) error {
+	// Shutdown ждет завершения обработчиков, а потоки изменений сами не завершаются
+	close(s.done)
 	err := s.srv.Shutdown(ctx)
 	if err != nil {
 		return fmt.Errorf("server shutdown: %w", err)
@@ -58,9 +80,13 @@ func (s *server) Stop(ctx context.Context) error {
 
 func (s *server) configureRouter() {
 	router := s.router
-	router.Use(loggingMiddleware(s.logger))
+	router.Use(routeMiddleware)
 
 	router.HandleFunc("/hello", handleHello).Methods(http.MethodGet)
+	router.HandleFunc("/healthz", handleHealthz).Methods(http.MethodGet)
+	router.HandleFunc("/readyz", handleReadyz(s.health)).Methods(http.MethodGet)
+	router.HandleFunc("/openapi.json", handleOpenAPI).Methods(http.MethodGet)
+	router.Handle("/metrics", metrics.Handler()).Methods(http.MethodGet)
 
 	apiRouter := router.PathPrefix("/api").Subrouter()
 	apiRouter.HandleFunc("/create", handleCreate(s.app)).Methods(http.MethodPost)
@@ -69,16 +95,90 @@ func (s *server) configureRouter() {
 	apiRouter.HandleFunc("/listday", handleListDay(s.app)).Methods(http.MethodPost)
 	apiRouter.HandleFunc("/listweek", handleListWeek(s.app)).Methods(http.MethodPost)
 	apiRouter.HandleFunc("/listmonth", handleListMonth(s.app)).Methods(http.MethodPost)
+	apiRouter.HandleFunc("/listrange", handleListRange(s.app)).Methods(http.MethodPost)
+	apiRouter.HandleFunc("/freebusy", handleFreeBusy(s.app)).Methods(http.MethodPost)
+	apiRouter.HandleFunc("/export", handleExport(s.app)).Methods(http.MethodGet)
+	apiRouter.HandleFunc("/import", handleImport(s.app)).Methods(http.MethodPost)
+
+	v1Router := apiRouter.PathPrefix("/v1").Subrouter()
+	v1Router.HandleFunc("/events", handleListEvents(s.app)).Methods(http.MethodGet)
+	v1Router.HandleFunc("/events", handleCreateEvent(s.app)).Methods(http.MethodPost)
+	v1Router.HandleFunc("/events", handleDeleteAllEvents(s.app)).Methods(http.MethodDelete)
+	v1Router.HandleFunc("/events/all", handleListAllEvents(s.app)).Methods(http.MethodGet)
+	v1Router.HandleFunc("/events/watch", handleWatchEvents(s.app, s.done)).Methods(http.MethodGet)
+	v1Router.HandleFunc("/events/{id:[0-9]+}", handleGetEvent(s.app)).Methods(http.MethodGet)
+	v1Router.HandleFunc("/events/{id:[0-9]+}", handlePutEvent(s.app)).Methods(http.MethodPut)
+	v1Router.HandleFunc("/events/{id:[0-9]+}", handlePatchEvent(s.app)).Methods(http.MethodPatch)
+	v1Router.HandleFunc("/events/{id:[0-9]+}", handleDeleteEvent(s.app)).Methods(http.MethodDelete)
+	v1Router.HandleFunc("/settings", handleGetSettings(s.app)).Methods(http.MethodGet)
+	v1Router.HandleFunc("/settings", handlePutSettings(s.app)).Methods(http.MethodPut)
+}
+
+// getUserID возвращает пользователя из заголовка запроса.
+// При ошибке отвечает клиенту и возвращает false.
+func getUserID(w http.ResponseWriter, r *http.Request) (int, bool) {
+	userID, err := parseUserID(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusUnauthorized)
+		return 0, false
+	}
+	return userID, true
+}
+
+func parseUserID(r *http.Request) (int, error) {
+	value := r.Header.Get(UserIDHeader)
+	if value == "" {
+		return 0, errors.New("no user id in header " + UserIDHeader)
+	}
+	userID, err := strconv.Atoi(value)
+	if err != nil || userID <= 0 {
+		return 0, errors.New("invalid user id " + strconv.Quote(value))
+	}
+	return userID, nil
+}
+
+func appErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, app.ErrForbidden):
+		return http.StatusForbidden
+	case errors.Is(err, storage.ErrConflict):
+		return http.StatusConflict
+	default:
+		return http.StatusBadRequest
+	}
+}
+
+// getVersion возвращает версию из заголовка If-Match ("3" или 3), а без него - из тела запроса.
+func getVersion(r *http.Request, event Event) (int, error) {
+	value := r.Header.Get("If-Match")
+	if value == "" {
+		return event.Version, nil
+	}
+	value = strings.TrimPrefix(value, "W/")
+	return strconv.Atoi(strings.Trim(value, `"`))
+}
+
+func formatETag(version int) string {
+	return `"` + strconv.Itoa(version) + `"`
 }
 
 func writeJSON(w http.ResponseWriter, v interface{}) {
+	writeJSONStatus(w, http.StatusOK, v)
+}
+
+func writeJSONStatus(w http.ResponseWriter, status int, v interface{}) {
 	w.Header().Add("Content-Type", "application/json")
+	w.WriteHeader(status)
 	data, _ := json.Marshal(v)
 	//nolint:errcheck
 	w.Write(data)
 }
 
-func httpEventToStorageEvent(event Event) storage.Event {
+func httpEventToStorageEvent(event Event) (storage.Event, error) {
+	recurrence, err := storage.NewRecurrence(event.RRule, event.ExDates)
+	if err != nil {
+		return storage.Event{}, err
+	}
 	return storage.Event{
 		ID:           event.ID,
 		Title:        event.Title,
@@ -87,11 +187,14 @@ func httpEventToStorageEvent(event Event) storage.Event {
 		Description:  event.Description,
 		UserID:       event.UserID,
 		Notification: event.Notification,
-	}
+		Recurrence:   recurrence,
+		AllDay:       event.AllDay,
+		Version:      event.Version,
+	}, nil
 }
 
 func storageEventToHTTPEvent(event storage.Event) Event {
-	return Event{
+	result := Event{
 		ID:           event.ID,
 		Title:        event.Title,
 		Start:        event.Start,
@@ -99,5 +202,12 @@ func storageEventToHTTPEvent(event storage.Event) Event {
 		Description:  event.Description,
 		UserID:       event.UserID,
 		Notification: event.Notification,
+		AllDay:       event.AllDay,
+		Version:      event.Version,
+	}
+	if event.Recurrence != nil {
+		result.RRule = event.Recurrence.RRule()
+		result.ExDates = event.Recurrence.Exceptions
 	}
+	return result
 }
@@ -0,0 +1,46 @@
+package httpserver
+
+import (
+	"net/http"
+
+	"github.com/anfilat/otus-go/hw12_13_14_15_calendar/internal/app"
+	"github.com/anfilat/otus-go/hw12_13_14_15_calendar/internal/storage"
+)
+
+func handleGetSettings(app app.App) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		userID, ok := getRestUserID(w, r)
+		if !ok {
+			return
+		}
+
+		settings, err := app.GetSettings(r.Context(), userID)
+		if err != nil {
+			writeAppError(w, err)
+			return
+		}
+
+		writeJSON(w, Settings{TimeZone: settings.TimeZone})
+	}
+}
+
+func handlePutSettings(app app.App) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		userID, ok := getRestUserID(w, r)
+		if !ok {
+			return
+		}
+
+		req := Settings{}
+		if !readRestJSON(w, r, &req) {
+			return
+		}
+		err := app.SaveSettings(r.Context(), userID, storage.UserSettings{TimeZone: req.TimeZone})
+		if err != nil {
+			writeAppError(w, err)
+			return
+		}
+
+		writeJSON(w, req)
+	}
+}
@@ -0,0 +1,71 @@
+package httpserver
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"testing"
+
+	"github.com/stretchr/testify/suite"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/propagation"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+)
+
+type HttpTracingTest struct {
+	SuiteTest
+	exporter *tracetest.InMemoryExporter
+	provider trace.TracerProvider
+}
+
+func (s *HttpTracingTest) SetupTest() {
+	s.provider = otel.GetTracerProvider()
+	s.exporter = tracetest.NewInMemoryExporter()
+	otel.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSyncer(s.exporter)))
+	otel.SetTextMapPropagator(propagation.TraceContext{})
+	s.SuiteTest.SetupTest()
+}
+
+func (s *HttpTracingTest) TearDownTest() {
+	s.SuiteTest.TearDownTest()
+	otel.SetTracerProvider(s.provider)
+}
+
+func (s *HttpTracingTest) TestTraceparent() {
+	const traceID = "4bf92f3577b34da6a3ce929d0e0e4736"
+	data, _ := json.Marshal(s.NewCommonEvent())
+	req, err := http.NewRequest(http.MethodPost, s.ts.URL+"/api/create", bytes.NewReader(data))
+	s.Require().NoError(err)
+	req.Header.Set(UserIDHeader, strconv.Itoa(1))
+	req.Header.Set("traceparent", "00-"+traceID+"-00f067aa0ba902b7-01")
+	res, err := http.DefaultClient.Do(req)
+	s.Require().NoError(err)
+	res.Body.Close()
+	s.Require().Equal(http.StatusOK, res.StatusCode)
+
+	spans := s.exporter.GetSpans()
+	var server, create *sdktrace.SpanSnapshot
+	for _, span := range spans {
+		switch span.Name {
+		case "POST /api/create":
+			server = span
+		case "app.Create":
+			create = span
+		}
+	}
+	s.Require().NotNil(server)
+	s.Require().NotNil(create)
+
+	s.Require().Equal(trace.SpanKindServer, server.SpanKind)
+	s.Require().Equal(traceID, server.SpanContext.TraceID().String())
+	s.Require().Equal("00f067aa0ba902b7", server.Parent.SpanID().String())
+	s.Require().Equal(traceID, create.SpanContext.TraceID().String())
+	s.Require().Equal(server.SpanContext.SpanID(), create.Parent.SpanID())
+}
+
+func TestHttpTracingTest(t *testing.T) {
+	suite.Run(t, new(HttpTracingTest))
+}
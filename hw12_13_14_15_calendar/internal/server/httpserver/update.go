@@ -10,6 +10,11 @@ import (
 
 func handleUpdate(app app.App) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
+		userID, ok := getUserID(w, r)
+		if !ok {
+			return
+		}
+
 		body, err := ioutil.ReadAll(r.Body)
 		defer r.Body.Close()
 		if err != nil {
@@ -24,13 +29,24 @@ func handleUpdate(app app.App) http.HandlerFunc {
 			return
 		}
 
-		change := httpEventToStorageEvent(req)
-		err = app.Update(r.Context(), req.ID, change)
+		change, err := httpEventToStorageEvent(req)
 		if err != nil {
 			http.Error(w, err.Error(), http.StatusBadRequest)
 			return
 		}
+		change.Version, err = getVersion(r, req)
+		if err != nil {
+			http.Error(w, "invalid If-Match header", http.StatusBadRequest)
+			return
+		}
+		change.UserID = userID
+		version, err := app.Update(r.Context(), userID, req.ID, change)
+		if err != nil {
+			http.Error(w, err.Error(), appErrorStatus(err))
+			return
+		}
 
-		writeJSON(w, OkResult{Ok: true})
+		w.Header().Set("ETag", formatETag(version))
+		writeJSON(w, UpdateResult{Ok: true, Version: version})
 	}
 }
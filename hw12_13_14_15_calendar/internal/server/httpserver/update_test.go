@@ -1,7 +1,9 @@
 package httpserver
 
 import (
+	"bytes"
 	"encoding/json"
+	"io/ioutil"
 	"net/http"
 	"testing"
 	"time"
@@ -24,6 +26,54 @@ func (s *HttpUpdateTest) TestUpdate() {
 	res, err := s.Call("update", data)
 	s.Require().NoError(err)
 	s.Require().Equal(http.StatusOK, res.StatusCode)
+	s.Require().Equal(`"2"`, res.Header.Get("ETag"))
+
+	body, err := ioutil.ReadAll(res.Body)
+	res.Body.Close()
+	s.Require().NoError(err)
+	result := UpdateResult{}
+	s.Require().NoError(json.Unmarshal(body, &result))
+	s.Require().Equal(UpdateResult{Ok: true, Version: 2}, result)
+}
+
+func (s *HttpUpdateTest) TestUpdateFailConflict() {
+	event := s.NewCommonEvent()
+	event.ID = s.AddEvent(event)
+
+	data, _ := json.Marshal(event)
+	res, err := s.Call("update", data)
+	s.Require().NoError(err)
+	res.Body.Close()
+	s.Require().Equal(http.StatusOK, res.StatusCode)
+
+	// повтор с той же версией
+	res, err = s.Call("update", data)
+	s.Require().NoError(err)
+	res.Body.Close()
+	s.Require().Equal(http.StatusConflict, res.StatusCode)
+}
+
+func (s *HttpUpdateTest) TestUpdateIfMatch() {
+	event := s.NewCommonEvent()
+	event.ID = s.AddEvent(event)
+	event.Version = 0
+	data, _ := json.Marshal(event)
+
+	req, err := http.NewRequest(http.MethodPost, s.ts.URL+"/api/update", bytes.NewReader(data))
+	s.Require().NoError(err)
+	req.Header.Set(UserIDHeader, "1")
+	req.Header.Set("If-Match", `"1"`)
+	res, err := http.DefaultClient.Do(req)
+	s.Require().NoError(err)
+	res.Body.Close()
+	s.Require().Equal(http.StatusOK, res.StatusCode)
+	s.Require().Equal(`"2"`, res.Header.Get("ETag"))
+
+	// без версии
+	res, err = s.Call("update", data)
+	s.Require().NoError(err)
+	res.Body.Close()
+	s.Require().Equal(http.StatusBadRequest, res.StatusCode)
 }
 
 func TestHttpUpdateTest(t *testing.T) {
@@ -0,0 +1,68 @@
+package httpserver
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/suite"
+)
+
+type UserTest struct {
+	SuiteTest
+}
+
+func (s *UserTest) TestNoUser() {
+	data, _ := json.Marshal(ListRequest{Date: time.Now()})
+	res, err := s.CallAs(0, "listday", data)
+	s.Require().NoError(err)
+	res.Body.Close()
+	s.Require().Equal(http.StatusUnauthorized, res.StatusCode)
+}
+
+func (s *UserTest) TestList() {
+	event := s.NewCommonEvent()
+	id := s.AddEvent(event)
+
+	other := s.NewCommonEvent()
+	other.UserID = 2
+	s.AddEvent(other)
+
+	data, _ := json.Marshal(ListRequest{Date: event.Start})
+	res, err := s.CallAs(1, "listday", data)
+	s.Require().NoError(err)
+	s.Require().Equal(http.StatusOK, res.StatusCode)
+	events := s.readEvents(res.Body)
+	s.Require().Equal(1, len(events))
+	s.Require().Equal(id, events[0].ID)
+}
+
+func (s *UserTest) TestForbidden() {
+	event := s.NewCommonEvent()
+	event.ID = s.AddEvent(event)
+
+	event.Title = "stolen event"
+	data, _ := json.Marshal(event)
+	res, err := s.CallAs(2, "update", data)
+	s.Require().NoError(err)
+	res.Body.Close()
+	s.Require().Equal(http.StatusForbidden, res.StatusCode)
+
+	data, _ = json.Marshal(DeleteRequest{ID: event.ID})
+	res, err = s.CallAs(2, "delete", data)
+	s.Require().NoError(err)
+	res.Body.Close()
+	s.Require().Equal(http.StatusForbidden, res.StatusCode)
+
+	data, _ = json.Marshal(ListRequest{Date: event.Start})
+	res, err = s.CallAs(1, "listday", data)
+	s.Require().NoError(err)
+	events := s.readEvents(res.Body)
+	s.Require().Equal(1, len(events))
+	s.Require().Equal("some event", events[0].Title)
+}
+
+func TestUserTest(t *testing.T) {
+	suite.Run(t, new(UserTest))
+}
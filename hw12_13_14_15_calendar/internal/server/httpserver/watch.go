@@ -0,0 +1,106 @@
+package httpserver
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/anfilat/otus-go/hw12_13_14_15_calendar/internal/app"
+)
+
+// Поток держится меньше WriteTimeout сервера, после чего закрывается,
+// и EventSource переподключается с заголовком Last-Event-ID без потери изменений.
+const (
+	watchStreamDuration = writeTimeout - time.Second
+	watchRetry          = time.Second
+)
+
+// handleWatchEvents передает изменения событий пользователя как Server-Sent Events:
+// event - created, updated или deleted, data - событие в JSON, id - токен для возобновления.
+// Параметры from и to (RFC 3339) необязательны, токен берется из Last-Event-ID или параметра token.
+// Без токена поток начинается с id текущего состояния, чтобы клиент мог переподключиться без пропусков.
+func handleWatchEvents(app app.App, done <-chan struct{}) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		userID, ok := getRestUserID(w, r)
+		if !ok {
+			return
+		}
+		flusher, ok := w.(http.Flusher)
+		if !ok {
+			writeError(w, http.StatusInternalServerError, codeInternal, "streaming is not supported")
+			return
+		}
+
+		query := r.URL.Query()
+		from, err := queryTime(query, "from")
+		if err != nil {
+			writeError(w, http.StatusBadRequest, codeBadRequest, "invalid parameter from")
+			return
+		}
+		to, err := queryTime(query, "to")
+		if err != nil {
+			writeError(w, http.StatusBadRequest, codeBadRequest, "invalid parameter to")
+			return
+		}
+		token := r.Header.Get("Last-Event-ID")
+		if token == "" {
+			token = query.Get("token")
+		}
+
+		changes, head, err := app.Watch(r.Context(), userID, watchFilter(from, to), token)
+		if err != nil {
+			writeAppError(w, err)
+			return
+		}
+
+		w.Header().Set("Content-Type", "text/event-stream")
+		w.Header().Set("Cache-Control", "no-cache")
+		w.WriteHeader(http.StatusOK)
+		if token == "" {
+			// без этого id клиент, отключенный до первого изменения,
+			// переподключился бы без Last-Event-ID и пропустил изменения за время разрыва
+			fmt.Fprintf(w, "id: %s\nretry: %d\n\n", head, watchRetry.Milliseconds())
+		} else {
+			fmt.Fprintf(w, "retry: %d\n\n", watchRetry.Milliseconds())
+		}
+		flusher.Flush()
+
+		timer := time.NewTimer(watchStreamDuration)
+		defer timer.Stop()
+		for {
+			select {
+			case change, ok := <-changes:
+				if !ok {
+					// отмена запроса или клиент не успевает читать
+					return
+				}
+				data, _ := json.Marshal(storageEventToHTTPEvent(change.Event))
+				fmt.Fprintf(w, "id: %s\nevent: %s\ndata: %s\n\n", change.Token, changeTypeName(change.Type), data)
+				flusher.Flush()
+			case <-timer.C:
+				return
+			case <-done:
+				return
+			}
+		}
+	}
+}
+
+// watchFilter нужна, потому что в обработчике имя app занято параметром.
+func watchFilter(from, to time.Time) app.WatchFilter {
+	return app.WatchFilter{From: from, To: to}
+}
+
+func changeTypeName(changeType app.ChangeType) string {
+	switch changeType {
+	case app.ChangeCreated:
+		return "created"
+	case app.ChangeUpdated:
+		return "updated"
+	case app.ChangeDeleted:
+		return "deleted"
+	default:
+		return "unknown"
+	}
+}
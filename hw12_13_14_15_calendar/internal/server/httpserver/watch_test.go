@@ -0,0 +1,166 @@
+package httpserver
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/suite"
+
+	"github.com/anfilat/otus-go/hw12_13_14_15_calendar/internal/health"
+)
+
+type HttpWatchTest struct {
+	SuiteTest
+}
+
+type sseMessage struct {
+	ID    string
+	Event string
+	Data  string
+}
+
+// Watch открывает поток изменений и возвращает канал его сообщений.
+func (s *HttpWatchTest) Watch(ctx context.Context, token string) (<-chan sseMessage, *http.Response) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, s.ts.URL+"/api/v1/events/watch", nil)
+	s.Require().NoError(err)
+	req.Header.Set(UserIDHeader, "1")
+	if token != "" {
+		req.Header.Set("Last-Event-ID", token)
+	}
+	res, err := http.DefaultClient.Do(req)
+	s.Require().NoError(err)
+	if res.StatusCode != http.StatusOK {
+		res.Body.Close()
+		return nil, res
+	}
+	s.Require().Equal("text/event-stream", res.Header.Get("Content-Type"))
+
+	messages := make(chan sseMessage)
+	go func() {
+		defer close(messages)
+		defer res.Body.Close()
+
+		scanner := bufio.NewScanner(res.Body)
+		msg := sseMessage{}
+		for scanner.Scan() {
+			line := scanner.Text()
+			switch {
+			case line == "":
+				// сообщение только с id - токен, с которым начинается поток
+				if msg.Event != "" || msg.ID != "" {
+					messages <- msg
+				}
+				msg = sseMessage{}
+			case strings.HasPrefix(line, "id: "):
+				msg.ID = strings.TrimPrefix(line, "id: ")
+			case strings.HasPrefix(line, "event: "):
+				msg.Event = strings.TrimPrefix(line, "event: ")
+			case strings.HasPrefix(line, "data: "):
+				msg.Data = strings.TrimPrefix(line, "data: ")
+			}
+		}
+	}()
+	return messages, res
+}
+
+func (s *HttpWatchTest) next(messages <-chan sseMessage) sseMessage {
+	select {
+	case msg, ok := <-messages:
+		s.Require().True(ok, "the stream is closed")
+		return msg
+	case <-time.After(time.Second):
+		s.FailNow("no message")
+	}
+	return sseMessage{}
+}
+
+func (s *HttpWatchTest) TestWatch() {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	messages, _ := s.Watch(ctx, "")
+	head := s.next(messages)
+	s.Require().Equal("", head.Event)
+	s.Require().NotEqual("", head.ID)
+
+	event := s.NewCommonEvent()
+	id := s.AddEvent(event)
+
+	msg := s.next(messages)
+	s.Require().Equal("created", msg.Event)
+	s.Require().NotEqual("", msg.ID)
+	result := Event{}
+	s.Require().NoError(json.Unmarshal([]byte(msg.Data), &result))
+	s.Require().Equal(id, result.ID)
+	s.EqualEvents(event, result)
+	cancel()
+
+	// изменение, пропущенное между подключениями
+	data, _ := json.Marshal(DeleteRequest{ID: id})
+	res, err := s.Call("delete", data)
+	s.Require().NoError(err)
+	res.Body.Close()
+
+	ctx, cancel = context.WithCancel(context.Background())
+	defer cancel()
+	messages, _ = s.Watch(ctx, msg.ID)
+	msg = s.next(messages)
+	s.Require().Equal("deleted", msg.Event)
+	s.Require().NoError(json.Unmarshal([]byte(msg.Data), &result))
+	s.Require().Equal(id, result.ID)
+}
+
+// Клиент, отключенный до первого изменения, переподключается с начальным id
+// и получает изменения, сделанные за время разрыва.
+func (s *HttpWatchTest) TestResumeBeforeChanges() {
+	ctx, cancel := context.WithCancel(context.Background())
+	messages, _ := s.Watch(ctx, "")
+	head := s.next(messages)
+	cancel()
+
+	id := s.AddEvent(s.NewCommonEvent())
+
+	ctx, cancel = context.WithCancel(context.Background())
+	defer cancel()
+	messages, _ = s.Watch(ctx, head.ID)
+	msg := s.next(messages)
+	s.Require().Equal("created", msg.Event)
+	result := Event{}
+	s.Require().NoError(json.Unmarshal([]byte(msg.Data), &result))
+	s.Require().Equal(id, result.ID)
+}
+
+func (s *HttpWatchTest) TestWatchFail() {
+	ctx := context.Background()
+
+	_, res := s.Watch(ctx, "abc")
+	s.Require().Equal(http.StatusUnprocessableEntity, res.StatusCode)
+
+	_, res = s.Watch(ctx, "1.1")
+	s.Require().Equal(http.StatusGone, res.StatusCode)
+}
+
+func (s *HttpWatchTest) TestStop() {
+	srv := newServer(s.app, health.New(), s.logg)
+	s.ts.Config.Handler = srv.handler
+
+	messages, _ := s.Watch(context.Background(), "")
+	s.next(messages)
+	close(srv.done)
+
+	select {
+	case _, ok := <-messages:
+		s.Require().False(ok)
+	case <-time.After(time.Second):
+		s.FailNow("the stream is not closed")
+	}
+}
+
+func TestHttpWatchTest(t *testing.T) {
+	suite.Run(t, new(HttpWatchTest))
+}
@@ -0,0 +1,50 @@
+package middleware
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/anfilat/otus-go/hw12_13_14_15_calendar/internal/logger"
+)
+
+// loggingMiddleware кладет в контекст логгер запроса с его ID и ID пользователя
+// и после ответа пишет запись о запросе.
+func loggingMiddleware(logg logger.Logger, message string, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		start := time.Now()
+
+		requestLogger := logg.WithField(logger.RequestIDField, requestID(w, r))
+		if userID, err := strconv.Atoi(r.Header.Get(UserIDHeader)); err == nil && userID > 0 {
+			requestLogger = requestLogger.WithField(logger.UserIDField, userID)
+		}
+
+		rw := &responseWriter{w, http.StatusOK}
+		next.ServeHTTP(rw, r.WithContext(logger.WithContext(r.Context(), requestLogger)))
+
+		requestLogger.WithFields(logger.Fields{
+			"remote_addr": requestAddr(r),
+			"method":      r.Method,
+			"uri":         r.RequestURI,
+			"proto":       r.Proto,
+			"status":      rw.code,
+			"latency_ms":  time.Since(start).Milliseconds(),
+			"user_agent":  r.UserAgent(),
+		}).Info(message)
+	})
+}
+
+// requestID возвращает ID запроса и передает его клиенту в заголовке ответа.
+func requestID(w http.ResponseWriter, r *http.Request) string {
+	id := r.Header.Get(RequestIDHeader)
+	if id == "" {
+		id = logger.NewRequestID()
+	}
+	w.Header().Set(RequestIDHeader, id)
+	return id
+}
+
+func requestAddr(r *http.Request) string {
+	return strings.Split(r.RemoteAddr, ":")[0]
+}
@@ -0,0 +1,20 @@
+package middleware
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/anfilat/otus-go/hw12_13_14_15_calendar/internal/metrics"
+)
+
+// metricsMiddleware учитывает запросы по шаблону маршрута, а не по пути, чтобы ID событий не плодили метки.
+func metricsMiddleware(server string, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		start := time.Now()
+
+		rw := &responseWriter{w, http.StatusOK}
+		next.ServeHTTP(rw, r)
+
+		metrics.ObserveHTTP(server, getRoute(r), r.Method, rw.code, time.Since(start))
+	})
+}
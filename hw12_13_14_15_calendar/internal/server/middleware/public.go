@@ -0,0 +1,48 @@
+// Package middleware содержит общие для HTTP сервера и шлюза middleware трассировки,
+// логирования и метрик запросов.
+package middleware
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/anfilat/otus-go/hw12_13_14_15_calendar/internal/logger"
+)
+
+// UserIDHeader - заголовок запроса с ID пользователя.
+const UserIDHeader = "X-User-Id"
+
+// RequestIDHeader - заголовок с ID запроса. Если клиент его не передал, ID создается сервером.
+const RequestIDHeader = "X-Request-Id"
+
+// Unmatched - маршрут запросов, для которых маршрутизатор не нашел маршрута. Путь вместо него
+// не используется, иначе любой клиент может плодить метки произвольными URL, а в именах
+// спанов окажутся ID событий.
+const Unmatched = "unmatched"
+
+type Options struct {
+	// Server - метка server метрик (http или gateway)
+	Server string
+	// Service - имя сервиса в атрибутах спана запроса
+	Service string
+	// Message - сообщение записи лога о запросе
+	Message string
+	Logger  logger.Logger
+}
+
+// Handler оборачивает весь обработчик сервера, поэтому запросы без маршрута тоже трассируются,
+// логируются и учитываются. Маршрут запроса обработчик передает через SetRoute.
+func Handler(next http.Handler, options Options) http.Handler {
+	handler := metricsMiddleware(options.Server, next)
+	handler = loggingMiddleware(options.Logger, options.Message, handler)
+	handler = tracingMiddleware(options.Service, handler)
+	return routeMiddleware(handler)
+}
+
+// SetRoute сохраняет шаблон маршрута запроса, например "/api/v1/events/{id}".
+// Маршрутизатор вызывает ее, когда маршрут найден.
+func SetRoute(ctx context.Context, route string) {
+	if holder, ok := ctx.Value(routeKey{}).(*string); ok {
+		*holder = route
+	}
+}
@@ -1,4 +1,4 @@
-package httpserver
+package middleware
 
 import "net/http"
 
@@ -11,3 +11,9 @@ func (w *responseWriter) WriteHeader(statusCode int) {
 	w.code = statusCode
 	w.ResponseWriter.WriteHeader(statusCode)
 }
+
+func (w *responseWriter) Flush() {
+	if flusher, ok := w.ResponseWriter.(http.Flusher); ok {
+		flusher.Flush()
+	}
+}
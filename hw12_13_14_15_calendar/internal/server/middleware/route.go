@@ -0,0 +1,24 @@
+package middleware
+
+import (
+	"context"
+	"net/http"
+)
+
+type routeKey struct{}
+
+// routeMiddleware кладет в контекст место для маршрута, который заполняет SetRoute.
+func routeMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		route := Unmatched
+		next.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), routeKey{}, &route)))
+	})
+}
+
+// getRoute возвращает маршрут запроса. Его можно получить только после обработки запроса.
+func getRoute(r *http.Request) string {
+	if route, ok := r.Context().Value(routeKey{}).(*string); ok {
+		return *route
+	}
+	return Unmatched
+}
@@ -0,0 +1,32 @@
+package middleware
+
+import (
+	"net/http"
+
+	"go.opentelemetry.io/otel/semconv"
+	"go.opentelemetry.io/otel/trace"
+
+	"github.com/anfilat/otus-go/hw12_13_14_15_calendar/internal/tracing"
+)
+
+// tracingMiddleware продолжает трассировку из заголовка traceparent или начинает новую.
+// Маршрут известен только после обработки запроса, поэтому имя спана задается в конце.
+func tracingMiddleware(service string, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ctx := tracing.ExtractHTTP(r.Context(), r.Header)
+		ctx, span := tracing.Start(ctx, r.Method,
+			trace.WithSpanKind(trace.SpanKindServer),
+			trace.WithAttributes(semconv.HTTPServerAttributesFromHTTPRequest(service, "", r)...),
+		)
+		defer span.End()
+
+		rw := &responseWriter{w, http.StatusOK}
+		next.ServeHTTP(rw, r.WithContext(ctx))
+
+		route := getRoute(r)
+		span.SetName(r.Method + " " + route)
+		span.SetAttributes(semconv.HTTPRouteKey.String(route))
+		span.SetAttributes(semconv.HTTPAttributesFromHTTPStatusCode(rw.code)...)
+		span.SetStatus(semconv.SpanStatusFromHTTPStatusCode(rw.code))
+	})
+}
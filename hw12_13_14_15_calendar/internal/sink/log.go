@@ -0,0 +1,31 @@
+package sink
+
+import (
+	"context"
+	"time"
+
+	"github.com/anfilat/otus-go/hw12_13_14_15_calendar/internal/logger"
+	"github.com/anfilat/otus-go/hw12_13_14_15_calendar/internal/queue"
+)
+
+type logSink struct {
+	logger logger.Logger
+}
+
+func NewLog(logger logger.Logger) Sink {
+	return &logSink{logger: logger}
+}
+
+func (s *logSink) Send(_ context.Context, notification queue.Notification) error {
+	s.logger.WithFields(logger.Fields{
+		"event_id":         notification.EventID,
+		"title":            notification.Title,
+		"date":             notification.Date.Format(time.RFC3339),
+		logger.UserIDField: notification.UserID,
+	}).Info("notification")
+	return nil
+}
+
+func (s *logSink) Close() error {
+	return nil
+}
@@ -0,0 +1,39 @@
+package sink
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/anfilat/otus-go/hw12_13_14_15_calendar/internal/logger"
+	"github.com/anfilat/otus-go/hw12_13_14_15_calendar/internal/queue"
+)
+
+type Sink interface {
+	Send(ctx context.Context, notification queue.Notification) error
+	Close() error
+}
+
+const (
+	KindLog     = "log"
+	KindStdout  = "stdout"
+	KindWebhook = "webhook"
+	KindFile    = "file"
+)
+
+// New создает sink по его виду. target - url для webhook или путь для file.
+func New(kind, target string, timeout time.Duration, logger logger.Logger) (Sink, error) {
+	switch kind {
+	case KindLog:
+		return NewLog(logger), nil
+	case KindStdout:
+		return NewWriter(os.Stdout), nil
+	case KindWebhook:
+		return NewWebhook(target, timeout), nil
+	case KindFile:
+		return NewFile(target)
+	default:
+		return nil, fmt.Errorf("unknown sink %q", kind)
+	}
+}
@@ -0,0 +1,78 @@
+package sink
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/anfilat/otus-go/hw12_13_14_15_calendar/internal/queue"
+)
+
+var notification = queue.Notification{
+	EventID: 1,
+	Title:   "event",
+	Date:    time.Date(2021, 2, 1, 12, 0, 0, 0, time.UTC),
+	UserID:  2,
+}
+
+func TestWriter(t *testing.T) {
+	var buf bytes.Buffer
+	s := NewWriter(&buf)
+
+	require.NoError(t, s.Send(context.Background(), notification))
+	require.NoError(t, s.Send(context.Background(), notification))
+	require.NoError(t, s.Close())
+
+	lines := bytes.Split(bytes.TrimSpace(buf.Bytes()), []byte("\n"))
+	require.Equal(t, 2, len(lines))
+	result := queue.Notification{}
+	require.NoError(t, json.Unmarshal(lines[0], &result))
+	require.Equal(t, notification, result)
+}
+
+func TestFile(t *testing.T) {
+	dir, err := ioutil.TempDir("", "sink")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	fileName := filepath.Join(dir, "notifications", "out.log")
+	s, err := NewFile(fileName)
+	require.NoError(t, err)
+	require.NoError(t, s.Send(context.Background(), notification))
+	require.NoError(t, s.Close())
+
+	data, err := ioutil.ReadFile(fileName)
+	require.NoError(t, err)
+	result := queue.Notification{}
+	require.NoError(t, json.Unmarshal(data, &result))
+	require.Equal(t, notification, result)
+}
+
+func TestWebhook(t *testing.T) {
+	var received queue.Notification
+	status := http.StatusOK
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		data, _ := ioutil.ReadAll(r.Body)
+		_ = json.Unmarshal(data, &received)
+		w.WriteHeader(status)
+	}))
+	defer ts.Close()
+
+	s := NewWebhook(ts.URL, time.Second)
+	defer s.Close()
+
+	require.NoError(t, s.Send(context.Background(), notification))
+	require.Equal(t, notification, received)
+
+	status = http.StatusServiceUnavailable
+	require.Error(t, s.Send(context.Background(), notification))
+}
@@ -0,0 +1,57 @@
+package sink
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"time"
+
+	"github.com/anfilat/otus-go/hw12_13_14_15_calendar/internal/queue"
+)
+
+type webhookSink struct {
+	url    string
+	client *http.Client
+}
+
+func NewWebhook(url string, timeout time.Duration) Sink {
+	return &webhookSink{
+		url:    url,
+		client: &http.Client{Timeout: timeout},
+	}
+}
+
+func (s *webhookSink) Send(ctx context.Context, notification queue.Notification) error {
+	data, err := json.Marshal(notification)
+	if err != nil {
+		return fmt.Errorf("sink marshal: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, s.url, bytes.NewReader(data))
+	if err != nil {
+		return fmt.Errorf("sink request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	res, err := s.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("sink request: %w", err)
+	}
+	defer res.Body.Close()
+	//nolint:errcheck
+	io.Copy(ioutil.Discard, res.Body)
+
+	if res.StatusCode < 200 || res.StatusCode >= 300 {
+		return fmt.Errorf("sink request: unexpected status %s", res.Status)
+	}
+	return nil
+}
+
+func (s *webhookSink) Close() error {
+	s.client.CloseIdleConnections()
+	return nil
+}
@@ -0,0 +1,62 @@
+package sink
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sync"
+
+	"github.com/anfilat/otus-go/hw12_13_14_15_calendar/internal/queue"
+)
+
+// writerSink пишет уведомления построчно в json.
+type writerSink struct {
+	mu     sync.Mutex
+	writer io.Writer
+	closer io.Closer
+}
+
+func NewWriter(writer io.Writer) Sink {
+	return &writerSink{writer: writer}
+}
+
+func NewFile(fileName string) (Sink, error) {
+	fileName, err := filepath.Abs(fileName)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open sink file: %w", err)
+	}
+	if err = os.MkdirAll(filepath.Dir(fileName), 0775); err != nil {
+		return nil, fmt.Errorf("failed to open sink file: %w", err)
+	}
+	file, err := os.OpenFile(fileName, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0666)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open sink file: %w", err)
+	}
+	return &writerSink{writer: file, closer: file}, nil
+}
+
+func (s *writerSink) Send(_ context.Context, notification queue.Notification) error {
+	data, err := json.Marshal(notification)
+	if err != nil {
+		return fmt.Errorf("sink marshal: %w", err)
+	}
+	data = append(data, '\n')
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, err := s.writer.Write(data); err != nil {
+		return fmt.Errorf("sink write: %w", err)
+	}
+	return nil
+}
+
+func (s *writerSink) Close() error {
+	if s.closer == nil {
+		return nil
+	}
+	return s.closer.Close()
+}
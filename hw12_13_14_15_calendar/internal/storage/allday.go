@@ -0,0 +1,46 @@
+package storage
+
+import "time"
+
+// Событие на весь день (Event.AllDay) задается датами, а не моментами: Start - полночь UTC первого дня,
+// Stop - полночь UTC дня после последнего. Такое событие приходится на одни и те же календарные дни
+// в любом часовом поясе: с интервалом [from, to) сравниваются календарные дата и время from и to.
+
+// Date возвращает полночь UTC календарной даты t в ее часовом поясе.
+func Date(t time.Time) time.Time {
+	year, month, day := t.Date()
+	return time.Date(year, month, day, 0, 0, 0, 0, time.UTC)
+}
+
+// AllDayDates приводит начало и окончание события на весь день к датам. Неполный последний день
+// включается в событие, событие короче дня занимает день start.
+func AllDayDates(start, stop time.Time) (time.Time, time.Time) {
+	from, to := Date(start), Date(stop)
+	if !to.Equal(floating(stop)) {
+		to = to.AddDate(0, 0, 1)
+	}
+	if !to.After(from) {
+		to = from.AddDate(0, 0, 1)
+	}
+	return from, to
+}
+
+// AllDayRange возвращает интервал [from, to), с которым сравниваются даты событий на весь день.
+func AllDayRange(from, to time.Time) (time.Time, time.Time) {
+	return floating(from), floating(to)
+}
+
+// floating возвращает момент UTC с теми же календарными датой и временем, что у t.
+func floating(t time.Time) time.Time {
+	year, month, day := t.Date()
+	hour, minute, sec := t.Clock()
+	return time.Date(year, month, day, hour, minute, sec, t.Nanosecond(), time.UTC)
+}
+
+// window возвращает интервал, с которым сравниваются повторения события.
+func window(event Event, from, to time.Time) (time.Time, time.Time) {
+	if event.AllDay {
+		return AllDayRange(from, to)
+	}
+	return from, to
+}
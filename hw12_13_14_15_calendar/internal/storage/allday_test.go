@@ -0,0 +1,44 @@
+package storage
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestAllDayDates(t *testing.T) {
+	moscow := time.FixedZone("MSK", 3*60*60)
+	monday := time.Date(2021, 3, 1, 0, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name        string
+		start, stop time.Time
+		days        int
+	}{
+		{"dates", monday, monday.AddDate(0, 0, 3), 3},
+		{"incomplete last day", monday.Add(9 * time.Hour), monday.AddDate(0, 0, 2).Add(18 * time.Hour), 3},
+		{"same day", monday, monday, 1},
+		{"zone", time.Date(2021, 3, 1, 1, 0, 0, 0, moscow), time.Date(2021, 3, 2, 0, 0, 0, 0, moscow), 1},
+	}
+	for _, tt := range tests {
+		start, stop := AllDayDates(tt.start, tt.stop)
+		require.Equal(t, monday, start, tt.name)
+		require.Equal(t, monday.AddDate(0, 0, tt.days), stop, tt.name)
+	}
+}
+
+func TestAllDayOccurrences(t *testing.T) {
+	moscow := time.FixedZone("MSK", 3*60*60)
+	monday := time.Date(2021, 3, 1, 0, 0, 0, 0, time.UTC)
+	event := Event{Start: monday, Stop: monday.AddDate(0, 0, 1), AllDay: true}
+
+	// 1 марта 01:00 в Москве - это еще 28 февраля в UTC
+	from := time.Date(2021, 3, 1, 1, 0, 0, 0, moscow)
+	require.True(t, Overlaps(event, from, from.Add(time.Hour)))
+	require.Len(t, Occurrences(event, from, from.Add(time.Hour)), 1)
+
+	event.AllDay = false
+	require.False(t, Overlaps(event, from, from.Add(time.Hour)))
+	require.Len(t, Occurrences(event, from, from.Add(time.Hour)), 0)
+}
@@ -0,0 +1,39 @@
+package storage
+
+import (
+	"errors"
+	"time"
+)
+
+var ErrDateBusy = errors.New("this time is already occupied by another event")
+
+// повторения события проверяются на занятость не дальше этого срока от его начала
+const BusyCheckHorizon = 365 * 24 * time.Hour
+
+// BusyRange возвращает интервал [from, to), в котором событие может пересечься с другими.
+func BusyRange(event Event) (time.Time, time.Time) {
+	if event.Recurrence == nil {
+		return event.Start, event.Stop
+	}
+	return event.Start, event.Start.Add(BusyCheckHorizon).Add(event.Stop.Sub(event.Start))
+}
+
+// IsBusy проверяет, пересекается ли событие или его повторения в пределах BusyCheckHorizon
+// с событиями others, кроме самого события. События на весь день время не занимают.
+func IsBusy(event Event, others []Event) bool {
+	if event.AllDay {
+		return false
+	}
+	occurrences := []Event{event}
+	if event.Recurrence != nil {
+		occurrences = Occurrences(event, event.Start, event.Start.Add(BusyCheckHorizon))
+	}
+	for _, occurrence := range occurrences {
+		for _, other := range others {
+			if other.ID != event.ID && !other.AllDay && Overlaps(other, occurrence.Start, occurrence.Stop) {
+				return true
+			}
+		}
+	}
+	return false
+}
@@ -0,0 +1,38 @@
+package storage
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestIsBusy(t *testing.T) {
+	start := time.Date(2021, 2, 1, 10, 0, 0, 0, time.UTC)
+	daily, err := NewRecurrence("FREQ=DAILY", nil)
+	require.NoError(t, err)
+	others := []Event{
+		{ID: 1, Start: start, Stop: start.Add(time.Hour)},
+		{ID: 2, Start: start.AddDate(0, 0, 10).Add(3 * time.Hour), Stop: start.AddDate(0, 0, 10).Add(4 * time.Hour)},
+	}
+
+	tests := []struct {
+		name  string
+		event Event
+		busy  bool
+	}{
+		{"overlap", Event{Start: start.Add(30 * time.Minute), Stop: start.Add(90 * time.Minute)}, true},
+		{"adjacent", Event{Start: start.Add(time.Hour), Stop: start.Add(2 * time.Hour)}, false},
+		{"itself", Event{ID: 1, Start: start, Stop: start.Add(time.Hour)}, false},
+		{"recurring", Event{Start: start.Add(3 * time.Hour), Stop: start.Add(4 * time.Hour), Recurrence: daily}, true},
+		{"recurring free", Event{Start: start.Add(5 * time.Hour), Stop: start.Add(6 * time.Hour), Recurrence: daily}, false},
+	}
+	for _, tt := range tests {
+		require.Equal(t, tt.busy, IsBusy(tt.event, others), tt.name)
+	}
+
+	// повторение занятого события
+	recurring := []Event{{ID: 3, Start: start, Stop: start.Add(time.Hour), Recurrence: daily}}
+	event := Event{Start: start.AddDate(0, 1, 0), Stop: start.AddDate(0, 1, 0).Add(time.Minute)}
+	require.True(t, IsBusy(event, recurring))
+}
@@ -2,18 +2,39 @@ package initstorage
 
 import (
 	"context"
+	"fmt"
 
 	"github.com/anfilat/otus-go/hw12_13_14_15_calendar/internal/storage"
 	"github.com/anfilat/otus-go/hw12_13_14_15_calendar/internal/storage/memorystorage"
+	"github.com/anfilat/otus-go/hw12_13_14_15_calendar/internal/storage/metricstorage"
+	"github.com/anfilat/otus-go/hw12_13_14_15_calendar/internal/storage/sqlitestorage"
 	"github.com/anfilat/otus-go/hw12_13_14_15_calendar/internal/storage/sqlstorage"
+	"github.com/anfilat/otus-go/hw12_13_14_15_calendar/internal/storage/walstorage"
 )
 
-func New(ctx context.Context, inmem bool, connect string) (storage.Storage, error) {
+// Драйверы базы данных.
+const (
+	// DriverPostgres - PostgreSQL, connect - строка подключения.
+	DriverPostgres = "postgres"
+	// DriverSQLite - встроенная база SQLite, connect - путь к файлу базы.
+	DriverSQLite = "sqlite"
+	// DriverWAL - события в памяти с журналом на диске, connect - каталог и параметры журнала.
+	DriverWAL = "wal"
+)
+
+func New(ctx context.Context, inmem bool, driver, connect string) (storage.Storage, error) {
 	var db storage.Storage
-	if inmem {
-		db = memorystorage.New()
-	} else {
-		db = sqlstorage.New()
+	switch {
+	case inmem:
+		db = metricstorage.New("memory", memorystorage.New())
+	case driver == DriverPostgres || driver == "":
+		db = metricstorage.New("sql", sqlstorage.New())
+	case driver == DriverSQLite:
+		db = metricstorage.New("sqlite", sqlitestorage.New())
+	case driver == DriverWAL:
+		db = metricstorage.New("wal", walstorage.New())
+	default:
+		return nil, fmt.Errorf("unknown database driver %q", driver)
 	}
 	err := db.Connect(ctx, connect)
 	return db, err
@@ -2,8 +2,32 @@ package memorystorage
 
 import "github.com/anfilat/otus-go/hw12_13_14_15_calendar/internal/storage"
 
+// Snapshot - состояние хранилища: события, последний выданный идентификатор
+// и настройки пользователей.
+type Snapshot struct {
+	LastID   int
+	Events   []storage.Event
+	Settings map[int]storage.UserSettings
+}
+
+// Store - хранилище в памяти, состояние которого можно сохранить и восстановить.
+type Store interface {
+	storage.Storage
+	// Snapshot возвращает копию состояния.
+	Snapshot() Snapshot
+	// Restore заменяет состояние на snapshot.
+	Restore(snapshot Snapshot)
+	// Put сохраняет событие как есть, с его идентификатором и версией.
+	Put(event storage.Event)
+}
+
 func New() storage.Storage {
+	return NewStore()
+}
+
+func NewStore() Store {
 	result := store{}
 	result.data = make(data)
+	result.settings = make(map[int]storage.UserSettings)
 	return &result
 }
@@ -12,9 +12,10 @@ import (
 type data map[int]storage.Event
 
 type store struct {
-	mu     sync.Mutex
-	lastID int
-	data   data
+	mu       sync.Mutex
+	lastID   int
+	data     data
+	settings map[int]storage.UserSettings
 }
 
 func (s *store) Connect(_ context.Context, _ string) error {
@@ -25,10 +26,28 @@ func (s *store) Close(_ context.Context) error {
 	return nil
 }
 
+func (s *store) Ping(_ context.Context) error {
+	return nil
+}
+
 func (s *store) Create(_ context.Context, event storage.Event) (int, error) {
 	s.mu.Lock()
 	defer s.mu.Unlock()
 
+	return s.create(event), nil
+}
+
+func (s *store) CreateIfFree(_ context.Context, event storage.Event) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.isBusy(event) {
+		return 0, storage.ErrDateBusy
+	}
+	return s.create(event), nil
+}
+
+func (s *store) create(event storage.Event) int {
 	id := s.newID()
 	event.ID = id
 	s.data[id] = storage.Event{
@@ -39,24 +58,53 @@ func (s *store) Create(_ context.Context, event storage.Event) (int, error) {
 		Description:  event.Description,
 		UserID:       event.UserID,
 		Notification: event.Notification,
+		Recurrence:   event.Recurrence,
+		AllDay:       event.AllDay,
+		Version:      storage.InitialVersion,
 	}
-	return id, nil
+	return id
 }
 
 func (s *store) Update(_ context.Context, id int, change storage.Event) error {
 	s.mu.Lock()
 	defer s.mu.Unlock()
 
+	return s.update(id, change)
+}
+
+func (s *store) UpdateIfFree(_ context.Context, id int, change storage.Event) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	event, ok := s.data[id]
+	if !ok {
+		return storage.ErrNotExistsEvent
+	}
+	change.ID = id
+	change.UserID = event.UserID
+	if s.isBusy(change) {
+		return storage.ErrDateBusy
+	}
+	return s.update(id, change)
+}
+
+func (s *store) update(id int, change storage.Event) error {
 	event, ok := s.data[id]
 	if !ok {
 		return storage.ErrNotExistsEvent
 	}
+	if event.Version != change.Version {
+		return storage.ErrConflict
+	}
 
 	event.Title = change.Title
 	event.Start = change.Start
 	event.Stop = change.Stop
 	event.Description = change.Description
 	event.Notification = change.Notification
+	event.Recurrence = change.Recurrence
+	event.AllDay = change.AllDay
+	event.Version++
 	s.data[id] = event
 
 	return nil
@@ -66,6 +114,9 @@ func (s *store) Delete(_ context.Context, id int) error {
 	s.mu.Lock()
 	defer s.mu.Unlock()
 
+	if _, ok := s.data[id]; !ok {
+		return storage.ErrNotExistsEvent
+	}
 	delete(s.data, id)
 	return nil
 }
@@ -75,9 +126,35 @@ func (s *store) DeleteAll(_ context.Context) error {
 	defer s.mu.Unlock()
 
 	s.data = make(data)
+	s.settings = make(map[int]storage.UserSettings)
 	return nil
 }
 
+func (s *store) DeleteBefore(_ context.Context, date time.Time) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	count := 0
+	for id, event := range s.data {
+		if isBefore(event, date) {
+			delete(s.data, id)
+			count++
+		}
+	}
+	return count, nil
+}
+
+func (s *store) Get(_ context.Context, id int) (storage.Event, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	event, ok := s.data[id]
+	if !ok {
+		return storage.Event{}, storage.ErrNotExistsEvent
+	}
+	return event, nil
+}
+
 func (s *store) ListAll(_ context.Context) ([]storage.Event, error) {
 	s.mu.Lock()
 	defer s.mu.Unlock()
@@ -92,35 +169,67 @@ func (s *store) ListAll(_ context.Context) ([]storage.Event, error) {
 	return result, nil
 }
 
-func (s *store) ListDay(_ context.Context, date time.Time) ([]storage.Event, error) {
+func (s *store) ListDay(_ context.Context, userID int, date time.Time) ([]storage.Event, error) {
+	from, to := storage.DayRange(date)
+	return s.listOverlapping(userID, from, to), nil
+}
+
+func (s *store) ListWeek(_ context.Context, userID int, date time.Time) ([]storage.Event, error) {
+	from, to := storage.WeekRange(date)
+	return s.listOverlapping(userID, from, to), nil
+}
+
+func (s *store) ListMonth(_ context.Context, userID int, date time.Time) ([]storage.Event, error) {
+	from, to := storage.MonthRange(date)
+	return s.listOverlapping(userID, from, to), nil
+}
+
+// listOverlapping возвращает события пользователя и их повторения, пересекающиеся с [from, to).
+func (s *store) listOverlapping(userID int, from, to time.Time) []storage.Event {
 	s.mu.Lock()
 	defer s.mu.Unlock()
 
 	var result []storage.Event
-	year, month, day := date.Date()
 	for _, event := range s.data {
-		eventYear, eventMonth, eventDay := event.Start.Date()
-		if eventYear == year && eventMonth == month && eventDay == day {
-			result = append(result, event)
+		if event.UserID == userID {
+			result = append(result, storage.Occurrences(event, from, to)...)
 		}
 	}
 	sort.Slice(result, func(i, j int) bool {
 		return result[i].Start.Before(result[j].Start)
 	})
-	return result, nil
+	return result
+}
+
+func (s *store) ListRange(_ context.Context, from, to time.Time, filter storage.Filter) (storage.Page, error) {
+	cursor, err := storage.DecodeCursor(filter.Cursor)
+	if err != nil {
+		return storage.Page{}, err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var result []storage.Event
+	for _, event := range s.data {
+		if filter.Match(event) {
+			result = append(result, storage.Occurrences(event, from, to)...)
+		}
+	}
+	return storage.Paginate(result, filter, cursor), nil
 }
 
-func (s *store) ListWeek(_ context.Context, date time.Time) ([]storage.Event, error) {
+func (s *store) ListToNotify(_ context.Context, from, to time.Time) ([]storage.Event, error) {
 	s.mu.Lock()
 	defer s.mu.Unlock()
 
 	var result []storage.Event
-	year, week := date.ISOWeek()
 	for _, event := range s.data {
-		eventYear, eventWeek := event.Start.ISOWeek()
-		if eventYear == year && eventWeek == week {
-			result = append(result, event)
+		if event.Notification == nil {
+			continue
 		}
+		notification := *event.Notification
+		result = append(result, storage.StartsIn(event, from.Add(notification), to.Add(notification))...)
 	}
 	sort.Slice(result, func(i, j int) bool {
 		return result[i].Start.Before(result[j].Start)
@@ -128,15 +237,13 @@ func (s *store) ListWeek(_ context.Context, date time.Time) ([]storage.Event, er
 	return result, nil
 }
 
-func (s *store) ListMonth(_ context.Context, date time.Time) ([]storage.Event, error) {
+func (s *store) ListBefore(_ context.Context, date time.Time) ([]storage.Event, error) {
 	s.mu.Lock()
 	defer s.mu.Unlock()
 
 	var result []storage.Event
-	year, month, _ := date.Date()
 	for _, event := range s.data {
-		eventYear, eventMonth, _ := event.Start.Date()
-		if eventYear == year && eventMonth == month {
+		if isBefore(event, date) {
 			result = append(result, event)
 		}
 	}
@@ -151,13 +258,88 @@ func (s *store) IsTimeBusy(_ context.Context, userID int, start, stop time.Time,
 	defer s.mu.Unlock()
 
 	for _, event := range s.data {
-		if event.UserID == userID && event.ID != excludeID && event.Start.Before(stop) && event.Stop.After(start) {
+		if event.UserID == userID && event.ID != excludeID && !event.AllDay && storage.Overlaps(event, start, stop) {
 			return true, nil
 		}
 	}
 	return false, nil
 }
 
+func (s *store) GetSettings(_ context.Context, userID int) (storage.UserSettings, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.settings[userID], nil
+}
+
+func (s *store) SaveSettings(_ context.Context, userID int, settings storage.UserSettings) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.settings[userID] = settings
+	return nil
+}
+
+// isBusy проверяет занятость времени события другими событиями пользователя.
+func (s *store) isBusy(event storage.Event) bool {
+	var others []storage.Event
+	for _, other := range s.data {
+		if other.UserID == event.UserID {
+			others = append(others, other)
+		}
+	}
+	return storage.IsBusy(event, others)
+}
+
+// isBefore проверяет, что событие со всеми повторениями закончилось раньше date.
+func isBefore(event storage.Event, date time.Time) bool {
+	lastStop, ok := storage.LastStop(event)
+	return ok && lastStop.Before(date)
+}
+
+func (s *store) Snapshot() Snapshot {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	events := make([]storage.Event, 0, len(s.data))
+	for _, event := range s.data {
+		events = append(events, event)
+	}
+	sort.Slice(events, func(i, j int) bool {
+		return events[i].ID < events[j].ID
+	})
+	settings := make(map[int]storage.UserSettings, len(s.settings))
+	for userID, userSettings := range s.settings {
+		settings[userID] = userSettings
+	}
+	return Snapshot{LastID: s.lastID, Events: events, Settings: settings}
+}
+
+func (s *store) Restore(snapshot Snapshot) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.lastID = snapshot.LastID
+	s.data = make(data, len(snapshot.Events))
+	for _, event := range snapshot.Events {
+		s.data[event.ID] = event
+	}
+	s.settings = make(map[int]storage.UserSettings, len(snapshot.Settings))
+	for userID, userSettings := range snapshot.Settings {
+		s.settings[userID] = userSettings
+	}
+}
+
+func (s *store) Put(event storage.Event) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.data[event.ID] = event
+	if event.ID > s.lastID {
+		s.lastID = event.ID
+	}
+}
+
 func (s *store) newID() int {
 	s.lastID++
 	return s.lastID
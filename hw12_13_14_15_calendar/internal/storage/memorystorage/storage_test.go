@@ -0,0 +1,17 @@
+package memorystorage_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/suite"
+
+	"github.com/anfilat/otus-go/hw12_13_14_15_calendar/internal/storage"
+	"github.com/anfilat/otus-go/hw12_13_14_15_calendar/internal/storage/memorystorage"
+	"github.com/anfilat/otus-go/hw12_13_14_15_calendar/internal/storage/storagetest"
+)
+
+func TestStorage(t *testing.T) {
+	suite.Run(t, &storagetest.Suite{NewStorage: func(t *testing.T) storage.Storage {
+		return memorystorage.New()
+	}})
+}
@@ -0,0 +1,12 @@
+// Package metricstorage учитывает в метриках время и ошибки операций хранилища.
+package metricstorage
+
+import "github.com/anfilat/otus-go/hw12_13_14_15_calendar/internal/storage"
+
+// New оборачивает хранилище, backend - его имя в метриках.
+func New(backend string, storage storage.Storage) storage.Storage {
+	return &store{
+		backend: backend,
+		storage: storage,
+	}
+}
@@ -0,0 +1,176 @@
+package metricstorage
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/anfilat/otus-go/hw12_13_14_15_calendar/internal/metrics"
+	"github.com/anfilat/otus-go/hw12_13_14_15_calendar/internal/storage"
+)
+
+type store struct {
+	backend string
+	storage storage.Storage
+}
+
+func (s *store) observe(operation string, begin time.Time, err error) {
+	if isExpected(err) {
+		err = nil
+	}
+	metrics.ObserveStorage(s.backend, operation, err, time.Since(begin))
+}
+
+// isExpected отличает ответы хранилища о состоянии событий от сбоев, на которые нужна реакция.
+func isExpected(err error) bool {
+	return errors.Is(err, storage.ErrNotExistsEvent) ||
+		errors.Is(err, storage.ErrConflict) ||
+		errors.Is(err, storage.ErrDateBusy)
+}
+
+func (s *store) Connect(ctx context.Context, connect string) error {
+	begin := time.Now()
+	err := s.storage.Connect(ctx, connect)
+	s.observe("connect", begin, err)
+	return err
+}
+
+func (s *store) Close(ctx context.Context) error {
+	begin := time.Now()
+	err := s.storage.Close(ctx)
+	s.observe("close", begin, err)
+	return err
+}
+
+func (s *store) Ping(ctx context.Context) error {
+	begin := time.Now()
+	err := s.storage.Ping(ctx)
+	s.observe("ping", begin, err)
+	return err
+}
+
+func (s *store) Create(ctx context.Context, event storage.Event) (int, error) {
+	begin := time.Now()
+	result, err := s.storage.Create(ctx, event)
+	s.observe("create", begin, err)
+	return result, err
+}
+
+func (s *store) Update(ctx context.Context, id int, change storage.Event) error {
+	begin := time.Now()
+	err := s.storage.Update(ctx, id, change)
+	s.observe("update", begin, err)
+	return err
+}
+
+func (s *store) CreateIfFree(ctx context.Context, event storage.Event) (int, error) {
+	begin := time.Now()
+	result, err := s.storage.CreateIfFree(ctx, event)
+	s.observe("create_if_free", begin, err)
+	return result, err
+}
+
+func (s *store) UpdateIfFree(ctx context.Context, id int, change storage.Event) error {
+	begin := time.Now()
+	err := s.storage.UpdateIfFree(ctx, id, change)
+	s.observe("update_if_free", begin, err)
+	return err
+}
+
+func (s *store) Delete(ctx context.Context, id int) error {
+	begin := time.Now()
+	err := s.storage.Delete(ctx, id)
+	s.observe("delete", begin, err)
+	return err
+}
+
+func (s *store) DeleteAll(ctx context.Context) error {
+	begin := time.Now()
+	err := s.storage.DeleteAll(ctx)
+	s.observe("delete_all", begin, err)
+	return err
+}
+
+func (s *store) DeleteBefore(ctx context.Context, date time.Time) (int, error) {
+	begin := time.Now()
+	result, err := s.storage.DeleteBefore(ctx, date)
+	s.observe("delete_before", begin, err)
+	return result, err
+}
+
+func (s *store) Get(ctx context.Context, id int) (storage.Event, error) {
+	begin := time.Now()
+	result, err := s.storage.Get(ctx, id)
+	s.observe("get", begin, err)
+	return result, err
+}
+
+func (s *store) ListAll(ctx context.Context) ([]storage.Event, error) {
+	begin := time.Now()
+	result, err := s.storage.ListAll(ctx)
+	s.observe("list_all", begin, err)
+	return result, err
+}
+
+func (s *store) ListDay(ctx context.Context, userID int, date time.Time) ([]storage.Event, error) {
+	begin := time.Now()
+	result, err := s.storage.ListDay(ctx, userID, date)
+	s.observe("list_day", begin, err)
+	return result, err
+}
+
+func (s *store) ListWeek(ctx context.Context, userID int, date time.Time) ([]storage.Event, error) {
+	begin := time.Now()
+	result, err := s.storage.ListWeek(ctx, userID, date)
+	s.observe("list_week", begin, err)
+	return result, err
+}
+
+func (s *store) ListMonth(ctx context.Context, userID int, date time.Time) ([]storage.Event, error) {
+	begin := time.Now()
+	result, err := s.storage.ListMonth(ctx, userID, date)
+	s.observe("list_month", begin, err)
+	return result, err
+}
+
+func (s *store) ListRange(ctx context.Context, from, to time.Time, filter storage.Filter) (storage.Page, error) {
+	begin := time.Now()
+	result, err := s.storage.ListRange(ctx, from, to, filter)
+	s.observe("list_range", begin, err)
+	return result, err
+}
+
+func (s *store) ListToNotify(ctx context.Context, from, to time.Time) ([]storage.Event, error) {
+	begin := time.Now()
+	result, err := s.storage.ListToNotify(ctx, from, to)
+	s.observe("list_to_notify", begin, err)
+	return result, err
+}
+
+func (s *store) ListBefore(ctx context.Context, date time.Time) ([]storage.Event, error) {
+	begin := time.Now()
+	result, err := s.storage.ListBefore(ctx, date)
+	s.observe("list_before", begin, err)
+	return result, err
+}
+
+func (s *store) IsTimeBusy(ctx context.Context, userID int, start, stop time.Time, excludeID int) (bool, error) {
+	begin := time.Now()
+	result, err := s.storage.IsTimeBusy(ctx, userID, start, stop, excludeID)
+	s.observe("is_time_busy", begin, err)
+	return result, err
+}
+
+func (s *store) GetSettings(ctx context.Context, userID int) (storage.UserSettings, error) {
+	begin := time.Now()
+	result, err := s.storage.GetSettings(ctx, userID)
+	s.observe("get_settings", begin, err)
+	return result, err
+}
+
+func (s *store) SaveSettings(ctx context.Context, userID int, settings storage.UserSettings) error {
+	begin := time.Now()
+	err := s.storage.SaveSettings(ctx, userID, settings)
+	s.observe("save_settings", begin, err)
+	return err
+}
@@ -0,0 +1,124 @@
+package storage
+
+import (
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Filter - условия выборки ListRange.
+type Filter struct {
+	// 0 - события всех пользователей
+	UserID int
+	// подстрока названия или описания без учета регистра, пустая - без ограничения
+	Search string
+	// сортировка по убыванию начала, по умолчанию - по возрастанию
+	Desc bool
+	// курсор, полученный с предыдущей страницей, пустой - первая страница
+	Cursor string
+	// размер страницы, 0 - без ограничения
+	Limit int
+}
+
+// Page - страница событий. NextCursor пустой на последней странице.
+type Page struct {
+	Events     []Event
+	NextCursor string
+}
+
+// Cursor - позиция последнего события страницы. Повторения одного события различаются по Start.
+type Cursor struct {
+	Start time.Time
+	ID    int
+}
+
+var ErrInvalidCursor = errors.New("invalid page cursor")
+
+func EncodeCursor(event Event) string {
+	value := strconv.FormatInt(event.Start.UnixNano(), 10) + ":" + strconv.Itoa(event.ID)
+	return base64.RawURLEncoding.EncodeToString([]byte(value))
+}
+
+// DecodeCursor разбирает курсор. Для пустой строки возвращает nil.
+func DecodeCursor(cursor string) (*Cursor, error) {
+	if cursor == "" {
+		return nil, nil
+	}
+
+	data, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s", ErrInvalidCursor, err)
+	}
+	parts := strings.SplitN(string(data), ":", 2)
+	if len(parts) != 2 {
+		return nil, ErrInvalidCursor
+	}
+	start, err := strconv.ParseInt(parts[0], 10, 64)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s", ErrInvalidCursor, err)
+	}
+	id, err := strconv.Atoi(parts[1])
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s", ErrInvalidCursor, err)
+	}
+	return &Cursor{Start: time.Unix(0, start), ID: id}, nil
+}
+
+// Match проверяет пользователя и текст события.
+func (f Filter) Match(event Event) bool {
+	if f.UserID != 0 && event.UserID != f.UserID {
+		return false
+	}
+	if f.Search == "" {
+		return true
+	}
+	search := strings.ToLower(f.Search)
+	return strings.Contains(strings.ToLower(event.Title), search) ||
+		strings.Contains(strings.ToLower(event.Description), search)
+}
+
+// Paginate сортирует события и возвращает страницу, следующую за курсором.
+func Paginate(events []Event, filter Filter, cursor *Cursor) Page {
+	less := func(a, b Event) bool {
+		if a.Start.Equal(b.Start) {
+			return a.ID < b.ID
+		}
+		return a.Start.Before(b.Start)
+	}
+	sort.Slice(events, func(i, j int) bool {
+		if filter.Desc {
+			return less(events[j], events[i])
+		}
+		return less(events[i], events[j])
+	})
+
+	var result []Event
+	for _, event := range events {
+		if cursor != nil && !cursor.IsBefore(event, filter.Desc) {
+			continue
+		}
+		if filter.Limit > 0 && len(result) == filter.Limit {
+			return Page{Events: result, NextCursor: EncodeCursor(result[len(result)-1])}
+		}
+		result = append(result, event)
+	}
+	return Page{Events: result}
+}
+
+// IsBefore проверяет, что событие идет после курсора в порядке сортировки.
+func (c Cursor) IsBefore(event Event, desc bool) bool {
+	if event.Start.Equal(c.Start) {
+		if desc {
+			return event.ID < c.ID
+		}
+		return event.ID > c.ID
+	}
+	if desc {
+		return event.Start.Before(c.Start)
+	}
+	return event.Start.After(c.Start)
+}
@@ -0,0 +1,67 @@
+package storage
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestCursor(t *testing.T) {
+	event := Event{ID: 42, Start: time.Date(2021, 2, 1, 10, 0, 0, 123000, time.UTC)}
+	cursor, err := DecodeCursor(EncodeCursor(event))
+	require.NoError(t, err)
+	require.Equal(t, 42, cursor.ID)
+	require.True(t, event.Start.Equal(cursor.Start))
+
+	cursor, err = DecodeCursor("")
+	require.NoError(t, err)
+	require.Nil(t, cursor)
+
+	for _, value := range []string{"!!!", "MTIz", "YWJjOjE"} {
+		_, err = DecodeCursor(value)
+		require.True(t, errors.Is(err, ErrInvalidCursor), value)
+	}
+}
+
+func TestPaginate(t *testing.T) {
+	start := time.Date(2021, 2, 1, 10, 0, 0, 0, time.UTC)
+	events := []Event{
+		{ID: 3, Start: start.Add(time.Hour)},
+		{ID: 1, Start: start},
+		{ID: 2, Start: start.Add(time.Hour)},
+		{ID: 1, Start: start.Add(2 * time.Hour)},
+	}
+
+	collect := func(filter Filter) []int {
+		var result []int
+		for {
+			cursor, err := DecodeCursor(filter.Cursor)
+			require.NoError(t, err)
+			page := Paginate(append([]Event(nil), events...), filter, cursor)
+			require.LessOrEqual(t, len(page.Events), filter.Limit)
+			for _, event := range page.Events {
+				result = append(result, event.ID)
+			}
+			if page.NextCursor == "" {
+				return result
+			}
+			filter.Cursor = page.NextCursor
+		}
+	}
+
+	require.Equal(t, []int{1, 2, 3, 1}, collect(Filter{Limit: 1}))
+	require.Equal(t, []int{1, 2, 3, 1}, collect(Filter{Limit: 3}))
+	require.Equal(t, []int{1, 3, 2, 1}, collect(Filter{Limit: 2, Desc: true}))
+	require.Equal(t, 4, len(Paginate(events, Filter{}, nil).Events))
+}
+
+func TestFilterMatch(t *testing.T) {
+	event := Event{UserID: 1, Title: "Планёрка", Description: "Weekly SYNC"}
+	require.True(t, Filter{}.Match(event))
+	require.True(t, Filter{UserID: 1, Search: "планёр"}.Match(event))
+	require.True(t, Filter{Search: "sync"}.Match(event))
+	require.False(t, Filter{UserID: 2}.Match(event))
+	require.False(t, Filter{Search: "обед"}.Match(event))
+}
@@ -9,22 +9,47 @@ import (
 type Storage interface {
 	Base
 	Events
+	Settings
 }
 
 type Base interface {
 	Connect(ctx context.Context, connect string) error
 	Close(ctx context.Context) error
+	// Ping проверяет, что хранилище доступно.
+	Ping(ctx context.Context) error
+}
+
+// Settings - настройки пользователей.
+type Settings interface {
+	// GetSettings возвращает настройки пользователя, нулевые, если они не сохранялись.
+	GetSettings(ctx context.Context, userID int) (UserSettings, error)
+	SaveSettings(ctx context.Context, userID int, settings UserSettings) error
 }
 
 type Events interface {
 	Create(ctx context.Context, event Event) (int, error)
 	Update(ctx context.Context, id int, change Event) error
+	// CreateIfFree и UpdateIfFree атомарно проверяют, что время события не занято
+	// другими событиями пользователя (см. IsBusy), иначе возвращают ErrDateBusy.
+	CreateIfFree(ctx context.Context, event Event) (int, error)
+	UpdateIfFree(ctx context.Context, id int, change Event) error
+	// Delete удаляет событие, а если его нет - возвращает ErrNotExistsEvent.
 	Delete(ctx context.Context, id int) error
+	// DeleteAll удаляет все события и настройки пользователей.
 	DeleteAll(ctx context.Context) error
+	DeleteBefore(ctx context.Context, date time.Time) (int, error)
+	Get(ctx context.Context, id int) (Event, error)
 	ListAll(ctx context.Context) ([]Event, error)
-	ListDay(ctx context.Context, date time.Time) ([]Event, error)
-	ListWeek(ctx context.Context, date time.Time) ([]Event, error)
-	ListMonth(ctx context.Context, date time.Time) ([]Event, error)
+	// ListDay, ListWeek и ListMonth возвращают события пользователя и их повторения, пересекающиеся
+	// с днем, неделей или месяцем, содержащими date (см. DayRange, WeekRange и MonthRange).
+	ListDay(ctx context.Context, userID int, date time.Time) ([]Event, error)
+	ListWeek(ctx context.Context, userID int, date time.Time) ([]Event, error)
+	ListMonth(ctx context.Context, userID int, date time.Time) ([]Event, error)
+	ListRange(ctx context.Context, from, to time.Time, filter Filter) (Page, error)
+	ListToNotify(ctx context.Context, from, to time.Time) ([]Event, error)
+	ListBefore(ctx context.Context, date time.Time) ([]Event, error)
+	// IsTimeBusy проверяет, пересекается ли [start, stop) с событиями пользователя, кроме excludeID
+	// и событий на весь день.
 	IsTimeBusy(ctx context.Context, userID int, start, stop time.Time, excludeID int) (bool, error)
 }
 
@@ -36,6 +61,21 @@ type Event struct {
 	Description  string
 	UserID       int
 	Notification *time.Duration
+	Recurrence   *Recurrence
+	// событие на весь день, Start и Stop - даты (см. AllDayDates), время оно не занимает
+	AllDay bool
+	// увеличивается при каждом изменении, в Update - ожидаемая текущая версия
+	Version int
 }
 
+type UserSettings struct {
+	// часовой пояс IANA, в котором считаются границы дня, недели и месяца
+	// в ListDay, ListWeek и ListMonth, если запрос не указал свой; пустой - не задан
+	TimeZone string
+}
+
+// версия созданного события
+const InitialVersion = 1
+
 var ErrNotExistsEvent = errors.New("no such event")
+var ErrConflict = errors.New("the event was changed by another request")
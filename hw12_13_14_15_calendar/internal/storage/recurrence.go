@@ -0,0 +1,414 @@
+package storage
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+type Frequency string
+
+const (
+	Daily   Frequency = "DAILY"
+	Weekly  Frequency = "WEEKLY"
+	Monthly Frequency = "MONTHLY"
+	Yearly  Frequency = "YEARLY"
+)
+
+// Recurrence - правило повторения события в духе RRULE из RFC 5545.
+// Повторения не начинаются раньше самого события, Start и Stop события задают их время и длительность.
+type Recurrence struct {
+	Freq Frequency
+	// каждый Interval-й день/неделю/месяц/год, 0 равносилен 1
+	Interval int
+	// дни недели, в которые повторяется событие
+	ByDay []time.Weekday
+	// общее число повторений, 0 - без ограничения
+	Count int
+	// время, позже которого повторений нет, нулевое - без ограничения
+	Until time.Time
+	// начала исключенных повторений (EXDATE)
+	Exceptions []time.Time
+	// часовой пояс, в котором раскрывается правило: имя IANA или смещение вида "+03:00",
+	// пустой - UTC. Дни недели и время повторений считаются в нем, а не в поясе,
+	// в котором хранилище вернуло начало события.
+	TimeZone string
+}
+
+var ErrInvalidRecurrence = errors.New("invalid recurrence rule")
+
+const untilFormat = "20060102T150405Z"
+
+var weekdays = map[string]time.Weekday{
+	"MO": time.Monday,
+	"TU": time.Tuesday,
+	"WE": time.Wednesday,
+	"TH": time.Thursday,
+	"FR": time.Friday,
+	"SA": time.Saturday,
+	"SU": time.Sunday,
+}
+
+var weekdayNames = map[time.Weekday]string{
+	time.Monday:    "MO",
+	time.Tuesday:   "TU",
+	time.Wednesday: "WE",
+	time.Thursday:  "TH",
+	time.Friday:    "FR",
+	time.Saturday:  "SA",
+	time.Sunday:    "SU",
+}
+
+// NewRecurrence разбирает RRULE (без префикса "RRULE:"). Для пустого правила возвращает nil.
+func NewRecurrence(rule string, exceptions []time.Time) (*Recurrence, error) {
+	if rule == "" {
+		return nil, nil
+	}
+
+	r := Recurrence{Exceptions: exceptions}
+	for _, part := range strings.Split(rule, ";") {
+		kv := strings.SplitN(part, "=", 2)
+		if len(kv) != 2 {
+			return nil, fmt.Errorf("%w: %q", ErrInvalidRecurrence, part)
+		}
+		key, value := strings.ToUpper(kv[0]), strings.ToUpper(kv[1])
+		var err error
+		switch key {
+		case "FREQ":
+			r.Freq = Frequency(value)
+		case "INTERVAL":
+			r.Interval, err = strconv.Atoi(value)
+		case "COUNT":
+			r.Count, err = strconv.Atoi(value)
+		case "UNTIL":
+			r.Until, err = parseUntil(value)
+		case "BYDAY":
+			r.ByDay, err = parseByDay(value)
+		case "WKST":
+			if value != "MO" {
+				err = errors.New("only WKST=MO is supported")
+			}
+		default:
+			err = errors.New("unsupported part")
+		}
+		if err != nil {
+			return nil, fmt.Errorf("%w: %q: %s", ErrInvalidRecurrence, part, err)
+		}
+	}
+
+	if err := r.Validate(); err != nil {
+		return nil, err
+	}
+	return &r, nil
+}
+
+func parseUntil(value string) (time.Time, error) {
+	if len(value) == len("20060102") {
+		day, err := time.Parse("20060102", value)
+		if err != nil {
+			return time.Time{}, err
+		}
+		// дата без времени включает весь день
+		return day.AddDate(0, 0, 1).Add(-time.Nanosecond), nil
+	}
+	return time.Parse(untilFormat, value)
+}
+
+func parseByDay(value string) ([]time.Weekday, error) {
+	var result []time.Weekday
+	for _, name := range strings.Split(value, ",") {
+		day, ok := weekdays[name]
+		if !ok {
+			return nil, fmt.Errorf("unsupported day %q", name)
+		}
+		result = append(result, day)
+	}
+	return result, nil
+}
+
+func (r Recurrence) Validate() error {
+	switch r.Freq {
+	case Daily, Weekly, Monthly, Yearly:
+	default:
+		return fmt.Errorf("%w: unknown frequency %q", ErrInvalidRecurrence, r.Freq)
+	}
+	if r.Interval < 0 {
+		return fmt.Errorf("%w: negative interval", ErrInvalidRecurrence)
+	}
+	if r.Count < 0 {
+		return fmt.Errorf("%w: negative count", ErrInvalidRecurrence)
+	}
+	if r.Count > 0 && !r.Until.IsZero() {
+		return fmt.Errorf("%w: both count and until", ErrInvalidRecurrence)
+	}
+	if _, err := r.Location(); err != nil {
+		return err
+	}
+	return nil
+}
+
+// Location возвращает часовой пояс правила. Пояс сервера "Local" не принимается:
+// повторения не должны зависеть от того, где запущен сервис.
+func (r Recurrence) Location() (*time.Location, error) {
+	switch {
+	case r.TimeZone == "":
+		return time.UTC, nil
+	case r.TimeZone == "Local":
+	case r.TimeZone[0] == '+' || r.TimeZone[0] == '-':
+		if t, err := time.Parse("-07:00", r.TimeZone); err == nil {
+			_, offset := t.Zone()
+			return time.FixedZone(r.TimeZone, offset), nil
+		}
+	default:
+		if location, err := time.LoadLocation(r.TimeZone); err == nil {
+			return location, nil
+		}
+	}
+	return nil, fmt.Errorf("%w: time zone %q", ErrInvalidRecurrence, r.TimeZone)
+}
+
+// RRule возвращает правило в формате RRULE без исключений.
+func (r Recurrence) RRule() string {
+	parts := []string{"FREQ=" + string(r.Freq)}
+	if r.Interval > 1 {
+		parts = append(parts, "INTERVAL="+strconv.Itoa(r.Interval))
+	}
+	if len(r.ByDay) > 0 {
+		days := make([]string, 0, len(r.ByDay))
+		for _, day := range r.ByDay {
+			days = append(days, weekdayNames[day])
+		}
+		parts = append(parts, "BYDAY="+strings.Join(days, ","))
+	}
+	if r.Count > 0 {
+		parts = append(parts, "COUNT="+strconv.Itoa(r.Count))
+	}
+	if !r.Until.IsZero() {
+		parts = append(parts, "UNTIL="+r.Until.UTC().Format(untilFormat))
+	}
+	return strings.Join(parts, ";")
+}
+
+// Occurrences возвращает повторения события, пересекающиеся с интервалом [from, to).
+// Для неповторяющегося события это само событие, для события на весь день сравниваются даты (см. AllDayRange).
+func Occurrences(event Event, from, to time.Time) []Event {
+	from, to = window(event, from, to)
+	var result []Event
+	eachOccurrence(event, to, func(occurrence Event) bool {
+		if occurrence.Stop.After(from) {
+			result = append(result, occurrence)
+		}
+		return true
+	})
+	return result
+}
+
+// Overlaps проверяет, пересекается ли хотя бы одно повторение события с интервалом [from, to).
+// Для события на весь день сравниваются даты.
+func Overlaps(event Event, from, to time.Time) bool {
+	from, to = window(event, from, to)
+	result := false
+	eachOccurrence(event, to, func(occurrence Event) bool {
+		result = occurrence.Stop.After(from)
+		return !result
+	})
+	return result
+}
+
+// StartsIn возвращает повторения события, начинающиеся в интервале [from, to).
+func StartsIn(event Event, from, to time.Time) []Event {
+	var result []Event
+	eachOccurrence(event, to, func(occurrence Event) bool {
+		if !occurrence.Start.Before(from) {
+			result = append(result, occurrence)
+		}
+		return true
+	})
+	return result
+}
+
+// LastStop возвращает окончание последнего повторения события.
+// Для бесконечно повторяющегося события возвращает false.
+func LastStop(event Event) (time.Time, bool) {
+	r := event.Recurrence
+	if r == nil {
+		return event.Stop, true
+	}
+	if r.Count == 0 && r.Until.IsZero() {
+		return time.Time{}, false
+	}
+
+	last := event.Stop
+	eachOccurrence(event, time.Time{}, func(occurrence Event) bool {
+		last = occurrence.Stop
+		return true
+	})
+	return last, true
+}
+
+// eachOccurrence перебирает повторения события в хронологическом порядке,
+// начинающиеся раньше to (нулевое to - без ограничения, только для конечных правил).
+func eachOccurrence(event Event, to time.Time, fn func(occurrence Event) bool) {
+	r := event.Recurrence
+	if r == nil {
+		if to.IsZero() || event.Start.Before(to) {
+			fn(event)
+		}
+		return
+	}
+
+	duration := event.Stop.Sub(event.Start)
+	interval := r.Interval
+	if interval < 1 {
+		interval = 1
+	}
+
+	location := event.Start.Location()
+	count := 0
+	for period := 0; ; period++ {
+		candidates, ok := periodStarts(event.Start, r, period*interval)
+		if !ok {
+			return
+		}
+		for _, start := range candidates {
+			if start.Before(event.Start) {
+				continue
+			}
+			if !to.IsZero() && !start.Before(to) {
+				return
+			}
+			if !r.Until.IsZero() && start.After(r.Until) {
+				return
+			}
+			count++
+			if !isException(r, start) {
+				occurrence := event
+				occurrence.Start = start.In(location)
+				occurrence.Stop = occurrence.Start.Add(duration)
+				if !fn(occurrence) {
+					return
+				}
+			}
+			if r.Count > 0 && count >= r.Count {
+				return
+			}
+		}
+	}
+}
+
+// максимальное число периодов, перебираемых при раскрытии правила
+const maxPeriods = 100000
+
+// periodStarts возвращает начала кандидатов в повторения для периода со смещением offset
+// (в днях, неделях, месяцах или годах от начала события). Даты и время считаются в поясе правила.
+func periodStarts(base time.Time, r *Recurrence, offset int) ([]time.Time, bool) {
+	if offset > maxPeriods {
+		return nil, false
+	}
+
+	loc, err := r.Location()
+	if err != nil {
+		loc = time.UTC
+	}
+	base = base.In(loc)
+	year, month, day := base.Date()
+	hour, minute, sec := base.Clock()
+	at := func(y int, m time.Month, d int) time.Time {
+		return time.Date(y, m, d, hour, minute, sec, base.Nanosecond(), loc)
+	}
+
+	var first, last time.Time
+	switch r.Freq {
+	case Daily:
+		first = at(year, month, day+offset)
+		last = first
+	case Weekly:
+		if len(r.ByDay) == 0 {
+			first = at(year, month, day+7*offset)
+			last = first
+		} else {
+			// неделя начинается с понедельника
+			monday := day - (int(base.Weekday())+6)%7
+			first = at(year, month, monday+7*offset)
+			last = at(year, month, monday+7*offset+6)
+		}
+	case Monthly:
+		if len(r.ByDay) == 0 {
+			first = at(year, month+time.Month(offset), day)
+			if first.Day() != day {
+				// в месяце нет такого числа
+				return nil, true
+			}
+			last = first
+		} else {
+			first = at(year, month+time.Month(offset), 1)
+			last = at(year, month+time.Month(offset)+1, 0)
+		}
+	case Yearly:
+		if len(r.ByDay) == 0 {
+			first = at(year+offset, month, day)
+			if first.Day() != day {
+				return nil, true
+			}
+			last = first
+		} else {
+			first = at(year+offset, time.January, 1)
+			last = at(year+offset, time.December, 31)
+		}
+	default:
+		return nil, false
+	}
+
+	var result []time.Time
+	for date := first; !date.After(last); date = at(date.Year(), date.Month(), date.Day()+1) {
+		if len(r.ByDay) == 0 || hasWeekday(r.ByDay, date.Weekday()) {
+			result = append(result, date)
+		}
+	}
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].Before(result[j])
+	})
+	return result, true
+}
+
+func hasWeekday(days []time.Weekday, day time.Weekday) bool {
+	for _, d := range days {
+		if d == day {
+			return true
+		}
+	}
+	return false
+}
+
+func isException(r *Recurrence, start time.Time) bool {
+	for _, exception := range r.Exceptions {
+		if exception.Equal(start) {
+			return true
+		}
+	}
+	return false
+}
+
+// DayRange, WeekRange и MonthRange возвращают границы [from, to) дня, недели (с понедельника)
+// и месяца, содержащих date, в часовом поясе date.
+func DayRange(date time.Time) (time.Time, time.Time) {
+	year, month, day := date.Date()
+	from := time.Date(year, month, day, 0, 0, 0, 0, date.Location())
+	return from, from.AddDate(0, 0, 1)
+}
+
+func WeekRange(date time.Time) (time.Time, time.Time) {
+	year, month, day := date.Date()
+	monday := day - (int(date.Weekday())+6)%7
+	from := time.Date(year, month, monday, 0, 0, 0, 0, date.Location())
+	return from, from.AddDate(0, 0, 7)
+}
+
+func MonthRange(date time.Time) (time.Time, time.Time) {
+	year, month, _ := date.Date()
+	from := time.Date(year, month, 1, 0, 0, 0, 0, date.Location())
+	return from, from.AddDate(0, 1, 0)
+}
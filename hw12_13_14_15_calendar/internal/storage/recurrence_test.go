@@ -0,0 +1,180 @@
+package storage
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewRecurrence(t *testing.T) {
+	tests := []struct {
+		rule   string
+		result string
+	}{
+		{"FREQ=DAILY", "FREQ=DAILY"},
+		{"freq=weekly;interval=2;byday=MO,WE", "FREQ=WEEKLY;INTERVAL=2;BYDAY=MO,WE"},
+		{"FREQ=MONTHLY;INTERVAL=1;COUNT=3", "FREQ=MONTHLY;COUNT=3"},
+		{"FREQ=YEARLY;UNTIL=20220101T100000Z;WKST=MO", "FREQ=YEARLY;UNTIL=20220101T100000Z"},
+		{"FREQ=DAILY;UNTIL=20220101", "FREQ=DAILY;UNTIL=20220101T235959Z"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.rule, func(t *testing.T) {
+			r, err := NewRecurrence(tt.rule, nil)
+			require.NoError(t, err)
+			require.Equal(t, tt.result, r.RRule())
+		})
+	}
+
+	r, err := NewRecurrence("", nil)
+	require.NoError(t, err)
+	require.Nil(t, r)
+}
+
+func TestNewRecurrenceFail(t *testing.T) {
+	rules := []string{
+		"DAILY",
+		"FREQ=HOURLY",
+		"FREQ=DAILY;INTERVAL=x",
+		"FREQ=DAILY;INTERVAL=-1",
+		"FREQ=WEEKLY;BYDAY=1MO",
+		"FREQ=DAILY;COUNT=2;UNTIL=20220101",
+		"FREQ=DAILY;BYHOUR=10",
+		"FREQ=WEEKLY;WKST=SU",
+	}
+	for _, rule := range rules {
+		t.Run(rule, func(t *testing.T) {
+			_, err := NewRecurrence(rule, nil)
+			require.True(t, errors.Is(err, ErrInvalidRecurrence))
+		})
+	}
+}
+
+func newEvent(start time.Time, rule string, exceptions ...time.Time) Event {
+	r, err := NewRecurrence(rule, exceptions)
+	if err != nil {
+		panic(err)
+	}
+	return Event{
+		ID:         1,
+		Title:      "event",
+		Start:      start,
+		Stop:       start.Add(time.Hour),
+		Recurrence: r,
+	}
+}
+
+func starts(events []Event) []string {
+	result := make([]string, 0, len(events))
+	for _, event := range events {
+		result = append(result, event.Start.Format("2006-01-02 15:04 Mon"))
+	}
+	return result
+}
+
+func TestStartsIn(t *testing.T) {
+	// понедельник
+	start := time.Date(2021, 2, 1, 10, 0, 0, 0, time.UTC)
+	from := start.AddDate(0, 0, -3)
+	to := start.AddDate(0, 2, 0)
+
+	tests := []struct {
+		name   string
+		event  Event
+		result []string
+	}{
+		{
+			"single",
+			newEvent(start, ""),
+			[]string{"2021-02-01 10:00 Mon"},
+		},
+		{
+			"daily with interval and count",
+			newEvent(start, "FREQ=DAILY;INTERVAL=3;COUNT=4"),
+			[]string{"2021-02-01 10:00 Mon", "2021-02-04 10:00 Thu", "2021-02-07 10:00 Sun", "2021-02-10 10:00 Wed"},
+		},
+		{
+			"daily by working days",
+			newEvent(start.AddDate(0, 0, 4), "FREQ=DAILY;BYDAY=MO,TU,WE,TH,FR;COUNT=3"),
+			[]string{"2021-02-05 10:00 Fri", "2021-02-08 10:00 Mon", "2021-02-09 10:00 Tue"},
+		},
+		{
+			"weekly by days until",
+			newEvent(start.AddDate(0, 0, 2), "FREQ=WEEKLY;BYDAY=FR,MO;UNTIL=20210212T100000Z"),
+			// само событие не попадает в BYDAY
+			[]string{"2021-02-05 10:00 Fri", "2021-02-08 10:00 Mon", "2021-02-12 10:00 Fri"},
+		},
+		{
+			"biweekly with exception",
+			newEvent(start, "FREQ=WEEKLY;INTERVAL=2;COUNT=4", start.AddDate(0, 0, 14)),
+			[]string{"2021-02-01 10:00 Mon", "2021-03-01 10:00 Mon", "2021-03-15 10:00 Mon"},
+		},
+		{
+			"monthly skips short months",
+			newEvent(time.Date(2021, 1, 31, 10, 0, 0, 0, time.UTC), "FREQ=MONTHLY;COUNT=3"),
+			[]string{"2021-01-31 10:00 Sun", "2021-03-31 10:00 Wed"},
+		},
+		{
+			"monthly by day",
+			newEvent(time.Date(2021, 2, 22, 10, 0, 0, 0, time.UTC), "FREQ=MONTHLY;BYDAY=MO;COUNT=3"),
+			[]string{"2021-02-22 10:00 Mon", "2021-03-01 10:00 Mon", "2021-03-08 10:00 Mon"},
+		},
+		{
+			"yearly",
+			newEvent(time.Date(2020, 3, 15, 10, 0, 0, 0, time.UTC), "FREQ=YEARLY"),
+			[]string{"2021-03-15 10:00 Mon"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			require.Equal(t, tt.result, starts(StartsIn(tt.event, from, to)))
+		})
+	}
+}
+
+func TestOverlaps(t *testing.T) {
+	start := time.Date(2021, 2, 1, 10, 0, 0, 0, time.UTC)
+	event := newEvent(start, "FREQ=WEEKLY;COUNT=3")
+
+	require.True(t, Overlaps(event, start.AddDate(0, 0, 7).Add(30*time.Minute), start.AddDate(0, 0, 7).Add(2*time.Hour)))
+	require.True(t, Overlaps(event, start.AddDate(0, 0, 14).Add(-time.Hour), start.AddDate(0, 0, 14).Add(time.Minute)))
+	require.False(t, Overlaps(event, start.AddDate(0, 0, 7).Add(time.Hour), start.AddDate(0, 0, 7).Add(2*time.Hour)))
+	require.False(t, Overlaps(event, start.AddDate(0, 0, 21), start.AddDate(0, 0, 21).Add(time.Hour)))
+}
+
+func TestLastStop(t *testing.T) {
+	start := time.Date(2021, 2, 1, 10, 0, 0, 0, time.UTC)
+
+	stop, ok := LastStop(newEvent(start, ""))
+	require.True(t, ok)
+	require.Equal(t, start.Add(time.Hour), stop)
+
+	stop, ok = LastStop(newEvent(start, "FREQ=DAILY;COUNT=3"))
+	require.True(t, ok)
+	require.Equal(t, start.AddDate(0, 0, 2).Add(time.Hour), stop)
+
+	stop, ok = LastStop(newEvent(start, "FREQ=WEEKLY;UNTIL=20210301"))
+	require.True(t, ok)
+	require.Equal(t, start.AddDate(0, 0, 28).Add(time.Hour), stop)
+
+	_, ok = LastStop(newEvent(start, "FREQ=WEEKLY"))
+	require.False(t, ok)
+}
+
+func TestRanges(t *testing.T) {
+	loc := time.FixedZone("UTC+3", 3*60*60)
+	date := time.Date(2021, 2, 3, 1, 30, 0, 0, loc)
+
+	from, to := DayRange(date)
+	require.Equal(t, time.Date(2021, 2, 3, 0, 0, 0, 0, loc), from)
+	require.Equal(t, time.Date(2021, 2, 4, 0, 0, 0, 0, loc), to)
+
+	from, to = WeekRange(date)
+	require.Equal(t, time.Date(2021, 2, 1, 0, 0, 0, 0, loc), from)
+	require.Equal(t, time.Date(2021, 2, 8, 0, 0, 0, 0, loc), to)
+
+	from, to = MonthRange(date)
+	require.Equal(t, time.Date(2021, 2, 1, 0, 0, 0, 0, loc), from)
+	require.Equal(t, time.Date(2021, 3, 1, 0, 0, 0, 0, loc), to)
+}
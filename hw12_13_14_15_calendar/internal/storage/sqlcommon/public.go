@@ -0,0 +1,14 @@
+// Package sqlcommon содержит общий код SQL хранилищ sqlstorage и sqlitestorage.
+package sqlcommon
+
+import (
+	"context"
+	"database/sql"
+)
+
+// Querier - общая часть *sql.DB, *sql.Tx и *sql.Conn.
+type Querier interface {
+	ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error)
+	QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error)
+	QueryRowContext(ctx context.Context, query string, args ...interface{}) *sql.Row
+}
@@ -0,0 +1,59 @@
+package sqlcommon
+
+import (
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/anfilat/otus-go/hw12_13_14_15_calendar/internal/storage"
+)
+
+// RecurrenceArgs возвращает значения колонок rrule, exdate, time_zone и last_stop.
+// Исключения хранятся через запятую в RFC 3339 с точностью до микросекунд, как и start
+// в обеих базах, иначе они не совпадут с повторениями, прочитанными из базы.
+func RecurrenceArgs(event storage.Event) (rrule, exdate, timeZone sql.NullString, lastStop sql.NullTime) {
+	if stop, ok := storage.LastStop(event); ok {
+		lastStop = sql.NullTime{Time: stop, Valid: true}
+	}
+	r := event.Recurrence
+	if r == nil {
+		return
+	}
+
+	rrule = sql.NullString{String: r.RRule(), Valid: true}
+	timeZone = sql.NullString{String: r.TimeZone, Valid: true}
+	if len(r.Exceptions) > 0 {
+		dates := make([]string, 0, len(r.Exceptions))
+		for _, date := range r.Exceptions {
+			dates = append(dates, date.Truncate(time.Microsecond).UTC().Format(time.RFC3339Nano))
+		}
+		exdate = sql.NullString{String: strings.Join(dates, ","), Valid: true}
+	}
+	return
+}
+
+// ScanRecurrence собирает правило повторения из колонок rrule, exdate и time_zone.
+// Для неповторяющегося события возвращает nil.
+func ScanRecurrence(rrule, exdate, timeZone sql.NullString) (*storage.Recurrence, error) {
+	if !rrule.Valid {
+		return nil, nil
+	}
+
+	var exceptions []time.Time
+	if exdate.Valid && exdate.String != "" {
+		for _, value := range strings.Split(exdate.String, ",") {
+			date, err := time.Parse(time.RFC3339Nano, value)
+			if err != nil {
+				return nil, fmt.Errorf("exdate: %w", err)
+			}
+			exceptions = append(exceptions, date)
+		}
+	}
+	r, err := storage.NewRecurrence(rrule.String, exceptions)
+	if err != nil {
+		return nil, err
+	}
+	r.TimeZone = timeZone.String
+	return r, nil
+}
@@ -0,0 +1,44 @@
+package sqlcommon
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"github.com/anfilat/otus-go/hw12_13_14_15_calendar/internal/storage"
+)
+
+// Запросы настроек одинаковы для PostgreSQL и SQLite: драйвер SQLite тоже понимает параметры "$n".
+
+// GetSettings возвращает настройки пользователя userID, пустые, если они не сохранялись.
+func GetSettings(ctx context.Context, q Querier, userID int) (storage.UserSettings, error) {
+	query := `
+		SELECT time_zone
+		FROM user_settings
+		WHERE user_id = $1
+	`
+	var result storage.UserSettings
+	err := q.QueryRowContext(ctx, query, userID).Scan(&result.TimeZone)
+	if errors.Is(err, sql.ErrNoRows) {
+		return storage.UserSettings{}, nil
+	}
+	if err != nil {
+		return storage.UserSettings{}, fmt.Errorf("db query: %w", err)
+	}
+	return result, nil
+}
+
+// SaveSettings сохраняет настройки пользователя userID, заменяя прежние.
+func SaveSettings(ctx context.Context, q Querier, userID int, settings storage.UserSettings) error {
+	query := `
+		INSERT INTO user_settings (user_id, time_zone)
+		VALUES($1, $2)
+		ON CONFLICT (user_id) DO UPDATE SET time_zone = excluded.time_zone
+	`
+	_, err := q.ExecContext(ctx, query, userID, settings.TimeZone)
+	if err != nil {
+		return fmt.Errorf("db exec: %w", err)
+	}
+	return nil
+}
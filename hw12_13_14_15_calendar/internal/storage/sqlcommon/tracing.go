@@ -0,0 +1,56 @@
+package sqlcommon
+
+import (
+	"context"
+	"database/sql"
+	"strings"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/semconv"
+	"go.opentelemetry.io/otel/trace"
+
+	"github.com/anfilat/otus-go/hw12_13_14_15_calendar/internal/tracing"
+)
+
+// tracedQuerier создает спан на каждый SQL запрос.
+type tracedQuerier struct {
+	q      Querier
+	system attribute.KeyValue
+}
+
+// Traced добавляет трассировку запросов к q. system - атрибут db.system для спанов,
+// например semconv.DBSystemPostgres.
+func Traced(q Querier, system attribute.KeyValue) Querier {
+	return tracedQuerier{q: q, system: system}
+}
+
+func (t tracedQuerier) ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error) {
+	ctx, span := t.startQuery(ctx, "sql.exec", query)
+	result, err := t.q.ExecContext(ctx, query, args...)
+	tracing.End(span, err)
+	return result, err
+}
+
+func (t tracedQuerier) QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error) {
+	ctx, span := t.startQuery(ctx, "sql.query", query)
+	rows, err := t.q.QueryContext(ctx, query, args...)
+	tracing.End(span, err)
+	return rows, err
+}
+
+func (t tracedQuerier) QueryRowContext(ctx context.Context, query string, args ...interface{}) *sql.Row {
+	ctx, span := t.startQuery(ctx, "sql.query", query)
+	row := t.q.QueryRowContext(ctx, query, args...)
+	tracing.End(span, row.Err())
+	return row
+}
+
+func (t tracedQuerier) startQuery(ctx context.Context, name, query string) (context.Context, trace.Span) {
+	return tracing.Start(ctx, name,
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(
+			t.system,
+			semconv.DBStatementKey.String(strings.Join(strings.Fields(query), " ")),
+		),
+	)
+}
@@ -0,0 +1,69 @@
+package sqlitestorage
+
+import (
+	"database/sql"
+	"strings"
+	"time"
+
+	"github.com/anfilat/otus-go/hw12_13_14_15_calendar/internal/storage"
+	"github.com/anfilat/otus-go/hw12_13_14_15_calendar/internal/storage/sqlcommon"
+)
+
+// SQLite не имеет типа для времени, оно хранится в микросекундах от начала эпохи.
+// В таком виде его можно сравнивать в запросах независимо от часового пояса.
+func toMicro(t time.Time) int64 {
+	return t.Unix()*1e6 + int64(t.Nanosecond())/1e3
+}
+
+func fromMicro(value int64) time.Time {
+	sec, usec := value/1e6, value%1e6
+	if usec < 0 {
+		sec--
+		usec += 1e6
+	}
+	return time.Unix(sec, usec*1e3)
+}
+
+// recurrenceArgs - sqlcommon.RecurrenceArgs с last_stop в микросекундах.
+func recurrenceArgs(event storage.Event) (rrule, exdate, timeZone sql.NullString, lastStop sql.NullInt64) {
+	rrule, exdate, timeZone, stop := sqlcommon.RecurrenceArgs(event)
+	if stop.Valid {
+		lastStop = sql.NullInt64{Int64: toMicro(stop.Time), Valid: true}
+	}
+	return
+}
+
+// conditions собирает условие WHERE с параметрами "?".
+type conditions struct {
+	parts []string
+	args  []interface{}
+}
+
+func (c *conditions) add(condition string, values ...interface{}) {
+	c.args = append(c.args, values...)
+	c.parts = append(c.parts, condition)
+}
+
+// addOverlap добавляет условие пересечения неповторяющихся событий с интервалом [from, to),
+// для событий на весь день - с его датами (см. storage.AllDayRange).
+func (c *conditions) addOverlap(from, to time.Time) {
+	dayFrom, dayTo := storage.AllDayRange(from, to)
+	c.add("rrule IS NULL AND (NOT all_day AND start < ? AND stop > ? OR all_day AND start < ? AND stop > ?)",
+		toMicro(to), toMicro(from), toMicro(dayTo), toMicro(dayFrom))
+}
+
+// addRecurringOverlap добавляет условие на повторяющиеся события, повторения которых могут
+// пересечься с интервалом [from, to). Сами повторения отбирает storage.Occurrences.
+func (c *conditions) addRecurringOverlap(from, to time.Time) {
+	dayFrom, dayTo := storage.AllDayRange(from, to)
+	c.add(`rrule IS NOT NULL AND (NOT all_day AND start < ? AND (last_stop IS NULL OR last_stop > ?)
+		OR all_day AND start < ? AND (last_stop IS NULL OR last_stop > ?))`,
+		toMicro(to), toMicro(from), toMicro(dayTo), toMicro(dayFrom))
+}
+
+func (c *conditions) String() string {
+	if len(c.parts) == 0 {
+		return "TRUE"
+	}
+	return strings.Join(c.parts, " AND ")
+}
@@ -0,0 +1,21 @@
+package sqlitestorage
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"github.com/stretchr/testify/suite"
+
+	"github.com/anfilat/otus-go/hw12_13_14_15_calendar/internal/storage"
+	"github.com/anfilat/otus-go/hw12_13_14_15_calendar/internal/storage/storagetest"
+)
+
+func TestConformance(t *testing.T) {
+	suite.Run(t, &storagetest.Suite{NewStorage: func(t *testing.T) storage.Storage {
+		db := New()
+		require.NoError(t, db.Connect(context.Background(), filepath.Join(t.TempDir(), "calendar.db")))
+		return db
+	}})
+}
@@ -0,0 +1,111 @@
+package sqlitestorage
+
+import (
+	"context"
+	"database/sql/driver"
+	"fmt"
+	"time"
+
+	"modernc.org/sqlite"
+)
+
+// pragmas выполняются на каждом новом соединении: PRAGMA действуют только
+// на свое соединение, а пул может переоткрыть соединение после ошибки.
+var pragmas = []string{
+	`PRAGMA journal_mode = WAL`,
+	// база может быть общей с процессом планировщика: пока он пишет, запросы ждут
+	// освобождения блокировки до 5 секунд, а не сразу получают SQLITE_BUSY
+	`PRAGMA busy_timeout = 5000`,
+}
+
+// connector открывает соединения с файлом базы name и настраивает их.
+type connector struct {
+	driver sqlite.Driver
+	name   string
+}
+
+func (c *connector) Connect(ctx context.Context) (driver.Conn, error) {
+	opened, err := c.driver.Open(c.name)
+	if err != nil {
+		return nil, err
+	}
+	sc, ok := opened.(sqliteConn)
+	if !ok {
+		opened.Close()
+		return nil, fmt.Errorf("sqlite connection does not support contexts")
+	}
+	result := &conn{sc}
+	for _, pragma := range pragmas {
+		if _, err := result.ExecContext(ctx, pragma, nil); err != nil {
+			result.Close()
+			return nil, fmt.Errorf("db exec: %w", err)
+		}
+	}
+	return result, nil
+}
+
+func (c *connector) Driver() driver.Driver {
+	return &c.driver
+}
+
+// sqliteConn - методы соединения драйвера, которые использует conn.
+type sqliteConn interface {
+	driver.Conn
+	driver.ExecerContext
+	driver.QueryerContext
+	driver.ConnBeginTx
+	driver.Pinger
+}
+
+// conn передает драйверу контексты без отмены, а отмену проверяет сам перед запросом.
+// Драйвер следит за отменой контекста в горутине, которая может сработать уже после
+// запроса: тогда она прерывает следующий, чужой запрос соединения, а если соединение
+// закрыто - роняет процесс. Начатый запрос поэтому не прерывается, но они короткие.
+type conn struct {
+	sqliteConn
+}
+
+func (c *conn) ExecContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Result, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	return c.sqliteConn.ExecContext(detached{ctx}, query, args)
+}
+
+func (c *conn) QueryContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Rows, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	return c.sqliteConn.QueryContext(detached{ctx}, query, args)
+}
+
+func (c *conn) BeginTx(ctx context.Context, opts driver.TxOptions) (driver.Tx, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	return c.sqliteConn.BeginTx(detached{ctx}, opts)
+}
+
+func (c *conn) Ping(ctx context.Context) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	return c.sqliteConn.Ping(detached{ctx})
+}
+
+// detached - контекст со значениями ctx, но без его отмены и срока.
+type detached struct {
+	context.Context
+}
+
+func (detached) Deadline() (time.Time, bool) {
+	return time.Time{}, false
+}
+
+func (detached) Done() <-chan struct{} {
+	return nil
+}
+
+func (detached) Err() error {
+	return nil
+}
@@ -0,0 +1,9 @@
+// Package sqlitestorage хранит события в файле SQLite для развертываний на одном узле.
+// При подключении применяются непримененные миграции migrations.SQLite.
+package sqlitestorage
+
+import "github.com/anfilat/otus-go/hw12_13_14_15_calendar/internal/storage"
+
+func New() storage.Storage {
+	return &store{}
+}
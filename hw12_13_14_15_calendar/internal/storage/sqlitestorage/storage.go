@@ -0,0 +1,553 @@
+package sqlitestorage
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"sort"
+	"strconv"
+	"time"
+
+	"go.opentelemetry.io/otel/semconv"
+
+	"github.com/anfilat/otus-go/hw12_13_14_15_calendar/internal/metrics"
+	"github.com/anfilat/otus-go/hw12_13_14_15_calendar/internal/migrator"
+	"github.com/anfilat/otus-go/hw12_13_14_15_calendar/internal/storage"
+	"github.com/anfilat/otus-go/hw12_13_14_15_calendar/internal/storage/sqlcommon"
+	"github.com/anfilat/otus-go/hw12_13_14_15_calendar/migrations"
+)
+
+type store struct {
+	db *sql.DB
+	// запросы вне транзакций, с трассировкой
+	q sqlcommon.Querier
+}
+
+// Connect открывает файл базы connect, создавая его при необходимости, и применяет миграции.
+func (s *store) Connect(ctx context.Context, connect string) error {
+	db := sql.OpenDB(&connector{name: connect})
+	// одно соединение: SQLite все равно пишет по одной транзакции за раз, а база ":memory:"
+	// не теряется при переподключении. Транзакции процесса идут по очереди, но с базой
+	// может работать и планировщик, от него защищают busy_timeout и BEGIN IMMEDIATE.
+	db.SetMaxOpenConns(1)
+	if err := db.PingContext(ctx); err != nil {
+		db.Close()
+		return fmt.Errorf("failed to connect to database: %w", err)
+	}
+	s.db = db
+	s.q = sqlcommon.Traced(db, semconv.DBSystemSqlite)
+	metrics.SetDB(db)
+
+	m, err := migrator.New(db, migrator.SQLite, migrations.SQLite)
+	if err != nil {
+		return err
+	}
+	if _, err := m.Up(ctx); err != nil {
+		return fmt.Errorf("failed to migrate database: %w", err)
+	}
+	return nil
+}
+
+func (s *store) Close(_ context.Context) error {
+	return s.db.Close()
+}
+
+func (s *store) Ping(ctx context.Context) error {
+	if err := s.db.PingContext(ctx); err != nil {
+		return fmt.Errorf("db ping: %w", err)
+	}
+	return nil
+}
+
+func (s *store) Create(ctx context.Context, event storage.Event) (int, error) {
+	return create(ctx, s.q, event)
+}
+
+func (s *store) CreateIfFree(ctx context.Context, event storage.Event) (id int, err error) {
+	err = s.inTx(ctx, func(q sqlcommon.Querier) error {
+		if err := checkBusy(ctx, q, event); err != nil {
+			return err
+		}
+		id, err = create(ctx, q, event)
+		return err
+	})
+	return
+}
+
+func create(ctx context.Context, q sqlcommon.Querier, event storage.Event) (int, error) {
+	rrule, exdate, timeZone, lastStop := recurrenceArgs(event)
+	var notification sql.NullInt64
+	if event.Notification != nil {
+		notification = sql.NullInt64{Int64: int64(*event.Notification), Valid: true}
+	}
+	query := `
+		INSERT INTO event (title, start, stop, description, user_id, rrule, exdate, time_zone, last_stop, notification,
+			all_day)
+		VALUES(?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+		RETURNING event_id
+	`
+	var id int
+	err := q.QueryRowContext(ctx, query, event.Title, toMicro(event.Start), toMicro(event.Stop),
+		event.Description, event.UserID, rrule, exdate, timeZone, lastStop, notification, event.AllDay).Scan(&id)
+	if err != nil {
+		return 0, fmt.Errorf("db exec: %w", err)
+	}
+	return id, nil
+}
+
+func (s *store) Update(ctx context.Context, id int, change storage.Event) error {
+	return update(ctx, s.q, id, change)
+}
+
+func (s *store) UpdateIfFree(ctx context.Context, id int, change storage.Event) error {
+	return s.inTx(ctx, func(q sqlcommon.Querier) error {
+		event, err := get(ctx, q, id)
+		if err != nil {
+			return err
+		}
+		change.ID = id
+		change.UserID = event.UserID
+		if err := checkBusy(ctx, q, change); err != nil {
+			return err
+		}
+		return update(ctx, q, id, change)
+	})
+}
+
+func update(ctx context.Context, q sqlcommon.Querier, id int, change storage.Event) error {
+	rrule, exdate, timeZone, lastStop := recurrenceArgs(change)
+	var notification sql.NullInt64
+	if change.Notification != nil {
+		notification = sql.NullInt64{Int64: int64(*change.Notification), Valid: true}
+	}
+	query := `
+		UPDATE event
+		SET title = ?,
+			start = ?,
+			stop = ?,
+			description = ?,
+			rrule = ?,
+			exdate = ?,
+			time_zone = ?,
+			last_stop = ?,
+			notification = ?,
+			all_day = ?,
+			version = version + 1
+		WHERE event_id = ? AND version = ?
+	`
+	result, err := q.ExecContext(ctx, query, change.Title, toMicro(change.Start), toMicro(change.Stop),
+		change.Description, rrule, exdate, timeZone, lastStop, notification, change.AllDay, id, change.Version)
+	if err != nil {
+		return fmt.Errorf("db exec: %w", err)
+	}
+	count, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("db rows affected: %w", err)
+	}
+	if count != 1 {
+		// событие удалено или изменено другим запросом
+		if _, err := get(ctx, q, id); err != nil {
+			return err
+		}
+		return storage.ErrConflict
+	}
+	return nil
+}
+
+func (s *store) Delete(ctx context.Context, id int) error {
+	query := `
+		DELETE FROM event
+		WHERE event_id = ?
+	`
+	result, err := s.q.ExecContext(ctx, query, id)
+	if err != nil {
+		return fmt.Errorf("db exec: %w", err)
+	}
+	count, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("db rows affected: %w", err)
+	}
+	if count == 0 {
+		return storage.ErrNotExistsEvent
+	}
+	return nil
+}
+
+func (s *store) DeleteAll(ctx context.Context) error {
+	// вместе со счетчиком event_id, как TRUNCATE ... RESTART IDENTITY в PostgreSQL
+	query := `
+		DELETE FROM event;
+		DELETE FROM sqlite_sequence WHERE name = 'event';
+		DELETE FROM user_settings;
+	`
+	_, err := s.q.ExecContext(ctx, query)
+	if err != nil {
+		return fmt.Errorf("db exec: %w", err)
+	}
+	return nil
+}
+
+func (s *store) DeleteBefore(ctx context.Context, date time.Time) (int, error) {
+	query := `
+		DELETE FROM event
+		WHERE last_stop < ?
+	`
+	result, err := s.q.ExecContext(ctx, query, toMicro(date))
+	if err != nil {
+		return 0, fmt.Errorf("db exec: %w", err)
+	}
+	count, err := result.RowsAffected()
+	if err != nil {
+		return 0, fmt.Errorf("db rows affected: %w", err)
+	}
+	return int(count), nil
+}
+
+func (s *store) Get(ctx context.Context, id int) (storage.Event, error) {
+	return get(ctx, s.q, id)
+}
+
+func get(ctx context.Context, q sqlcommon.Querier, id int) (storage.Event, error) {
+	query := `
+		SELECT event_id, title, start, stop, description, user_id, notification, rrule, exdate, time_zone, all_day, version
+		FROM event
+		WHERE event_id = ?
+	`
+	events, err := queryList(ctx, q, query, id)
+	if err != nil {
+		return storage.Event{}, err
+	}
+	if len(events) == 0 {
+		return storage.Event{}, storage.ErrNotExistsEvent
+	}
+	return events[0], nil
+}
+
+func (s *store) ListAll(ctx context.Context) ([]storage.Event, error) {
+	query := `
+		SELECT event_id, title, start, stop, description, user_id, notification, rrule, exdate, time_zone, all_day, version
+		FROM event
+		ORDER BY start
+	`
+	return s.queryList(ctx, query)
+}
+
+func (s *store) ListDay(ctx context.Context, userID int, date time.Time) ([]storage.Event, error) {
+	from, to := storage.DayRange(date)
+	return s.listOverlapping(ctx, userID, from, to)
+}
+
+func (s *store) ListWeek(ctx context.Context, userID int, date time.Time) ([]storage.Event, error) {
+	from, to := storage.WeekRange(date)
+	return s.listOverlapping(ctx, userID, from, to)
+}
+
+func (s *store) ListMonth(ctx context.Context, userID int, date time.Time) ([]storage.Event, error) {
+	from, to := storage.MonthRange(date)
+	return s.listOverlapping(ctx, userID, from, to)
+}
+
+func (s *store) ListRange(ctx context.Context, from, to time.Time, filter storage.Filter) (storage.Page, error) {
+	cursor, err := storage.DecodeCursor(filter.Cursor)
+	if err != nil {
+		return storage.Page{}, err
+	}
+
+	where := userCondition(filter)
+	where.addOverlap(from, to)
+	if cursor != nil {
+		if filter.Desc {
+			where.add("(start, event_id) < (?, ?)", toMicro(cursor.Start), cursor.ID)
+		} else {
+			where.add("(start, event_id) > (?, ?)", toMicro(cursor.Start), cursor.ID)
+		}
+	}
+	order := "ORDER BY start, event_id"
+	if filter.Desc {
+		order = "ORDER BY start DESC, event_id DESC"
+	}
+	limit := ""
+	// lower в SQLite меняет регистр только латиницы, поэтому поиск по тексту
+	// делается после выборки и ограничить ее нельзя
+	if filter.Limit > 0 && filter.Search == "" {
+		// на одно событие больше, чтобы узнать, есть ли следующая страница
+		limit = "LIMIT " + strconv.Itoa(filter.Limit+1)
+	}
+	query := `
+		SELECT event_id, title, start, stop, description, user_id, notification, rrule, exdate, time_zone, all_day, version
+		FROM event
+		WHERE ` + where.String() + `
+		` + order + `
+		` + limit
+	single, err := s.queryList(ctx, query, where.args...)
+	if err != nil {
+		return storage.Page{}, err
+	}
+
+	where = userCondition(filter)
+	where.addRecurringOverlap(from, to)
+	query = `
+		SELECT event_id, title, start, stop, description, user_id, notification, rrule, exdate, time_zone, all_day, version
+		FROM event
+		WHERE ` + where.String()
+	recurring, err := s.queryList(ctx, query, where.args...)
+	if err != nil {
+		return storage.Page{}, err
+	}
+	for _, event := range recurring {
+		single = append(single, storage.Occurrences(event, from, to)...)
+	}
+
+	result := make([]storage.Event, 0, len(single))
+	for _, event := range single {
+		if filter.Match(event) {
+			result = append(result, event)
+		}
+	}
+	return storage.Paginate(result, filter, cursor), nil
+}
+
+// userCondition возвращает условие на пользователя фильтра.
+func userCondition(filter storage.Filter) *conditions {
+	result := &conditions{}
+	if filter.UserID != 0 {
+		result.add("user_id = ?", filter.UserID)
+	}
+	return result
+}
+
+func (s *store) ListToNotify(ctx context.Context, from, to time.Time) ([]storage.Event, error) {
+	// start хранится в микросекундах, notification - в наносекундах
+	query := `
+		SELECT event_id, title, start, stop, description, user_id, notification, rrule, exdate, time_zone, all_day, version
+		FROM event
+		WHERE rrule IS NULL AND notification IS NOT NULL
+			AND start - notification / 1000 >= ?
+			AND start - notification / 1000 < ?
+		ORDER BY start
+	`
+	result, err := s.queryList(ctx, query, toMicro(from), toMicro(to))
+	if err != nil {
+		return nil, err
+	}
+
+	query = `
+		SELECT event_id, title, start, stop, description, user_id, notification, rrule, exdate, time_zone, all_day, version
+		FROM event
+		WHERE rrule IS NOT NULL AND notification IS NOT NULL
+			AND start - notification / 1000 < ?
+			AND (last_stop IS NULL OR last_stop > ?)
+	`
+	recurring, err := s.queryList(ctx, query, toMicro(to), toMicro(from))
+	if err != nil {
+		return nil, err
+	}
+	for _, event := range recurring {
+		notification := *event.Notification
+		result = append(result, storage.StartsIn(event, from.Add(notification), to.Add(notification))...)
+	}
+	sortByStart(result)
+	return result, nil
+}
+
+func (s *store) ListBefore(ctx context.Context, date time.Time) ([]storage.Event, error) {
+	query := `
+		SELECT event_id, title, start, stop, description, user_id, notification, rrule, exdate, time_zone, all_day, version
+		FROM event
+		WHERE last_stop < ?
+		ORDER BY start
+	`
+	return s.queryList(ctx, query, toMicro(date))
+}
+
+// listOverlapping возвращает события пользователя и их повторения, пересекающиеся с [from, to).
+func (s *store) listOverlapping(ctx context.Context, userID int, from, to time.Time) ([]storage.Event, error) {
+	where := &conditions{}
+	where.add("user_id = ?", userID)
+	where.addOverlap(from, to)
+	query := `
+		SELECT event_id, title, start, stop, description, user_id, notification, rrule, exdate, time_zone, all_day, version
+		FROM event
+		WHERE ` + where.String() + `
+		ORDER BY start
+	`
+	result, err := s.queryList(ctx, query, where.args...)
+	if err != nil {
+		return nil, err
+	}
+
+	where = &conditions{}
+	where.add("user_id = ?", userID)
+	where.addRecurringOverlap(from, to)
+	query = `
+		SELECT event_id, title, start, stop, description, user_id, notification, rrule, exdate, time_zone, all_day, version
+		FROM event
+		WHERE ` + where.String()
+	recurring, err := s.queryList(ctx, query, where.args...)
+	if err != nil {
+		return nil, err
+	}
+	for _, event := range recurring {
+		result = append(result, storage.Occurrences(event, from, to)...)
+	}
+	sortByStart(result)
+	return result, nil
+}
+
+func (s *store) queryList(ctx context.Context, query string, args ...interface{}) ([]storage.Event, error) {
+	return queryList(ctx, s.q, query, args...)
+}
+
+func queryList(
+	ctx context.Context,
+	q sqlcommon.Querier,
+	query string,
+	args ...interface{},
+) (result []storage.Event, resultErr error) {
+	//nolint:rowserrcheck
+	rows, err := q.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("db query: %w", err)
+	}
+	defer func() {
+		err := rows.Close()
+		if err != nil && resultErr == nil {
+			resultErr = err
+		}
+	}()
+
+	for rows.Next() {
+		var event storage.Event
+		var start, stop int64
+		var notification sql.NullInt64
+		var description, rrule, exdate, timeZone sql.NullString
+		err := rows.Scan(
+			&event.ID,
+			&event.Title,
+			&start,
+			&stop,
+			&description,
+			&event.UserID,
+			&notification,
+			&rrule,
+			&exdate,
+			&timeZone,
+			&event.AllDay,
+			&event.Version,
+		)
+		if err != nil {
+			resultErr = fmt.Errorf("db scan: %w", err)
+			return
+		}
+		event.Start = fromMicro(start)
+		event.Stop = fromMicro(stop)
+		event.Description = description.String
+		if notification.Valid {
+			event.Notification = (*time.Duration)(&notification.Int64)
+		}
+		if event.AllDay {
+			// даты события на весь день - полночь UTC, в местном поясе это может быть другой день
+			event.Start, event.Stop = event.Start.UTC(), event.Stop.UTC()
+		}
+		event.Recurrence, err = sqlcommon.ScanRecurrence(rrule, exdate, timeZone)
+		if err != nil {
+			resultErr = fmt.Errorf("db scan: %w", err)
+			return
+		}
+		result = append(result, event)
+	}
+	if err := rows.Err(); err != nil {
+		resultErr = fmt.Errorf("db rows: %w", err)
+		return
+	}
+	return
+}
+
+func (s *store) IsTimeBusy(ctx context.Context, userID int, start, stop time.Time, excludeID int) (bool, error) {
+	query := `
+		SELECT Count(*) AS count
+		FROM event
+		WHERE user_id = ? AND start < ? AND stop > ? AND event_id != ? AND rrule IS NULL AND NOT all_day
+	`
+	var count int
+	err := s.q.QueryRowContext(ctx, query, userID, toMicro(stop), toMicro(start), excludeID).Scan(&count)
+	if err != nil {
+		return false, fmt.Errorf("db query: %w", err)
+	}
+	if count > 0 {
+		return true, nil
+	}
+
+	query = `
+		SELECT event_id, title, start, stop, description, user_id, notification, rrule, exdate, time_zone, all_day, version
+		FROM event
+		WHERE user_id = ? AND start < ? AND (last_stop IS NULL OR last_stop > ?) AND event_id != ?
+			AND rrule IS NOT NULL AND NOT all_day
+	`
+	recurring, err := s.queryList(ctx, query, userID, toMicro(stop), toMicro(start), excludeID)
+	if err != nil {
+		return false, err
+	}
+	for _, event := range recurring {
+		if storage.Overlaps(event, start, stop) {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// checkBusy возвращает ErrDateBusy, если время события занято другими событиями пользователя.
+func checkBusy(ctx context.Context, q sqlcommon.Querier, event storage.Event) error {
+	from, to := storage.BusyRange(event)
+	query := `
+		SELECT event_id, title, start, stop, description, user_id, notification, rrule, exdate, time_zone, all_day, version
+		FROM event
+		WHERE user_id = ? AND event_id != ? AND start < ? AND (last_stop IS NULL OR last_stop > ?)
+	`
+	others, err := queryList(ctx, q, query, event.UserID, event.ID, toMicro(to), toMicro(from))
+	if err != nil {
+		return err
+	}
+	if storage.IsBusy(event, others) {
+		return storage.ErrDateBusy
+	}
+	return nil
+}
+
+// inTx выполняет fn в транзакции BEGIN IMMEDIATE: блокировка записи берется в начале,
+// поэтому проверка занятости времени не устаревает до записи, даже если в базу пишет
+// другой процесс. Отложенная транзакция получила бы SQLITE_BUSY при переходе от чтения
+// к записи без ожидания busy_timeout. database/sql начинает только отложенные транзакции,
+// поэтому транзакция управляется запросами на выделенном соединении.
+func (s *store) inTx(ctx context.Context, fn func(q sqlcommon.Querier) error) (resultErr error) {
+	conn, err := s.db.Conn(ctx)
+	if err != nil {
+		return fmt.Errorf("db conn: %w", err)
+	}
+	defer conn.Close()
+
+	if _, err := conn.ExecContext(ctx, `BEGIN IMMEDIATE`); err != nil {
+		return fmt.Errorf("db begin: %w", err)
+	}
+	defer func() {
+		if resultErr != nil {
+			// без ctx запроса: транзакцию надо откатить, даже если он отменен
+			//nolint:errcheck
+			conn.ExecContext(context.Background(), `ROLLBACK`)
+		}
+	}()
+
+	if err := fn(sqlcommon.Traced(conn, semconv.DBSystemSqlite)); err != nil {
+		return err
+	}
+	if _, err := conn.ExecContext(ctx, `COMMIT`); err != nil {
+		return fmt.Errorf("db commit: %w", err)
+	}
+	return nil
+}
+
+func sortByStart(events []storage.Event) {
+	sort.Slice(events, func(i, j int) bool {
+		return events[i].Start.Before(events[j].Start)
+	})
+}
@@ -0,0 +1,173 @@
+package sqlitestorage
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/anfilat/otus-go/hw12_13_14_15_calendar/internal/storage"
+)
+
+func newStore(t *testing.T) storage.Storage {
+	ctx := context.Background()
+	db := New()
+	require.NoError(t, db.Connect(ctx, filepath.Join(t.TempDir(), "calendar.db")))
+	t.Cleanup(func() {
+		_ = db.Close(ctx)
+	})
+	return db
+}
+
+func TestStorage(t *testing.T) {
+	ctx := context.Background()
+	db := newStore(t)
+	require.NoError(t, db.Ping(ctx))
+
+	start := time.Date(2021, 3, 1, 10, 0, 0, 123456789, time.UTC)
+	notification := 15 * time.Minute
+	event := storage.Event{
+		Title:        "Совещание",
+		Start:        start,
+		Stop:         start.Add(time.Hour),
+		Description:  "ПЛАН на неделю",
+		UserID:       1,
+		Notification: &notification,
+	}
+	id, err := db.Create(ctx, event)
+	require.NoError(t, err)
+	require.Equal(t, 1, id)
+
+	saved, err := db.Get(ctx, id)
+	require.NoError(t, err)
+	require.Equal(t, event.Title, saved.Title)
+	require.Equal(t, event.Description, saved.Description)
+	require.True(t, start.Truncate(time.Microsecond).Equal(saved.Start))
+	require.Equal(t, notification, *saved.Notification)
+	require.Equal(t, storage.InitialVersion, saved.Version)
+
+	_, err = db.Get(ctx, id+1)
+	require.True(t, errors.Is(err, storage.ErrNotExistsEvent))
+
+	_, err = db.CreateIfFree(ctx, event)
+	require.True(t, errors.Is(err, storage.ErrDateBusy))
+
+	saved.Title = "Планерка"
+	require.NoError(t, db.Update(ctx, id, saved))
+	require.True(t, errors.Is(db.Update(ctx, id, saved), storage.ErrConflict))
+
+	// lower в SQLite не меняет регистр кириллицы, поиск должен работать и для нее
+	page, err := db.ListRange(ctx, start.Add(-time.Hour), start.Add(time.Hour), storage.Filter{Search: "план"})
+	require.NoError(t, err)
+	require.Len(t, page.Events, 1)
+	require.Equal(t, "Планерка", page.Events[0].Title)
+
+	events, err := db.ListToNotify(ctx, start.Add(-notification), start)
+	require.NoError(t, err)
+	require.Len(t, events, 1)
+
+	// счетчик идентификаторов сбрасывается вместе с событиями
+	require.NoError(t, db.DeleteAll(ctx))
+	id, err = db.Create(ctx, event)
+	require.NoError(t, err)
+	require.Equal(t, 1, id)
+}
+
+func TestRecurringExceptions(t *testing.T) {
+	ctx := context.Background()
+	db := newStore(t)
+
+	start := time.Date(2021, 3, 1, 10, 0, 0, 123456789, time.UTC)
+	recurrence, err := storage.NewRecurrence("FREQ=DAILY;COUNT=3", []time.Time{start.AddDate(0, 0, 1)})
+	require.NoError(t, err)
+	_, err = db.Create(ctx, storage.Event{
+		Title:      "Зарядка",
+		Start:      start,
+		Stop:       start.Add(time.Hour),
+		UserID:     1,
+		Recurrence: recurrence,
+	})
+	require.NoError(t, err)
+
+	events, err := db.ListWeek(ctx, 1, start)
+	require.NoError(t, err)
+	require.Len(t, events, 2)
+	require.True(t, start.AddDate(0, 0, 2).Truncate(time.Microsecond).Equal(events[1].Start))
+}
+
+// Два процесса с общим файлом базы не получают SQLITE_BUSY и не записывают пересекающиеся события.
+func TestSharedFile(t *testing.T) {
+	ctx := context.Background()
+	path := filepath.Join(t.TempDir(), "calendar.db")
+	var stores []storage.Storage
+	for i := 0; i < 2; i++ {
+		db := New()
+		require.NoError(t, db.Connect(ctx, path))
+		t.Cleanup(func() {
+			_ = db.Close(ctx)
+		})
+		stores = append(stores, db)
+	}
+
+	start := time.Date(2021, 3, 1, 10, 0, 0, 0, time.UTC)
+	const count = 200
+	errs := make(chan error, 2*count)
+	var wg sync.WaitGroup
+	for _, db := range stores {
+		for i := 0; i < count; i++ {
+			wg.Add(1)
+			go func(db storage.Storage, i int) {
+				defer wg.Done()
+				// половина событий пересекается с событиями другого хранилища
+				eventStart := start.Add(time.Duration(i/2) * time.Hour)
+				_, err := db.CreateIfFree(ctx, storage.Event{
+					Title:  "Событие",
+					Start:  eventStart,
+					Stop:   eventStart.Add(time.Hour),
+					UserID: 1,
+				})
+				errs <- err
+			}(db, i)
+		}
+	}
+	wg.Wait()
+	close(errs)
+
+	for err := range errs {
+		if err != nil {
+			require.True(t, errors.Is(err, storage.ErrDateBusy), err)
+		}
+	}
+	events, err := stores[0].ListAll(ctx)
+	require.NoError(t, err)
+	require.Len(t, events, count/2)
+	for i := 1; i < len(events); i++ {
+		require.False(t, events[i].Start.Before(events[i-1].Stop))
+	}
+}
+
+func TestCanceledContext(t *testing.T) {
+	ctx := context.Background()
+	db := newStore(t)
+	id, err := db.Create(ctx, storage.Event{Title: "event", Start: time.Now(), Stop: time.Now(), UserID: 1})
+	require.NoError(t, err)
+
+	// отмена контекста после запроса не прерывает следующие запросы
+	for i := 0; i < 1000; i++ {
+		queryCtx, cancel := context.WithCancel(ctx)
+		_, err := db.Get(queryCtx, id)
+		cancel()
+		require.NoError(t, err)
+		_, err = db.Get(ctx, id)
+		require.NoError(t, err)
+	}
+
+	canceled, cancel := context.WithCancel(ctx)
+	cancel()
+	_, err = db.Get(canceled, id)
+	require.True(t, errors.Is(err, context.Canceled))
+}
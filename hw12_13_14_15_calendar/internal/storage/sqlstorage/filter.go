@@ -0,0 +1,61 @@
+package sqlstorage
+
+import (
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/anfilat/otus-go/hw12_13_14_15_calendar/internal/storage"
+)
+
+// conditions собирает условие WHERE, нумеруя параметры по порядку.
+type conditions struct {
+	parts []string
+	args  []interface{}
+}
+
+// add добавляет условие, в котором параметры обозначены "?".
+func (c *conditions) add(condition string, values ...interface{}) {
+	for _, value := range values {
+		c.args = append(c.args, value)
+		condition = strings.Replace(condition, "?", "$"+strconv.Itoa(len(c.args)), 1)
+	}
+	c.parts = append(c.parts, condition)
+}
+
+func (c *conditions) String() string {
+	if len(c.parts) == 0 {
+		return "TRUE"
+	}
+	return strings.Join(c.parts, " AND ")
+}
+
+// addOverlap добавляет условие пересечения неповторяющихся событий с интервалом [from, to),
+// для событий на весь день - с его датами (см. storage.AllDayRange).
+func (c *conditions) addOverlap(from, to time.Time) {
+	dayFrom, dayTo := storage.AllDayRange(from, to)
+	c.add("rrule IS NULL AND (NOT all_day AND start < ? AND stop > ? OR all_day AND start < ? AND stop > ?)",
+		to, from, dayTo, dayFrom)
+}
+
+// addRecurringOverlap добавляет условие на повторяющиеся события, повторения которых могут
+// пересечься с интервалом [from, to). Сами повторения отбирает storage.Occurrences.
+func (c *conditions) addRecurringOverlap(from, to time.Time) {
+	dayFrom, dayTo := storage.AllDayRange(from, to)
+	c.add(`rrule IS NOT NULL AND (NOT all_day AND start < ? AND (last_stop IS NULL OR last_stop > ?)
+		OR all_day AND start < ? AND (last_stop IS NULL OR last_stop > ?))`,
+		to, from, dayTo, dayFrom)
+}
+
+// filterConditions возвращает условия на пользователя и текст события.
+func filterConditions(filter storage.Filter) *conditions {
+	result := &conditions{}
+	if filter.UserID != 0 {
+		result.add("user_id = ?", filter.UserID)
+	}
+	if filter.Search != "" {
+		result.add("(strpos(lower(title), lower(?)) > 0 OR strpos(lower(description), lower(?)) > 0)",
+			filter.Search, filter.Search)
+	}
+	return result
+}
@@ -0,0 +1,16 @@
+package sqlstorage
+
+import (
+	"context"
+
+	"github.com/anfilat/otus-go/hw12_13_14_15_calendar/internal/storage"
+	"github.com/anfilat/otus-go/hw12_13_14_15_calendar/internal/storage/sqlcommon"
+)
+
+func (s *store) GetSettings(ctx context.Context, userID int) (storage.UserSettings, error) {
+	return sqlcommon.GetSettings(ctx, s.q, userID)
+}
+
+func (s *store) SaveSettings(ctx context.Context, userID int, settings storage.UserSettings) error {
+	return sqlcommon.SaveSettings(ctx, s.q, userID, settings)
+}
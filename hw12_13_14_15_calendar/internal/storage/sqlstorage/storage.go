@@ -4,16 +4,24 @@ import (
 	"context"
 	"database/sql"
 	"fmt"
+	"sort"
+	"strconv"
 	"time"
 
 	// init db driver.
 	_ "github.com/jackc/pgx/v4/stdlib"
 
+	"go.opentelemetry.io/otel/semconv"
+
+	"github.com/anfilat/otus-go/hw12_13_14_15_calendar/internal/metrics"
 	"github.com/anfilat/otus-go/hw12_13_14_15_calendar/internal/storage"
+	"github.com/anfilat/otus-go/hw12_13_14_15_calendar/internal/storage/sqlcommon"
 )
 
 type store struct {
 	db *sql.DB
+	// запросы вне транзакций, с трассировкой
+	q sqlcommon.Querier
 }
 
 func (s *store) Connect(ctx context.Context, connect string) error {
@@ -22,6 +30,8 @@ func (s *store) Connect(ctx context.Context, connect string) error {
 		return fmt.Errorf("failed to connect to database: %w", err)
 	}
 	s.db = db
+	s.q = sqlcommon.Traced(db, semconv.DBSystemPostgres)
+	metrics.SetDB(db)
 	return s.db.PingContext(ctx)
 }
 
@@ -29,26 +39,52 @@ func (s *store) Close(_ context.Context) error {
 	return s.db.Close()
 }
 
+func (s *store) Ping(ctx context.Context) error {
+	if err := s.db.PingContext(ctx); err != nil {
+		return fmt.Errorf("db ping: %w", err)
+	}
+	return nil
+}
+
 func (s *store) Create(ctx context.Context, event storage.Event) (int, error) {
+	return create(ctx, s.q, event)
+}
+
+func (s *store) CreateIfFree(ctx context.Context, event storage.Event) (id int, err error) {
+	err = s.inUserTx(ctx, event.UserID, func(q sqlcommon.Querier) error {
+		if err := checkBusy(ctx, q, event); err != nil {
+			return err
+		}
+		id, err = create(ctx, q, event)
+		return err
+	})
+	return
+}
+
+func create(ctx context.Context, q sqlcommon.Querier, event storage.Event) (int, error) {
+	rrule, exdate, timeZone, lastStop := sqlcommon.RecurrenceArgs(event)
 	var query string
 	var args []interface{}
 	if event.Notification != nil {
 		query = `
-			INSERT INTO event (title, start, stop, description, user_id, notification)
-			VALUES($1, $2, $3, $4, $5, $6)
+			INSERT INTO event (title, start, stop, description, user_id, rrule, exdate, time_zone, last_stop, all_day,
+				notification)
+			VALUES($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11)
 			RETURNING event_id
 		`
-		args = []interface{}{event.Title, event.Start, event.Stop, event.Description, event.UserID, event.Notification}
+		args = []interface{}{event.Title, event.Start, event.Stop, event.Description, event.UserID,
+			rrule, exdate, timeZone, lastStop, event.AllDay, event.Notification}
 	} else {
 		query = `
-			INSERT INTO event (title, start, stop, description, user_id)
-			VALUES($1, $2, $3, $4, $5)
+			INSERT INTO event (title, start, stop, description, user_id, rrule, exdate, time_zone, last_stop, all_day)
+			VALUES($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)
 			RETURNING event_id
 		`
-		args = []interface{}{event.Title, event.Start, event.Stop, event.Description, event.UserID}
+		args = []interface{}{event.Title, event.Start, event.Stop, event.Description, event.UserID,
+			rrule, exdate, timeZone, lastStop, event.AllDay}
 	}
 	var id int
-	err := s.db.QueryRowContext(ctx, query, args...).Scan(&id)
+	err := q.QueryRowContext(ctx, query, args...).Scan(&id)
 	if err != nil {
 		return 0, fmt.Errorf("db exec: %w", err)
 	}
@@ -56,6 +92,27 @@ func (s *store) Create(ctx context.Context, event storage.Event) (int, error) {
 }
 
 func (s *store) Update(ctx context.Context, id int, change storage.Event) error {
+	return update(ctx, s.q, id, change)
+}
+
+func (s *store) UpdateIfFree(ctx context.Context, id int, change storage.Event) error {
+	// владелец события не меняется, поэтому его можно узнать до блокировки
+	event, err := get(ctx, s.q, id)
+	if err != nil {
+		return err
+	}
+	change.ID = id
+	change.UserID = event.UserID
+	return s.inUserTx(ctx, event.UserID, func(q sqlcommon.Querier) error {
+		if err := checkBusy(ctx, q, change); err != nil {
+			return err
+		}
+		return update(ctx, q, id, change)
+	})
+}
+
+func update(ctx context.Context, q sqlcommon.Querier, id int, change storage.Event) error {
+	rrule, exdate, timeZone, lastStop := sqlcommon.RecurrenceArgs(change)
 	var query string
 	var args []interface{}
 	if change.Notification != nil {
@@ -65,10 +122,17 @@ func (s *store) Update(ctx context.Context, id int, change storage.Event) error
 				start = $2,
 				stop = $3,
 				description = $4,
-				notification = $5
-			WHERE event_id = $6;
+				rrule = $5,
+				exdate = $6,
+				time_zone = $7,
+				last_stop = $8,
+				all_day = $9,
+				notification = $10,
+				version = version + 1
+			WHERE event_id = $11 AND version = $12;
 		`
-		args = []interface{}{change.Title, change.Start, change.Stop, change.Description, change.Notification, id}
+		args = []interface{}{change.Title, change.Start, change.Stop, change.Description,
+			rrule, exdate, timeZone, lastStop, change.AllDay, change.Notification, id, change.Version}
 	} else {
 		query = `
 			UPDATE event
@@ -76,12 +140,19 @@ func (s *store) Update(ctx context.Context, id int, change storage.Event) error
 				start = $2,
 				stop = $3,
 				description = $4,
-				notification = null
-			WHERE event_id = $5;
+				rrule = $5,
+				exdate = $6,
+				time_zone = $7,
+				last_stop = $8,
+				all_day = $9,
+				notification = null,
+				version = version + 1
+			WHERE event_id = $10 AND version = $11;
 		`
-		args = []interface{}{change.Title, change.Start, change.Stop, change.Description, id}
+		args = []interface{}{change.Title, change.Start, change.Stop, change.Description,
+			rrule, exdate, timeZone, lastStop, change.AllDay, id, change.Version}
 	}
-	result, err := s.db.ExecContext(ctx, query, args...)
+	result, err := q.ExecContext(ctx, query, args...)
 	if err != nil {
 		return fmt.Errorf("db exec: %w", err)
 	}
@@ -90,7 +161,11 @@ func (s *store) Update(ctx context.Context, id int, change storage.Event) error
 		return fmt.Errorf("db rows affected: %w", err)
 	}
 	if count != 1 {
-		return storage.ErrNotExistsEvent
+		// событие удалено или изменено другим запросом
+		if _, err := get(ctx, q, id); err != nil {
+			return err
+		}
+		return storage.ErrConflict
 	}
 	return nil
 }
@@ -100,70 +175,231 @@ func (s *store) Delete(ctx context.Context, id int) error {
 		DELETE FROM event
 		WHERE event_id = $1
 	`
-	_, err := s.db.ExecContext(ctx, query, id)
+	result, err := s.q.ExecContext(ctx, query, id)
 	if err != nil {
 		return fmt.Errorf("db exec: %w", err)
 	}
+	count, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("db rows affected: %w", err)
+	}
+	if count == 0 {
+		return storage.ErrNotExistsEvent
+	}
 	return nil
 }
 
 func (s *store) DeleteAll(ctx context.Context) error {
 	query := `
-		TRUNCATE TABLE event RESTART IDENTITY
+		TRUNCATE TABLE event, user_settings RESTART IDENTITY
 	`
-	_, err := s.db.ExecContext(ctx, query)
+	_, err := s.q.ExecContext(ctx, query)
 	if err != nil {
 		return fmt.Errorf("db exec: %w", err)
 	}
 	return nil
 }
 
+func (s *store) DeleteBefore(ctx context.Context, date time.Time) (int, error) {
+	query := `
+		DELETE FROM event
+		WHERE last_stop < $1
+	`
+	result, err := s.q.ExecContext(ctx, query, date)
+	if err != nil {
+		return 0, fmt.Errorf("db exec: %w", err)
+	}
+	count, err := result.RowsAffected()
+	if err != nil {
+		return 0, fmt.Errorf("db rows affected: %w", err)
+	}
+	return int(count), nil
+}
+
+func (s *store) Get(ctx context.Context, id int) (storage.Event, error) {
+	return get(ctx, s.q, id)
+}
+
+func get(ctx context.Context, q sqlcommon.Querier, id int) (storage.Event, error) {
+	query := `
+		SELECT event_id, title, start, stop, description, user_id, notification, rrule, exdate, time_zone, all_day, version
+		FROM event
+		WHERE event_id = $1
+	`
+	events, err := queryList(ctx, q, query, id)
+	if err != nil {
+		return storage.Event{}, err
+	}
+	if len(events) == 0 {
+		return storage.Event{}, storage.ErrNotExistsEvent
+	}
+	return events[0], nil
+}
+
 func (s *store) ListAll(ctx context.Context) ([]storage.Event, error) {
 	query := `
-		SELECT event_id, title, start, stop, description, user_id, notification
+		SELECT event_id, title, start, stop, description, user_id, notification, rrule, exdate, time_zone, all_day, version
 		FROM event
 		ORDER BY start
 	`
 	return s.queryList(ctx, query)
 }
 
-func (s *store) ListDay(ctx context.Context, date time.Time) ([]storage.Event, error) {
-	year, month, day := date.Date()
+func (s *store) ListDay(ctx context.Context, userID int, date time.Time) ([]storage.Event, error) {
+	from, to := storage.DayRange(date)
+	return s.listOverlapping(ctx, userID, from, to)
+}
+
+func (s *store) ListWeek(ctx context.Context, userID int, date time.Time) ([]storage.Event, error) {
+	from, to := storage.WeekRange(date)
+	return s.listOverlapping(ctx, userID, from, to)
+}
+
+func (s *store) ListMonth(ctx context.Context, userID int, date time.Time) ([]storage.Event, error) {
+	from, to := storage.MonthRange(date)
+	return s.listOverlapping(ctx, userID, from, to)
+}
+
+func (s *store) ListRange(ctx context.Context, from, to time.Time, filter storage.Filter) (storage.Page, error) {
+	cursor, err := storage.DecodeCursor(filter.Cursor)
+	if err != nil {
+		return storage.Page{}, err
+	}
+
+	where := filterConditions(filter)
+	where.addOverlap(from, to)
+	if cursor != nil {
+		if filter.Desc {
+			where.add("(start, event_id) < (?, ?)", cursor.Start, cursor.ID)
+		} else {
+			where.add("(start, event_id) > (?, ?)", cursor.Start, cursor.ID)
+		}
+	}
+	order := "ORDER BY start, event_id"
+	if filter.Desc {
+		order = "ORDER BY start DESC, event_id DESC"
+	}
+	limit := ""
+	if filter.Limit > 0 {
+		// на одно событие больше, чтобы узнать, есть ли следующая страница
+		limit = "LIMIT " + strconv.Itoa(filter.Limit+1)
+	}
 	query := `
-		SELECT event_id, title, start, stop, description, user_id, notification
+		SELECT event_id, title, start, stop, description, user_id, notification, rrule, exdate, time_zone, all_day, version
 		FROM event
-		WHERE extract(year from start) = $1 AND extract(month from start) = $2 AND extract(day from start) = $3
+		WHERE ` + where.String() + `
+		` + order + `
+		` + limit
+	result, err := s.queryList(ctx, query, where.args...)
+	if err != nil {
+		return storage.Page{}, err
+	}
+
+	where = filterConditions(filter)
+	where.addRecurringOverlap(from, to)
+	query = `
+		SELECT event_id, title, start, stop, description, user_id, notification, rrule, exdate, time_zone, all_day, version
+		FROM event
+		WHERE ` + where.String()
+	recurring, err := s.queryList(ctx, query, where.args...)
+	if err != nil {
+		return storage.Page{}, err
+	}
+	for _, event := range recurring {
+		result = append(result, storage.Occurrences(event, from, to)...)
+	}
+	return storage.Paginate(result, filter, cursor), nil
+}
+
+func (s *store) ListToNotify(ctx context.Context, from, to time.Time) ([]storage.Event, error) {
+	// notification хранится в наносекундах
+	query := `
+		SELECT event_id, title, start, stop, description, user_id, notification, rrule, exdate, time_zone, all_day, version
+		FROM event
+		WHERE rrule IS NULL AND notification IS NOT NULL
+			AND start - notification / 1000 * interval '1 microsecond' >= $1
+			AND start - notification / 1000 * interval '1 microsecond' < $2
 		ORDER BY start
 	`
-	return s.queryList(ctx, query, year, month, day)
+	result, err := s.queryList(ctx, query, from, to)
+	if err != nil {
+		return nil, err
+	}
+
+	query = `
+		SELECT event_id, title, start, stop, description, user_id, notification, rrule, exdate, time_zone, all_day, version
+		FROM event
+		WHERE rrule IS NOT NULL AND notification IS NOT NULL
+			AND start - notification / 1000 * interval '1 microsecond' < $1
+			AND (last_stop IS NULL OR last_stop > $2)
+	`
+	recurring, err := s.queryList(ctx, query, to, from)
+	if err != nil {
+		return nil, err
+	}
+	for _, event := range recurring {
+		notification := *event.Notification
+		result = append(result, storage.StartsIn(event, from.Add(notification), to.Add(notification))...)
+	}
+	sortByStart(result)
+	return result, nil
 }
 
-func (s *store) ListWeek(ctx context.Context, date time.Time) ([]storage.Event, error) {
-	year, week := date.ISOWeek()
+func (s *store) ListBefore(ctx context.Context, date time.Time) ([]storage.Event, error) {
 	query := `
-		SELECT event_id, title, start, stop, description, user_id, notification
+		SELECT event_id, title, start, stop, description, user_id, notification, rrule, exdate, time_zone, all_day, version
 		FROM event
-		WHERE extract(isoyear from start) = $1 AND extract(week from start) = $2
+		WHERE last_stop < $1
 		ORDER BY start
 	`
-	return s.queryList(ctx, query, year, week)
+	return s.queryList(ctx, query, date)
 }
 
-func (s *store) ListMonth(ctx context.Context, date time.Time) ([]storage.Event, error) {
-	year, month, _ := date.Date()
+// listOverlapping возвращает события пользователя и их повторения, пересекающиеся с [from, to).
+func (s *store) listOverlapping(ctx context.Context, userID int, from, to time.Time) ([]storage.Event, error) {
+	where := filterConditions(storage.Filter{UserID: userID})
+	where.addOverlap(from, to)
 	query := `
-		SELECT event_id, title, start, stop, description, user_id, notification
+		SELECT event_id, title, start, stop, description, user_id, notification, rrule, exdate, time_zone, all_day, version
 		FROM event
-		WHERE extract(year from start) = $1 AND extract(month from start) = $2
+		WHERE ` + where.String() + `
 		ORDER BY start
 	`
-	return s.queryList(ctx, query, year, month)
+	result, err := s.queryList(ctx, query, where.args...)
+	if err != nil {
+		return nil, err
+	}
+
+	where = filterConditions(storage.Filter{UserID: userID})
+	where.addRecurringOverlap(from, to)
+	query = `
+		SELECT event_id, title, start, stop, description, user_id, notification, rrule, exdate, time_zone, all_day, version
+		FROM event
+		WHERE ` + where.String()
+	recurring, err := s.queryList(ctx, query, where.args...)
+	if err != nil {
+		return nil, err
+	}
+	for _, event := range recurring {
+		result = append(result, storage.Occurrences(event, from, to)...)
+	}
+	sortByStart(result)
+	return result, nil
+}
+
+func (s *store) queryList(ctx context.Context, query string, args ...interface{}) ([]storage.Event, error) {
+	return queryList(ctx, s.q, query, args...)
 }
 
-func (s *store) queryList(ctx context.Context, query string, args ...interface{}) (result []storage.Event, resultErr error) {
+func queryList(
+	ctx context.Context,
+	q sqlcommon.Querier,
+	query string,
+	args ...interface{},
+) (result []storage.Event, resultErr error) {
 	// проверка есть, чего линтер хочет непонятно
 	//nolint:rowserrcheck
-	rows, err := s.db.QueryContext(ctx, query, args...)
+	rows, err := q.QueryContext(ctx, query, args...)
 	if err != nil {
 		return nil, fmt.Errorf("db query: %w", err)
 	}
@@ -177,6 +413,7 @@ func (s *store) queryList(ctx context.Context, query string, args ...interface{}
 	for rows.Next() {
 		var event storage.Event
 		var notification sql.NullInt64
+		var rrule, exdate, timeZone sql.NullString
 		err := rows.Scan(
 			&event.ID,
 			&event.Title,
@@ -185,6 +422,11 @@ func (s *store) queryList(ctx context.Context, query string, args ...interface{}
 			&event.Description,
 			&event.UserID,
 			&notification,
+			&rrule,
+			&exdate,
+			&timeZone,
+			&event.AllDay,
+			&event.Version,
 		)
 		if err != nil {
 			resultErr = fmt.Errorf("db scan: %w", err)
@@ -193,6 +435,15 @@ func (s *store) queryList(ctx context.Context, query string, args ...interface{}
 		if notification.Valid {
 			event.Notification = (*time.Duration)(&notification.Int64)
 		}
+		if event.AllDay {
+			// даты события на весь день - полночь UTC, в местном поясе это может быть другой день
+			event.Start, event.Stop = event.Start.UTC(), event.Stop.UTC()
+		}
+		event.Recurrence, err = sqlcommon.ScanRecurrence(rrule, exdate, timeZone)
+		if err != nil {
+			resultErr = fmt.Errorf("db scan: %w", err)
+			return
+		}
 		result = append(result, event)
 	}
 	if err := rows.Err(); err != nil {
@@ -206,12 +457,85 @@ func (s *store) IsTimeBusy(ctx context.Context, userID int, start, stop time.Tim
 	query := `
 		SELECT Count(*) AS count
 		FROM event
-		WHERE user_id = $1 AND start < $2 AND stop > $3 AND event_id != $4
+		WHERE user_id = $1 AND start < $2 AND stop > $3 AND event_id != $4 AND rrule IS NULL AND NOT all_day
 	`
 	var count int
-	err := s.db.QueryRowContext(ctx, query, userID, stop, start, excludeID).Scan(&count)
+	err := s.q.QueryRowContext(ctx, query, userID, stop, start, excludeID).Scan(&count)
 	if err != nil {
 		return false, fmt.Errorf("db query: %w", err)
 	}
-	return count > 0, nil
+	if count > 0 {
+		return true, nil
+	}
+
+	query = `
+		SELECT event_id, title, start, stop, description, user_id, notification, rrule, exdate, time_zone, all_day, version
+		FROM event
+		WHERE user_id = $1 AND start < $2 AND (last_stop IS NULL OR last_stop > $3) AND event_id != $4
+			AND rrule IS NOT NULL AND NOT all_day
+	`
+	recurring, err := s.queryList(ctx, query, userID, stop, start, excludeID)
+	if err != nil {
+		return false, err
+	}
+	for _, event := range recurring {
+		if storage.Overlaps(event, start, stop) {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// checkBusy возвращает ErrDateBusy, если время события занято другими событиями пользователя.
+func checkBusy(ctx context.Context, q sqlcommon.Querier, event storage.Event) error {
+	from, to := storage.BusyRange(event)
+	query := `
+		SELECT event_id, title, start, stop, description, user_id, notification, rrule, exdate, time_zone, all_day, version
+		FROM event
+		WHERE user_id = $1 AND event_id != $2 AND start < $3 AND (last_stop IS NULL OR last_stop > $4)
+	`
+	others, err := queryList(ctx, q, query, event.UserID, event.ID, to, from)
+	if err != nil {
+		return err
+	}
+	if storage.IsBusy(event, others) {
+		return storage.ErrDateBusy
+	}
+	return nil
+}
+
+// inUserTx выполняет fn в транзакции, заблокировав изменения событий пользователя другими транзакциями.
+func (s *store) inUserTx(ctx context.Context, userID int, fn func(q sqlcommon.Querier) error) (resultErr error) {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("db begin: %w", err)
+	}
+	defer func() {
+		if resultErr != nil {
+			//nolint:errcheck
+			tx.Rollback()
+		}
+	}()
+
+	q := sqlcommon.Traced(tx, semconv.DBSystemPostgres)
+	_, err = q.ExecContext(ctx, `SELECT pg_advisory_xact_lock($1, $2)`, userLockClass, userID)
+	if err != nil {
+		return fmt.Errorf("db lock: %w", err)
+	}
+	if err := fn(q); err != nil {
+		return err
+	}
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("db commit: %w", err)
+	}
+	return nil
+}
+
+// первая часть ключа advisory lock для блокировки событий пользователя
+const userLockClass = 1
+
+func sortByStart(events []storage.Event) {
+	sort.Slice(events, func(i, j int) bool {
+		return events[i].Start.Before(events[j].Start)
+	})
 }
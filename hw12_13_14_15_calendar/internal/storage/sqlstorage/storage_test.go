@@ -0,0 +1,27 @@
+package sqlstorage_test
+
+import (
+	"context"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"github.com/stretchr/testify/suite"
+
+	"github.com/anfilat/otus-go/hw12_13_14_15_calendar/internal/storage"
+	"github.com/anfilat/otus-go/hw12_13_14_15_calendar/internal/storage/sqlstorage"
+	"github.com/anfilat/otus-go/hw12_13_14_15_calendar/internal/storage/storagetest"
+)
+
+// Нужна база PostgreSQL с примененными миграциями, см. make test-pq.
+func TestStorage(t *testing.T) {
+	connect := os.Getenv("PQ_TEST")
+	if connect == "" {
+		t.Skip("PQ_TEST is not set")
+	}
+	suite.Run(t, &storagetest.Suite{NewStorage: func(t *testing.T) storage.Storage {
+		db := sqlstorage.New()
+		require.NoError(t, db.Connect(context.Background(), connect))
+		return db
+	}})
+}
@@ -0,0 +1,145 @@
+package storagetest
+
+import (
+	"context"
+	"time"
+
+	"github.com/anfilat/otus-go/hw12_13_14_15_calendar/internal/storage"
+)
+
+func newAllDayEvent(userID int, title string, date time.Time, days int) storage.Event {
+	event := newEvent(userID, title, date, time.Duration(days)*24*time.Hour)
+	event.AllDay = true
+	return event
+}
+
+func (s *Suite) TestAllDayCreateGet() {
+	ctx := context.Background()
+	event := newAllDayEvent(1, "conference", monday, 3)
+	id := s.create(event)
+
+	saved, err := s.db.Get(ctx, id)
+	s.Require().NoError(err)
+	s.Require().True(saved.AllDay)
+	// даты возвращаются в UTC независимо от местного пояса
+	s.Require().Equal(monday, saved.Start)
+	s.Require().Equal(monday.AddDate(0, 0, 3), saved.Stop)
+
+	saved.AllDay = false
+	s.Require().NoError(s.db.Update(ctx, id, saved))
+	saved, err = s.db.Get(ctx, id)
+	s.Require().NoError(err)
+	s.Require().False(saved.AllDay)
+}
+
+// Многодневное событие попадает в каждый свой день, а не только в первый.
+func (s *Suite) TestListMultiDay() {
+	ctx := context.Background()
+	// с воскресенья 22:00 до вторника 10:00
+	trip := s.create(newEvent(1, "trip", monday.Add(-2*time.Hour), 36*time.Hour))
+	conference := s.create(newAllDayEvent(1, "conference", monday, 3))
+
+	tests := []struct {
+		name string
+		date time.Time
+		ids  []int
+	}{
+		{"sunday", monday.AddDate(0, 0, -1), []int{trip}},
+		{"monday", monday, []int{trip, conference}},
+		{"tuesday", monday.AddDate(0, 0, 1), []int{trip, conference}},
+		{"wednesday", monday.AddDate(0, 0, 2).Add(12 * time.Hour), []int{conference}},
+		{"thursday", monday.AddDate(0, 0, 3), nil},
+	}
+	for _, tt := range tests {
+		events, err := s.db.ListDay(ctx, 1, tt.date)
+		s.Require().NoError(err)
+		s.Require().ElementsMatch(tt.ids, ids(events), tt.name)
+	}
+
+	events, err := s.db.ListWeek(ctx, 1, monday.AddDate(0, 0, -1))
+	s.Require().NoError(err)
+	s.Require().Equal([]int{trip}, ids(events))
+	events, err = s.db.ListMonth(ctx, 1, monday.AddDate(0, 0, -1))
+	s.Require().NoError(err)
+	s.Require().Equal([]int{trip}, ids(events))
+}
+
+// Событие на весь день приходится на одни и те же календарные дни в любом часовом поясе.
+func (s *Suite) TestListAllDayTimeZones() {
+	ctx := context.Background()
+	moscow := time.FixedZone("MSK", 3*60*60)
+	hawaii := time.FixedZone("HST", -10*60*60)
+	id := s.create(newAllDayEvent(1, "holiday", monday, 1))
+
+	tests := []struct {
+		name  string
+		date  time.Time
+		count int
+	}{
+		{"moscow", time.Date(2021, 3, 1, 1, 0, 0, 0, moscow), 1},
+		{"moscow next day", time.Date(2021, 3, 2, 1, 0, 0, 0, moscow), 0},
+		{"hawaii", time.Date(2021, 3, 1, 23, 0, 0, 0, hawaii), 1},
+		{"hawaii previous day", time.Date(2021, 2, 28, 23, 0, 0, 0, hawaii), 0},
+	}
+	for _, tt := range tests {
+		events, err := s.db.ListDay(ctx, 1, tt.date)
+		s.Require().NoError(err)
+		s.Require().Len(events, tt.count, tt.name)
+	}
+
+	from := time.Date(2021, 3, 1, 20, 0, 0, 0, hawaii)
+	page, err := s.db.ListRange(ctx, from, from.Add(time.Hour), storage.Filter{})
+	s.Require().NoError(err)
+	s.Require().Equal([]int{id}, ids(page.Events))
+	from = time.Date(2021, 3, 2, 0, 0, 0, 0, moscow)
+	page, err = s.db.ListRange(ctx, from, from.Add(time.Hour), storage.Filter{})
+	s.Require().NoError(err)
+	s.Require().Len(page.Events, 0)
+}
+
+func (s *Suite) TestListAllDayRecurring() {
+	ctx := context.Background()
+	weekly, err := storage.NewRecurrence("FREQ=WEEKLY;COUNT=3", nil)
+	s.Require().NoError(err)
+	// выходные: суббота и воскресенье каждой недели
+	event := newAllDayEvent(1, "weekend", monday.AddDate(0, 0, 5), 2)
+	event.Recurrence = weekly
+	id := s.create(event)
+
+	events, err := s.db.ListDay(ctx, 1, monday.AddDate(0, 0, 13).Add(23*time.Hour))
+	s.Require().NoError(err)
+	s.Require().Equal([]int{id}, ids(events))
+	s.Require().Equal([]time.Time{monday.AddDate(0, 0, 12)}, starts(events))
+
+	events, err = s.db.ListDay(ctx, 1, monday.AddDate(0, 0, 14))
+	s.Require().NoError(err)
+	s.Require().Len(events, 0)
+
+	events, err = s.db.ListMonth(ctx, 1, monday)
+	s.Require().NoError(err)
+	s.Require().Len(events, 3)
+}
+
+// События на весь день не занимают время.
+func (s *Suite) TestAllDayIsNotBusy() {
+	ctx := context.Background()
+	s.create(newAllDayEvent(1, "conference", monday, 3))
+	daily, err := storage.NewRecurrence("FREQ=DAILY", nil)
+	s.Require().NoError(err)
+	recurring := newAllDayEvent(1, "daily", monday, 1)
+	recurring.Recurrence = daily
+	s.create(recurring)
+
+	busy, err := s.db.IsTimeBusy(ctx, 1, monday.Add(10*time.Hour), monday.Add(11*time.Hour), 0)
+	s.Require().NoError(err)
+	s.Require().False(busy)
+
+	id, err := s.db.CreateIfFree(ctx, newEvent(1, "meeting", monday.Add(10*time.Hour), time.Hour))
+	s.Require().NoError(err)
+	_, err = s.db.CreateIfFree(ctx, newAllDayEvent(1, "holiday", monday, 1))
+	s.Require().NoError(err)
+
+	busy, err = s.db.IsTimeBusy(ctx, 1, monday.Add(10*time.Hour), monday.Add(11*time.Hour), id)
+	s.Require().NoError(err)
+	s.Require().False(busy)
+}
@@ -0,0 +1,176 @@
+package storagetest
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/anfilat/otus-go/hw12_13_14_15_calendar/internal/storage"
+)
+
+func (s *Suite) TestIsTimeBusy() {
+	ctx := context.Background()
+	// 10:00-11:00
+	id := s.create(newEvent(1, "event", monday.Add(10*time.Hour), time.Hour))
+	daily, err := storage.NewRecurrence("FREQ=DAILY;COUNT=3", nil)
+	s.Require().NoError(err)
+	// 14:00-15:00 три дня
+	recurring := newEvent(1, "daily", monday.Add(14*time.Hour), time.Hour)
+	recurring.Recurrence = daily
+	recurringID := s.create(recurring)
+
+	tests := []struct {
+		name        string
+		userID      int
+		start, stop time.Duration
+		excludeID   int
+		busy        bool
+	}{
+		{"same time", 1, 10 * time.Hour, 11 * time.Hour, 0, true},
+		{"inside", 1, 10*time.Hour + 15*time.Minute, 10*time.Hour + 45*time.Minute, 0, true},
+		{"around", 1, 9 * time.Hour, 12 * time.Hour, 0, true},
+		{"overlaps start", 1, 9*time.Hour + 30*time.Minute, 10*time.Hour + 30*time.Minute, 0, true},
+		{"overlaps stop", 1, 10*time.Hour + 30*time.Minute, 11*time.Hour + 30*time.Minute, 0, true},
+		{"ends at start", 1, 9 * time.Hour, 10 * time.Hour, 0, false},
+		{"starts at stop", 1, 11 * time.Hour, 12 * time.Hour, 0, false},
+		{"other user", 2, 10 * time.Hour, 11 * time.Hour, 0, false},
+		{"excluded", 1, 10 * time.Hour, 11 * time.Hour, id, false},
+		{"recurring", 1, 38*time.Hour + 30*time.Minute, 39 * time.Hour, 0, true},
+		{"recurring excluded", 1, 38*time.Hour + 30*time.Minute, 39 * time.Hour, recurringID, false},
+		{"between repeats", 1, 16 * time.Hour, 37 * time.Hour, 0, false},
+		{"after last repeat", 1, 86 * time.Hour, 87 * time.Hour, 0, false},
+	}
+	for _, tt := range tests {
+		busy, err := s.db.IsTimeBusy(ctx, tt.userID, monday.Add(tt.start), monday.Add(tt.stop), tt.excludeID)
+		s.Require().NoError(err)
+		s.Require().Equal(tt.busy, busy, tt.name)
+	}
+}
+
+func (s *Suite) TestCreateIfFree() {
+	ctx := context.Background()
+	_, err := s.db.CreateIfFree(ctx, newEvent(1, "event", monday.Add(10*time.Hour), time.Hour))
+	s.Require().NoError(err)
+
+	_, err = s.db.CreateIfFree(ctx, newEvent(1, "overlaps", monday.Add(10*time.Hour+30*time.Minute), time.Hour))
+	s.Require().True(errors.Is(err, storage.ErrDateBusy))
+	_, err = s.db.CreateIfFree(ctx, newEvent(1, "adjacent", monday.Add(11*time.Hour), time.Hour))
+	s.Require().NoError(err)
+	_, err = s.db.CreateIfFree(ctx, newEvent(2, "other user", monday.Add(10*time.Hour), time.Hour))
+	s.Require().NoError(err)
+
+	// повторение пересекается с событием на следующий день
+	_, err = s.db.CreateIfFree(ctx, newEvent(1, "tomorrow", monday.Add(38*time.Hour), time.Hour))
+	s.Require().NoError(err)
+	daily, err := storage.NewRecurrence("FREQ=DAILY", nil)
+	s.Require().NoError(err)
+	event := newEvent(1, "daily", monday.Add(14*time.Hour), time.Hour)
+	event.Recurrence = daily
+	_, err = s.db.CreateIfFree(ctx, event)
+	s.Require().True(errors.Is(err, storage.ErrDateBusy))
+}
+
+func (s *Suite) TestUpdateIfFree() {
+	ctx := context.Background()
+	id := s.create(newEvent(1, "event", monday.Add(10*time.Hour), time.Hour))
+	s.create(newEvent(1, "other", monday.Add(12*time.Hour), time.Hour))
+
+	// пересечение с самим собой не занятость
+	change := newEvent(1, "event", monday.Add(10*time.Hour+30*time.Minute), time.Hour)
+	s.Require().NoError(s.db.UpdateIfFree(ctx, id, change))
+
+	change = newEvent(1, "event", monday.Add(11*time.Hour+30*time.Minute), time.Hour)
+	change.Version = storage.InitialVersion + 1
+	s.Require().True(errors.Is(s.db.UpdateIfFree(ctx, id, change), storage.ErrDateBusy))
+
+	s.Require().True(errors.Is(s.db.UpdateIfFree(ctx, id+1000, change), storage.ErrNotExistsEvent))
+}
+
+const goroutines = 10
+
+func (s *Suite) TestConcurrentCreate() {
+	ctx := context.Background()
+	results := make(chan int, goroutines)
+	var wg sync.WaitGroup
+	for i := 0; i < goroutines; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			id, err := s.db.Create(ctx, newEvent(1, "event", monday.Add(time.Duration(i)*time.Hour), time.Hour))
+			if err == nil {
+				results <- id
+			}
+		}(i)
+	}
+	wg.Wait()
+	close(results)
+
+	unique := make(map[int]bool)
+	for id := range results {
+		unique[id] = true
+	}
+	s.Require().Len(unique, goroutines)
+	events, err := s.db.ListAll(ctx)
+	s.Require().NoError(err)
+	s.Require().Len(events, goroutines)
+}
+
+// Из одновременных попыток занять одно время удается ровно одна.
+func (s *Suite) TestConcurrentCreateIfFree() {
+	ctx := context.Background()
+	errs := make(chan error, goroutines)
+	var wg sync.WaitGroup
+	for i := 0; i < goroutines; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			start := monday.Add(10*time.Hour + time.Duration(i)*time.Minute)
+			_, err := s.db.CreateIfFree(ctx, newEvent(1, "event", start, time.Hour))
+			errs <- err
+		}(i)
+	}
+	wg.Wait()
+	close(errs)
+
+	s.requireOneSuccess(errs, storage.ErrDateBusy)
+	events, err := s.db.ListAll(ctx)
+	s.Require().NoError(err)
+	s.Require().Len(events, 1)
+}
+
+// Из одновременных изменений одной версии события удается ровно одно.
+func (s *Suite) TestConcurrentUpdate() {
+	ctx := context.Background()
+	id := s.create(newEvent(1, "event", monday.Add(10*time.Hour), time.Hour))
+
+	errs := make(chan error, goroutines)
+	var wg sync.WaitGroup
+	for i := 0; i < goroutines; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			change := newEvent(1, "event", monday.Add(10*time.Hour+time.Duration(i)*time.Minute), time.Hour)
+			errs <- s.db.Update(ctx, id, change)
+		}(i)
+	}
+	wg.Wait()
+	close(errs)
+
+	s.requireOneSuccess(errs, storage.ErrConflict)
+	saved, err := s.db.Get(ctx, id)
+	s.Require().NoError(err)
+	s.Require().Equal(storage.InitialVersion+1, saved.Version)
+}
+
+func (s *Suite) requireOneSuccess(errs <-chan error, expected error) {
+	succeeded := 0
+	for err := range errs {
+		if err == nil {
+			succeeded++
+			continue
+		}
+		s.Require().True(errors.Is(err, expected), err)
+	}
+	s.Require().Equal(1, succeeded)
+}
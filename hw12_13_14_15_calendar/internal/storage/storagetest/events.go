@@ -0,0 +1,158 @@
+package storagetest
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/anfilat/otus-go/hw12_13_14_15_calendar/internal/storage"
+)
+
+func (s *Suite) TestCreateGet() {
+	ctx := context.Background()
+	notification := 15 * time.Minute
+	event := newEvent(1, "Совещание", monday.Add(10*time.Hour+123456*time.Microsecond), time.Hour)
+	event.Description = "план на неделю"
+	event.Notification = &notification
+
+	id := s.create(event)
+	s.Require().NotEqual(0, id)
+
+	saved, err := s.db.Get(ctx, id)
+	s.Require().NoError(err)
+	s.Require().Equal(id, saved.ID)
+	s.Require().Equal(event.Title, saved.Title)
+	s.Require().Equal(event.Description, saved.Description)
+	s.Require().True(event.Start.Equal(saved.Start), saved.Start)
+	s.Require().True(event.Stop.Equal(saved.Stop), saved.Stop)
+	s.Require().Equal(event.UserID, saved.UserID)
+	s.Require().Equal(notification, *saved.Notification)
+	s.Require().Nil(saved.Recurrence)
+	s.Require().Equal(storage.InitialVersion, saved.Version)
+
+	other := s.create(newEvent(1, "other", monday.Add(12*time.Hour), time.Hour))
+	s.Require().NotEqual(id, other)
+}
+
+func (s *Suite) TestCreateRecurring() {
+	ctx := context.Background()
+	start := monday.Add(10 * time.Hour)
+	recurrence, err := storage.NewRecurrence("FREQ=WEEKLY;COUNT=4", []time.Time{start.AddDate(0, 0, 7)})
+	s.Require().NoError(err)
+	event := newEvent(1, "weekly", start, time.Hour)
+	event.Recurrence = recurrence
+
+	saved, err := s.db.Get(ctx, s.create(event))
+	s.Require().NoError(err)
+	s.Require().NotNil(saved.Recurrence)
+	s.Require().Equal("FREQ=WEEKLY;COUNT=4", saved.Recurrence.RRule())
+	s.Require().Len(saved.Recurrence.Exceptions, 1)
+	s.Require().True(start.AddDate(0, 0, 7).Equal(saved.Recurrence.Exceptions[0]))
+}
+
+func (s *Suite) TestGetNotExists() {
+	_, err := s.db.Get(context.Background(), 1000)
+	s.Require().True(errors.Is(err, storage.ErrNotExistsEvent))
+}
+
+func (s *Suite) TestUpdate() {
+	ctx := context.Background()
+	id := s.create(newEvent(1, "event", monday.Add(10*time.Hour), time.Hour))
+
+	notification := time.Hour
+	change := newEvent(2, "changed", monday.Add(12*time.Hour), 2*time.Hour)
+	change.Description = "description"
+	change.Notification = &notification
+	s.Require().NoError(s.db.Update(ctx, id, change))
+
+	saved, err := s.db.Get(ctx, id)
+	s.Require().NoError(err)
+	s.Require().Equal("changed", saved.Title)
+	s.Require().Equal("description", saved.Description)
+	s.Require().True(change.Start.Equal(saved.Start))
+	s.Require().True(change.Stop.Equal(saved.Stop))
+	s.Require().Equal(notification, *saved.Notification)
+	// владелец события не меняется
+	s.Require().Equal(1, saved.UserID)
+	s.Require().Equal(storage.InitialVersion+1, saved.Version)
+
+	// уведомление можно убрать
+	saved.Notification = nil
+	s.Require().NoError(s.db.Update(ctx, id, saved))
+	saved, err = s.db.Get(ctx, id)
+	s.Require().NoError(err)
+	s.Require().Nil(saved.Notification)
+	s.Require().Equal(storage.InitialVersion+2, saved.Version)
+}
+
+func (s *Suite) TestUpdateConflict() {
+	ctx := context.Background()
+	id := s.create(newEvent(1, "event", monday.Add(10*time.Hour), time.Hour))
+
+	change := newEvent(1, "first", monday.Add(10*time.Hour), time.Hour)
+	s.Require().NoError(s.db.Update(ctx, id, change))
+
+	// версия устарела
+	change.Title = "second"
+	s.Require().True(errors.Is(s.db.Update(ctx, id, change), storage.ErrConflict))
+	saved, err := s.db.Get(ctx, id)
+	s.Require().NoError(err)
+	s.Require().Equal("first", saved.Title)
+
+	s.Require().True(errors.Is(s.db.Update(ctx, id+1000, change), storage.ErrNotExistsEvent))
+}
+
+func (s *Suite) TestDelete() {
+	ctx := context.Background()
+	id := s.create(newEvent(1, "event", monday.Add(10*time.Hour), time.Hour))
+	other := s.create(newEvent(1, "other", monday.Add(12*time.Hour), time.Hour))
+
+	s.Require().NoError(s.db.Delete(ctx, id))
+	_, err := s.db.Get(ctx, id)
+	s.Require().True(errors.Is(err, storage.ErrNotExistsEvent))
+	_, err = s.db.Get(ctx, other)
+	s.Require().NoError(err)
+
+	err = s.db.Delete(ctx, id)
+	s.Require().True(errors.Is(err, storage.ErrNotExistsEvent))
+}
+
+func (s *Suite) TestDeleteAll() {
+	ctx := context.Background()
+	s.create(newEvent(1, "first", monday.Add(10*time.Hour), time.Hour))
+	s.create(newEvent(2, "second", monday.Add(10*time.Hour), time.Hour))
+
+	s.Require().NoError(s.db.DeleteAll(ctx))
+	events, err := s.db.ListAll(ctx)
+	s.Require().NoError(err)
+	s.Require().Len(events, 0)
+}
+
+func (s *Suite) TestDeleteBefore() {
+	ctx := context.Background()
+	old := s.create(newEvent(1, "old", monday.Add(10*time.Hour), time.Hour))
+	// заканчивается ровно на границе и не удаляется
+	edge := s.create(newEvent(1, "edge", monday.Add(11*time.Hour), time.Hour))
+	finite, err := storage.NewRecurrence("FREQ=DAILY;COUNT=2", nil)
+	s.Require().NoError(err)
+	event := newEvent(1, "finite", monday.AddDate(0, 0, -7), time.Hour)
+	event.Recurrence = finite
+	oldRecurring := s.create(event)
+	infinite, err := storage.NewRecurrence("FREQ=DAILY", nil)
+	s.Require().NoError(err)
+	event = newEvent(1, "infinite", monday.AddDate(0, 0, -7), time.Hour)
+	event.Recurrence = infinite
+	endless := s.create(event)
+
+	before := monday.Add(12 * time.Hour)
+	events, err := s.db.ListBefore(ctx, before)
+	s.Require().NoError(err)
+	s.Require().ElementsMatch([]int{old, oldRecurring}, ids(events))
+
+	count, err := s.db.DeleteBefore(ctx, before)
+	s.Require().NoError(err)
+	s.Require().Equal(2, count)
+	events, err = s.db.ListAll(ctx)
+	s.Require().NoError(err)
+	s.Require().ElementsMatch([]int{edge, endless}, ids(events))
+}
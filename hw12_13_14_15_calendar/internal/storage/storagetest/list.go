@@ -0,0 +1,309 @@
+package storagetest
+
+import (
+	"context"
+	"time"
+
+	"github.com/anfilat/otus-go/hw12_13_14_15_calendar/internal/storage"
+)
+
+func (s *Suite) TestListAllOrder() {
+	ctx := context.Background()
+	third := s.create(newEvent(1, "third", monday.Add(14*time.Hour), time.Hour))
+	first := s.create(newEvent(2, "first", monday.Add(10*time.Hour), time.Hour))
+	second := s.create(newEvent(1, "second", monday.Add(12*time.Hour), time.Hour))
+
+	events, err := s.db.ListAll(ctx)
+	s.Require().NoError(err)
+	s.Require().Equal([]int{first, second, third}, ids(events))
+}
+
+func (s *Suite) TestListDay() {
+	ctx := context.Background()
+	day := monday.AddDate(0, 0, 1)
+	// заканчивается ровно в начале дня
+	s.create(newEvent(1, "previous day", day.Add(-time.Hour), time.Hour))
+	// начинается накануне и продолжается в этот день
+	overnight := s.create(newEvent(1, "overnight", day.Add(-time.Minute), time.Hour))
+	midnight := s.create(newEvent(1, "midnight", day, time.Hour))
+	late := s.create(newEvent(1, "late", day.Add(24*time.Hour-time.Minute), time.Hour))
+	s.create(newEvent(1, "next day", day.AddDate(0, 0, 1), time.Hour))
+	s.create(newEvent(2, "other user", day.Add(10*time.Hour), time.Hour))
+
+	// любое время внутри дня
+	for _, date := range []time.Time{day, day.Add(12 * time.Hour), day.Add(24*time.Hour - time.Nanosecond)} {
+		events, err := s.db.ListDay(ctx, 1, date)
+		s.Require().NoError(err)
+		s.Require().Equal([]int{overnight, midnight, late}, ids(events), date)
+	}
+}
+
+func (s *Suite) TestListWeek() {
+	ctx := context.Background()
+	s.create(newEvent(1, "previous sunday", monday.Add(-time.Hour), time.Hour))
+	first := s.create(newEvent(1, "monday", monday, time.Hour))
+	middle := s.create(newEvent(1, "thursday", monday.AddDate(0, 0, 3).Add(12*time.Hour), time.Hour))
+	last := s.create(newEvent(1, "sunday", monday.AddDate(0, 0, 7).Add(-time.Minute), time.Hour))
+	s.create(newEvent(1, "next monday", monday.AddDate(0, 0, 7), time.Hour))
+	s.create(newEvent(2, "other user", monday.Add(10*time.Hour), time.Hour))
+
+	for i := 0; i < 7; i++ {
+		date := monday.AddDate(0, 0, i).Add(12 * time.Hour)
+		events, err := s.db.ListWeek(ctx, 1, date)
+		s.Require().NoError(err)
+		s.Require().Equal([]int{first, middle, last}, ids(events), date)
+	}
+}
+
+func (s *Suite) TestListWeekAcrossYears() {
+	ctx := context.Background()
+	// неделя с понедельника 28 декабря 2020 по воскресенье 3 января 2021
+	december := s.create(newEvent(1, "december", time.Date(2020, 12, 28, 10, 0, 0, 0, time.UTC), time.Hour))
+	january := s.create(newEvent(1, "january", time.Date(2021, 1, 3, 10, 0, 0, 0, time.UTC), time.Hour))
+	s.create(newEvent(1, "next week", time.Date(2021, 1, 4, 10, 0, 0, 0, time.UTC), time.Hour))
+
+	for _, date := range []time.Time{
+		time.Date(2020, 12, 31, 10, 0, 0, 0, time.UTC),
+		time.Date(2021, 1, 1, 10, 0, 0, 0, time.UTC),
+	} {
+		events, err := s.db.ListWeek(ctx, 1, date)
+		s.Require().NoError(err)
+		s.Require().Equal([]int{december, january}, ids(events), date)
+	}
+}
+
+func (s *Suite) TestListMonth() {
+	ctx := context.Background()
+	february := time.Date(2021, 2, 1, 0, 0, 0, 0, time.UTC)
+	s.create(newEvent(1, "january", february.Add(-time.Hour), time.Hour))
+	first := s.create(newEvent(1, "first day", february, time.Hour))
+	last := s.create(newEvent(1, "last day", time.Date(2021, 2, 28, 23, 59, 0, 0, time.UTC), time.Hour))
+	s.create(newEvent(1, "march", time.Date(2021, 3, 1, 0, 0, 0, 0, time.UTC), time.Hour))
+	s.create(newEvent(2, "other user", february.Add(10*time.Hour), time.Hour))
+
+	for _, date := range []time.Time{february, time.Date(2021, 2, 15, 12, 0, 0, 0, time.UTC)} {
+		events, err := s.db.ListMonth(ctx, 1, date)
+		s.Require().NoError(err)
+		s.Require().Equal([]int{first, last}, ids(events), date)
+	}
+}
+
+func (s *Suite) TestListRecurring() {
+	ctx := context.Background()
+	start := monday.Add(10 * time.Hour)
+	recurrence, err := storage.NewRecurrence("FREQ=DAILY;COUNT=5", []time.Time{start.AddDate(0, 0, 2)})
+	s.Require().NoError(err)
+	event := newEvent(1, "daily", start, time.Hour)
+	event.Recurrence = recurrence
+	id := s.create(event)
+
+	events, err := s.db.ListWeek(ctx, 1, monday)
+	s.Require().NoError(err)
+	s.Require().Equal([]int{id, id, id, id}, ids(events))
+	s.Require().Equal([]time.Time{
+		start,
+		start.AddDate(0, 0, 1),
+		start.AddDate(0, 0, 3),
+		start.AddDate(0, 0, 4),
+	}, starts(events))
+
+	events, err = s.db.ListDay(ctx, 1, start.AddDate(0, 0, 2))
+	s.Require().NoError(err)
+	s.Require().Len(events, 0)
+}
+
+// Границы дня, недели и месяца берутся в часовом поясе даты запроса,
+// независимо от пояса, в котором было задано событие.
+func (s *Suite) TestListTimeZones() {
+	ctx := context.Background()
+	moscow := time.FixedZone("MSK", 3*60*60)
+	newYork := time.FixedZone("EST", -5*60*60)
+
+	// 1 марта 23:30 UTC - это уже 2 марта в Москве и еще 1 марта в Нью-Йорке
+	start := time.Date(2021, 3, 1, 23, 30, 0, 0, time.UTC)
+	id := s.create(newEvent(1, "event", start.In(newYork), 20*time.Minute))
+
+	saved, err := s.db.Get(ctx, id)
+	s.Require().NoError(err)
+	s.Require().True(start.Equal(saved.Start))
+
+	tests := []struct {
+		name  string
+		date  time.Time
+		count int
+	}{
+		{"utc day", time.Date(2021, 3, 1, 12, 0, 0, 0, time.UTC), 1},
+		{"utc next day", time.Date(2021, 3, 2, 12, 0, 0, 0, time.UTC), 0},
+		{"moscow day", time.Date(2021, 3, 2, 12, 0, 0, 0, moscow), 1},
+		{"moscow previous day", time.Date(2021, 3, 1, 12, 0, 0, 0, moscow), 0},
+		{"new york day", time.Date(2021, 3, 1, 12, 0, 0, 0, newYork), 1},
+		{"new york next day", time.Date(2021, 3, 2, 12, 0, 0, 0, newYork), 0},
+	}
+	for _, tt := range tests {
+		events, err := s.db.ListDay(ctx, 1, tt.date)
+		s.Require().NoError(err)
+		s.Require().Len(events, tt.count, tt.name)
+	}
+
+	// воскресенье 28 февраля 23:30 UTC - в Москве уже понедельник новой недели
+	// и новый месяц
+	start = time.Date(2021, 2, 28, 23, 30, 0, 0, time.UTC)
+	id = s.create(newEvent(1, "sunday", start, 20*time.Minute))
+
+	events, err := s.db.ListWeek(ctx, 1, time.Date(2021, 2, 26, 12, 0, 0, 0, time.UTC))
+	s.Require().NoError(err)
+	s.Require().Equal([]int{id}, ids(events))
+	events, err = s.db.ListWeek(ctx, 1, time.Date(2021, 2, 26, 12, 0, 0, 0, moscow))
+	s.Require().NoError(err)
+	s.Require().Len(events, 0)
+
+	events, err = s.db.ListMonth(ctx, 1, time.Date(2021, 2, 15, 12, 0, 0, 0, time.UTC))
+	s.Require().NoError(err)
+	s.Require().Equal([]int{id}, ids(events))
+	events, err = s.db.ListMonth(ctx, 1, time.Date(2021, 2, 15, 12, 0, 0, 0, moscow))
+	s.Require().NoError(err)
+	s.Require().Len(events, 0)
+}
+
+// Повторения раскрываются в поясе правила, а не в поясе, в котором хранилище вернуло начало события.
+func (s *Suite) TestListRecurringTimeZone() {
+	ctx := context.Background()
+	moscow, err := time.LoadLocation("Europe/Moscow")
+	s.Require().NoError(err)
+	berlin, err := time.LoadLocation("Europe/Berlin")
+	s.Require().NoError(err)
+
+	// понедельник 00:30 по Москве - еще воскресенье в UTC
+	start := time.Date(2030, 3, 4, 0, 30, 0, 0, time.FixedZone("", 3*60*60))
+	recurrence, err := storage.NewRecurrence("FREQ=WEEKLY;BYDAY=MO", nil)
+	s.Require().NoError(err)
+	recurrence.TimeZone = "Europe/Moscow"
+	event := newEvent(1, "weekly", start, 20*time.Minute)
+	event.Recurrence = recurrence
+	id := s.create(event)
+
+	saved, err := s.db.Get(ctx, id)
+	s.Require().NoError(err)
+	s.Require().Equal("Europe/Moscow", saved.Recurrence.TimeZone)
+
+	events, err := s.db.ListMonth(ctx, 1, start)
+	s.Require().NoError(err)
+	s.Require().Len(events, 4)
+	for _, event := range events {
+		local := event.Start.In(moscow)
+		s.Require().Equal(time.Monday, local.Weekday(), local)
+		s.Require().Equal(30, local.Minute(), local)
+	}
+
+	// 31 марта 2030 в Берлине переходят на летнее время, местное время повторений не меняется
+	s.Require().NoError(s.db.DeleteAll(ctx))
+	start = time.Date(2030, 3, 25, 9, 0, 0, 0, berlin)
+	recurrence, err = storage.NewRecurrence("FREQ=WEEKLY;COUNT=3", nil)
+	s.Require().NoError(err)
+	recurrence.TimeZone = "Europe/Berlin"
+	event = newEvent(1, "weekly", start, time.Hour)
+	event.Recurrence = recurrence
+	s.create(event)
+
+	events, err = s.db.ListMonth(ctx, 1, time.Date(2030, 4, 1, 0, 0, 0, 0, berlin))
+	s.Require().NoError(err)
+	s.Require().Len(events, 2)
+	for _, event := range events {
+		s.Require().Equal(9, event.Start.In(berlin).Hour(), event.Start)
+	}
+}
+
+func (s *Suite) TestListRange() {
+	ctx := context.Background()
+	first := s.create(newEvent(1, "Первое", monday.Add(10*time.Hour), time.Hour))
+	second := s.create(newEvent(1, "second", monday.Add(12*time.Hour), time.Hour))
+	// с одинаковым началом упорядочены по идентификатору
+	third := s.create(newEvent(1, "third", monday.Add(12*time.Hour), time.Hour))
+	other := s.create(newEvent(2, "other", monday.Add(11*time.Hour), time.Hour))
+	// пересекается с началом интервала
+	early := s.create(newEvent(1, "early", monday.Add(9*time.Hour+30*time.Minute), time.Hour))
+	// заканчивается ровно в начале интервала
+	s.create(newEvent(1, "before", monday.Add(9*time.Hour), time.Hour))
+	// начинается ровно в конце интервала
+	s.create(newEvent(1, "after", monday.Add(13*time.Hour), time.Hour))
+
+	from, to := monday.Add(10*time.Hour), monday.Add(13*time.Hour)
+	page, err := s.db.ListRange(ctx, from, to, storage.Filter{})
+	s.Require().NoError(err)
+	s.Require().Equal([]int{early, first, other, second, third}, ids(page.Events))
+	s.Require().Equal("", page.NextCursor)
+
+	page, err = s.db.ListRange(ctx, from, to, storage.Filter{UserID: 1, Desc: true})
+	s.Require().NoError(err)
+	s.Require().Equal([]int{third, second, first, early}, ids(page.Events))
+
+	page, err = s.db.ListRange(ctx, from, to, storage.Filter{Search: "первое"})
+	s.Require().NoError(err)
+	s.Require().Equal([]int{first}, ids(page.Events))
+}
+
+func (s *Suite) TestListRangePages() {
+	ctx := context.Background()
+	var expected []int
+	for i := 0; i < 7; i++ {
+		expected = append(expected, s.create(newEvent(1, "event", monday.Add(time.Duration(i/2)*time.Hour), time.Hour)))
+	}
+	s.create(newEvent(2, "other", monday, time.Hour))
+	from, to := monday, monday.AddDate(0, 0, 1)
+
+	for _, desc := range []bool{false, true} {
+		var result []int
+		filter := storage.Filter{UserID: 1, Desc: desc, Limit: 3}
+		for pages := 0; ; pages++ {
+			s.Require().Less(pages, 3)
+			page, err := s.db.ListRange(ctx, from, to, filter)
+			s.Require().NoError(err)
+			s.Require().LessOrEqual(len(page.Events), 3)
+			result = append(result, ids(page.Events)...)
+			if page.NextCursor == "" {
+				break
+			}
+			filter.Cursor = page.NextCursor
+		}
+		want := expected
+		if desc {
+			want = make([]int, len(expected))
+			for i, id := range expected {
+				want[len(expected)-1-i] = id
+			}
+		}
+		s.Require().Equal(want, result, "desc %v", desc)
+	}
+}
+
+func (s *Suite) TestListToNotify() {
+	ctx := context.Background()
+	notification := 15 * time.Minute
+	event := newEvent(1, "event", monday.Add(10*time.Hour), time.Hour)
+	event.Notification = &notification
+	id := s.create(event)
+	s.create(newEvent(1, "without notification", monday.Add(10*time.Hour), time.Hour))
+
+	daily, err := storage.NewRecurrence("FREQ=DAILY", nil)
+	s.Require().NoError(err)
+	recurring := newEvent(2, "daily", monday.AddDate(0, 0, -3).Add(10*time.Hour), time.Hour)
+	recurring.Notification = &notification
+	recurring.Recurrence = daily
+	recurringID := s.create(recurring)
+
+	tests := []struct {
+		name     string
+		from, to time.Time
+		ids      []int
+	}{
+		{"notification time", monday.Add(9*time.Hour + 45*time.Minute), monday.Add(9*time.Hour + 50*time.Minute), []int{id, recurringID}},
+		{"before", monday.Add(9*time.Hour + 40*time.Minute), monday.Add(9*time.Hour + 45*time.Minute), nil},
+		{"after", monday.Add(9*time.Hour + 46*time.Minute), monday.Add(10 * time.Hour), nil},
+		{"next day", monday.Add(33*time.Hour + 45*time.Minute), monday.Add(33*time.Hour + 46*time.Minute), []int{recurringID}},
+	}
+	for _, tt := range tests {
+		events, err := s.db.ListToNotify(ctx, tt.from, tt.to)
+		s.Require().NoError(err)
+		s.Require().ElementsMatch(tt.ids, ids(events), tt.name)
+	}
+}
@@ -0,0 +1,30 @@
+package storagetest
+
+import (
+	"context"
+
+	"github.com/anfilat/otus-go/hw12_13_14_15_calendar/internal/storage"
+)
+
+func (s *Suite) TestSettings() {
+	ctx := context.Background()
+	settings, err := s.db.GetSettings(ctx, 1)
+	s.Require().NoError(err)
+	s.Require().Equal(storage.UserSettings{}, settings)
+
+	s.Require().NoError(s.db.SaveSettings(ctx, 1, storage.UserSettings{TimeZone: "Europe/Moscow"}))
+	s.Require().NoError(s.db.SaveSettings(ctx, 2, storage.UserSettings{TimeZone: "Asia/Tokyo"}))
+	s.Require().NoError(s.db.SaveSettings(ctx, 1, storage.UserSettings{TimeZone: "America/New_York"}))
+
+	settings, err = s.db.GetSettings(ctx, 1)
+	s.Require().NoError(err)
+	s.Require().Equal("America/New_York", settings.TimeZone)
+	settings, err = s.db.GetSettings(ctx, 2)
+	s.Require().NoError(err)
+	s.Require().Equal("Asia/Tokyo", settings.TimeZone)
+
+	s.Require().NoError(s.db.DeleteAll(ctx))
+	settings, err = s.db.GetSettings(ctx, 1)
+	s.Require().NoError(err)
+	s.Require().Equal(storage.UserSettings{}, settings)
+}
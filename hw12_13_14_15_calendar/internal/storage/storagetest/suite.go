@@ -0,0 +1,76 @@
+// Package storagetest - общий набор тестов для реализаций storage.Storage.
+// Реализация подключается тестом в своем пакете:
+//
+//	func TestStorage(t *testing.T) {
+//		suite.Run(t, &storagetest.Suite{NewStorage: func(t *testing.T) storage.Storage {
+//			...
+//		}})
+//	}
+package storagetest
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/suite"
+
+	"github.com/anfilat/otus-go/hw12_13_14_15_calendar/internal/storage"
+)
+
+type Suite struct {
+	suite.Suite
+	// NewStorage возвращает подключенное хранилище. Перед каждым тестом
+	// события в нем удаляются, после теста оно закрывается.
+	NewStorage func(t *testing.T) storage.Storage
+
+	db storage.Storage
+}
+
+func (s *Suite) SetupTest() {
+	s.db = s.NewStorage(s.T())
+	s.Require().NoError(s.db.DeleteAll(context.Background()))
+}
+
+func (s *Suite) TearDownTest() {
+	ctx := context.Background()
+	s.Require().NoError(s.db.DeleteAll(ctx))
+	s.Require().NoError(s.db.Close(ctx))
+}
+
+// база для дат тестов, понедельник
+var monday = time.Date(2021, 3, 1, 0, 0, 0, 0, time.UTC)
+
+func newEvent(userID int, title string, start time.Time, duration time.Duration) storage.Event {
+	return storage.Event{
+		Title:   title,
+		Start:   start,
+		Stop:    start.Add(duration),
+		UserID:  userID,
+		Version: storage.InitialVersion,
+	}
+}
+
+func (s *Suite) create(event storage.Event) int {
+	id, err := s.db.Create(context.Background(), event)
+	s.Require().NoError(err)
+	return id
+}
+
+// ids возвращает идентификаторы событий по порядку.
+func ids(events []storage.Event) []int {
+	result := make([]int, 0, len(events))
+	for _, event := range events {
+		result = append(result, event.ID)
+	}
+	return result
+}
+
+// starts возвращает начала событий в UTC по порядку.
+func starts(events []storage.Event) []time.Time {
+	result := make([]time.Time, 0, len(events))
+	for _, event := range events {
+		result = append(result, event.Start.UTC())
+	}
+	return result
+}
@@ -0,0 +1,12 @@
+//go:build !darwin && !dragonfly && !freebsd && !linux && !netbsd && !openbsd && !windows
+// +build !darwin,!dragonfly,!freebsd,!linux,!netbsd,!openbsd,!windows
+
+package walstorage
+
+import "os"
+
+// lockFile ничего не делает: на этих системах блокировка каталога не поддерживается,
+// и не открывать его из двух процессов остается заботой администратора.
+func lockFile(_ *os.File) error {
+	return nil
+}
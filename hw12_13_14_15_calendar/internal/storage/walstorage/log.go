@@ -0,0 +1,95 @@
+package walstorage
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"io"
+	"time"
+
+	"github.com/anfilat/otus-go/hw12_13_14_15_calendar/internal/storage"
+)
+
+// Запись журнала: длина данных и их CRC32 (по 4 байта, big endian), затем данные в JSON.
+// Запись, оборванная при сбое или поврежденная, отбрасывается вместе со всем, что после нее.
+const (
+	headerSize = 8
+	// больше не бывает, такая длина - признак повреждения заголовка
+	maxRecordSize = 16 << 20
+)
+
+type op string
+
+const (
+	// событие целиком, после создания или изменения
+	opPut          op = "put"
+	opDelete       op = "delete"
+	opDeleteAll    op = "deleteAll"
+	opDeleteBefore op = "deleteBefore"
+	// настройки пользователя ID
+	opSaveSettings op = "saveSettings"
+)
+
+type record struct {
+	// номер записи, сквозной для журнала и снимков
+	Seq   uint64
+	Op    op
+	Event *storage.Event `json:",omitempty"`
+	ID    int            `json:",omitempty"`
+	Date  *time.Time     `json:",omitempty"`
+
+	Settings *storage.UserSettings `json:",omitempty"`
+}
+
+func encodeRecord(r record) ([]byte, error) {
+	payload, err := json.Marshal(r)
+	if err != nil {
+		return nil, fmt.Errorf("wal encode: %w", err)
+	}
+	result := make([]byte, headerSize+len(payload))
+	binary.BigEndian.PutUint32(result[0:4], uint32(len(payload)))
+	binary.BigEndian.PutUint32(result[4:8], crc32.ChecksumIEEE(payload))
+	copy(result[headerSize:], payload)
+	return result, nil
+}
+
+// readRecords передает в fn записи журнала до его конца или до первой оборванной
+// или поврежденной записи и возвращает смещение конца последней целой записи.
+func readRecords(r io.Reader, fn func(record) error) (int64, error) {
+	var offset int64
+	header := make([]byte, headerSize)
+	for {
+		if _, err := io.ReadFull(r, header); err != nil {
+			return offset, tailError(err)
+		}
+		size := binary.BigEndian.Uint32(header[0:4])
+		if size > maxRecordSize {
+			return offset, nil
+		}
+		payload := make([]byte, size)
+		if _, err := io.ReadFull(r, payload); err != nil {
+			return offset, tailError(err)
+		}
+		if crc32.ChecksumIEEE(payload) != binary.BigEndian.Uint32(header[4:8]) {
+			return offset, nil
+		}
+		var rec record
+		if err := json.Unmarshal(payload, &rec); err != nil {
+			return offset, nil
+		}
+		if err := fn(rec); err != nil {
+			return offset, err
+		}
+		offset += headerSize + int64(size)
+	}
+}
+
+// tailError отличает конец журнала, в том числе оборванный, от ошибки чтения.
+func tailError(err error) error {
+	if errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF) {
+		return nil
+	}
+	return fmt.Errorf("wal read: %w", err)
+}
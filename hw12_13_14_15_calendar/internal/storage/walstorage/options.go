@@ -0,0 +1,68 @@
+package walstorage
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	defaultFsyncInterval = time.Second
+	defaultSnapshotEvery = 1000
+)
+
+type options struct {
+	dir           string
+	fsync         string
+	fsyncInterval time.Duration
+	// число записей журнала, после которого сохраняется снимок
+	snapshotEvery int
+}
+
+// parseConnect разбирает строку подключения "каталог?параметры".
+func parseConnect(connect string) (options, error) {
+	result := options{
+		fsync:         FsyncAlways,
+		fsyncInterval: defaultFsyncInterval,
+		snapshotEvery: defaultSnapshotEvery,
+	}
+	dir, query := connect, ""
+	if i := strings.IndexByte(connect, '?'); i >= 0 {
+		dir, query = connect[:i], connect[i+1:]
+	}
+	if dir == "" {
+		return options{}, errors.New("wal directory is required")
+	}
+	result.dir = dir
+
+	values, err := url.ParseQuery(query)
+	if err != nil {
+		return options{}, fmt.Errorf("wal options: %w", err)
+	}
+	for key := range values {
+		value := values.Get(key)
+		switch key {
+		case "fsync":
+			if value != FsyncAlways && value != FsyncInterval && value != FsyncNever {
+				return options{}, fmt.Errorf("unknown wal fsync policy %q", value)
+			}
+			result.fsync = value
+		case "fsyncInterval":
+			result.fsyncInterval, err = time.ParseDuration(value)
+			if err != nil || result.fsyncInterval <= 0 {
+				return options{}, fmt.Errorf("invalid wal fsyncInterval %q", value)
+			}
+		case "snapshotEvery":
+			result.snapshotEvery, err = strconv.Atoi(value)
+			if err != nil || result.snapshotEvery <= 0 {
+				return options{}, fmt.Errorf("invalid wal snapshotEvery %q", value)
+			}
+		default:
+			return options{}, fmt.Errorf("unknown wal option %q", key)
+		}
+	}
+	return result, nil
+}
@@ -0,0 +1,26 @@
+// Package walstorage - встроенное хранилище для установок без сервера базы данных.
+// События хранятся в памяти (memorystorage), каждое изменение дописывается в журнал
+// упреждающей записи, а состояние периодически сохраняется в снимок. При подключении
+// загружается снимок и проигрываются записи журнала после него. Как и memorystorage,
+// хранилище принадлежит одному процессу: другие процессы не видят его изменений.
+//
+// Строка подключения - каталог с файлами хранилища и необязательные параметры:
+//
+//	/var/lib/calendar?fsync=interval&fsyncInterval=1s&snapshotEvery=1000
+package walstorage
+
+import "github.com/anfilat/otus-go/hw12_13_14_15_calendar/internal/storage"
+
+// Политики сброса журнала на диск, параметр fsync.
+const (
+	// FsyncAlways - fsync после каждой записи, подтвержденное изменение не теряется.
+	FsyncAlways = "always"
+	// FsyncInterval - fsync раз в fsyncInterval, при сбое теряются изменения последнего интервала.
+	FsyncInterval = "interval"
+	// FsyncNever - сброс на диск остается операционной системе.
+	FsyncNever = "never"
+)
+
+func New() storage.Storage {
+	return &store{}
+}
@@ -0,0 +1,562 @@
+package walstorage
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/anfilat/otus-go/hw12_13_14_15_calendar/internal/storage"
+	"github.com/anfilat/otus-go/hw12_13_14_15_calendar/internal/storage/memorystorage"
+)
+
+const (
+	logName      = "wal.log"
+	snapshotName = "snapshot.json"
+	lockName     = "lock"
+)
+
+var (
+	ErrClosed = errors.New("wal storage is closed")
+	ErrLocked = errors.New("wal dir is used by another process")
+)
+
+// Чтение идет прямо из памяти mem. Изменение, результат которого известен заранее
+// (удаления, настройки), сначала записывается в журнал, а потом применяется к памяти.
+// Создание и изменение события сначала выполняются в памяти, где выдается идентификатор,
+// проверяется занятость времени и версия, и откатываются, если запись в журнал не удалась.
+// Так в памяти не остается изменений, которых нет в журнале.
+type store struct {
+	mem memorystorage.Store
+
+	// упорядочивает изменения и записи журнала
+	mu   sync.Mutex
+	opts options
+	// файл блокировки каталога, удерживается до Close
+	lock *os.File
+	log  *os.File
+	// номер последней записи
+	seq uint64
+	// записей журнала после последнего снимка
+	sinceSnapshot int
+	// есть записи, не сброшенные на диск
+	dirty bool
+	// ошибка записи журнала; после нее изменения не принимаются,
+	// потому что память и диск могут расходиться
+	err error
+	// ошибка сохранения снимка, журнал при этом цел
+	snapshotErr error
+
+	stop chan struct{}
+	done chan struct{}
+}
+
+// snapshotFile - содержимое файла снимка. Seq - номер последней вошедшей в него записи журнала.
+type snapshotFile struct {
+	Seq uint64
+	memorystorage.Snapshot
+}
+
+func (s *store) Connect(ctx context.Context, connect string) error {
+	opts, err := parseConnect(connect)
+	if err != nil {
+		return err
+	}
+	s.opts = opts
+	s.mem = memorystorage.NewStore()
+
+	if err := os.MkdirAll(opts.dir, 0o755); err != nil {
+		return fmt.Errorf("wal dir: %w", err)
+	}
+	if err := s.lockDir(); err != nil {
+		return err
+	}
+	err = s.loadSnapshot()
+	if err == nil {
+		err = s.openLog(ctx)
+	}
+	if err != nil {
+		s.unlockDir()
+		return err
+	}
+
+	if opts.fsync == FsyncInterval {
+		s.stop = make(chan struct{})
+		s.done = make(chan struct{})
+		go s.syncLoop()
+	}
+	return nil
+}
+
+// lockDir не дает второму процессу открыть тот же каталог: у каждого была бы своя
+// копия данных в памяти, и их записи журнала перемешались бы.
+func (s *store) lockDir() error {
+	file, err := os.OpenFile(filepath.Join(s.opts.dir, lockName), os.O_RDWR|os.O_CREATE, 0o644)
+	if err != nil {
+		return fmt.Errorf("wal lock: %w", err)
+	}
+	if err := lockFile(file); err != nil {
+		file.Close()
+		if errors.Is(err, ErrLocked) {
+			return err
+		}
+		return fmt.Errorf("wal lock: %w", err)
+	}
+	s.lock = file
+	return nil
+}
+
+// unlockDir снимает блокировку закрытием файла.
+func (s *store) unlockDir() {
+	if s.lock != nil {
+		s.lock.Close()
+		s.lock = nil
+	}
+}
+
+func (s *store) loadSnapshot() error {
+	data, err := os.ReadFile(filepath.Join(s.opts.dir, snapshotName))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("wal snapshot: %w", err)
+	}
+	var snapshot snapshotFile
+	if err := json.Unmarshal(data, &snapshot); err != nil {
+		return fmt.Errorf("wal snapshot: %w", err)
+	}
+	s.mem.Restore(snapshot.Snapshot)
+	s.seq = snapshot.Seq
+	return nil
+}
+
+// openLog проигрывает журнал и отрезает от него оборванный при сбое хвост.
+func (s *store) openLog(ctx context.Context) error {
+	file, err := os.OpenFile(filepath.Join(s.opts.dir, logName), os.O_RDWR|os.O_CREATE|os.O_APPEND, 0o644)
+	if err != nil {
+		return fmt.Errorf("wal open: %w", err)
+	}
+	offset, err := readRecords(file, func(r record) error {
+		// записи до снимка уже в нем, если сбой случился между снимком и очисткой журнала
+		if r.Seq <= s.seq {
+			return nil
+		}
+		s.seq = r.Seq
+		s.sinceSnapshot++
+		return s.apply(ctx, r)
+	})
+	if err == nil {
+		err = file.Truncate(offset)
+	}
+	if err != nil {
+		file.Close()
+		return fmt.Errorf("wal replay: %w", err)
+	}
+	s.log = file
+	return nil
+}
+
+func (s *store) apply(ctx context.Context, r record) error {
+	switch r.Op {
+	case opPut:
+		if r.Event == nil {
+			return errors.New("put without event")
+		}
+		s.mem.Put(*r.Event)
+		return nil
+	case opDelete:
+		// журналы старых версий записывали и удаления отсутствующих событий
+		if err := s.mem.Delete(ctx, r.ID); err != nil && !errors.Is(err, storage.ErrNotExistsEvent) {
+			return err
+		}
+		return nil
+	case opDeleteAll:
+		return s.mem.DeleteAll(ctx)
+	case opDeleteBefore:
+		if r.Date == nil {
+			return errors.New("deleteBefore without date")
+		}
+		_, err := s.mem.DeleteBefore(ctx, *r.Date)
+		return err
+	case opSaveSettings:
+		if r.Settings == nil {
+			return errors.New("saveSettings without settings")
+		}
+		return s.mem.SaveSettings(ctx, r.ID, *r.Settings)
+	default:
+		return fmt.Errorf("unknown operation %q", r.Op)
+	}
+}
+
+// syncLoop сбрасывает журнал на диск раз в fsyncInterval.
+func (s *store) syncLoop() {
+	defer close(s.done)
+	ticker := time.NewTicker(s.opts.fsyncInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-s.stop:
+			return
+		case <-ticker.C:
+			s.mu.Lock()
+			if s.dirty && s.err == nil {
+				s.sync()
+			}
+			s.mu.Unlock()
+		}
+	}
+}
+
+func (s *store) sync() {
+	if err := s.log.Sync(); err != nil {
+		s.err = fmt.Errorf("wal sync: %w", err)
+		return
+	}
+	s.dirty = false
+}
+
+// Close сохраняет снимок, чтобы следующее подключение не проигрывало журнал.
+func (s *store) Close(_ context.Context) error {
+	if s.stop != nil {
+		close(s.stop)
+		<-s.done
+		s.stop = nil
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.log == nil {
+		return nil
+	}
+	err := s.err
+	if err == nil {
+		err = s.snapshot()
+	}
+	if closeErr := s.log.Close(); err == nil && closeErr != nil {
+		err = fmt.Errorf("wal close: %w", closeErr)
+	}
+	s.log = nil
+	s.unlockDir()
+	return err
+}
+
+func (s *store) Ping(_ context.Context) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	switch {
+	case s.log == nil:
+		return ErrClosed
+	case s.err != nil:
+		return s.err
+	default:
+		return s.snapshotErr
+	}
+}
+
+func (s *store) Create(ctx context.Context, event storage.Event) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	defer s.snapshotIfNeeded()
+
+	if err := s.writable(); err != nil {
+		return 0, err
+	}
+	id, err := s.mem.Create(ctx, event)
+	if err != nil {
+		return 0, err
+	}
+	return id, s.appendCreated(ctx, id)
+}
+
+func (s *store) CreateIfFree(ctx context.Context, event storage.Event) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	defer s.snapshotIfNeeded()
+
+	if err := s.writable(); err != nil {
+		return 0, err
+	}
+	id, err := s.mem.CreateIfFree(ctx, event)
+	if err != nil {
+		return 0, err
+	}
+	return id, s.appendCreated(ctx, id)
+}
+
+func (s *store) Update(ctx context.Context, id int, change storage.Event) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	defer s.snapshotIfNeeded()
+
+	if err := s.writable(); err != nil {
+		return err
+	}
+	old, err := s.mem.Get(ctx, id)
+	if err != nil {
+		return err
+	}
+	if err := s.mem.Update(ctx, id, change); err != nil {
+		return err
+	}
+	return s.appendUpdated(ctx, old)
+}
+
+func (s *store) UpdateIfFree(ctx context.Context, id int, change storage.Event) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	defer s.snapshotIfNeeded()
+
+	if err := s.writable(); err != nil {
+		return err
+	}
+	old, err := s.mem.Get(ctx, id)
+	if err != nil {
+		return err
+	}
+	if err := s.mem.UpdateIfFree(ctx, id, change); err != nil {
+		return err
+	}
+	return s.appendUpdated(ctx, old)
+}
+
+func (s *store) Delete(ctx context.Context, id int) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	defer s.snapshotIfNeeded()
+
+	if err := s.writable(); err != nil {
+		return err
+	}
+	if _, err := s.mem.Get(ctx, id); err != nil {
+		return err
+	}
+	if err := s.append(record{Op: opDelete, ID: id}); err != nil {
+		return err
+	}
+	return s.mem.Delete(ctx, id)
+}
+
+func (s *store) DeleteAll(ctx context.Context) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	defer s.snapshotIfNeeded()
+
+	if err := s.writable(); err != nil {
+		return err
+	}
+	if err := s.append(record{Op: opDeleteAll}); err != nil {
+		return err
+	}
+	return s.mem.DeleteAll(ctx)
+}
+
+func (s *store) DeleteBefore(ctx context.Context, date time.Time) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	defer s.snapshotIfNeeded()
+
+	if err := s.writable(); err != nil {
+		return 0, err
+	}
+	events, err := s.mem.ListBefore(ctx, date)
+	if err != nil || len(events) == 0 {
+		return 0, err
+	}
+	if err := s.append(record{Op: opDeleteBefore, Date: &date}); err != nil {
+		return 0, err
+	}
+	return s.mem.DeleteBefore(ctx, date)
+}
+
+func (s *store) SaveSettings(ctx context.Context, userID int, settings storage.UserSettings) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	defer s.snapshotIfNeeded()
+
+	if err := s.writable(); err != nil {
+		return err
+	}
+	if err := s.append(record{Op: opSaveSettings, ID: userID, Settings: &settings}); err != nil {
+		return err
+	}
+	return s.mem.SaveSettings(ctx, userID, settings)
+}
+
+func (s *store) Get(ctx context.Context, id int) (storage.Event, error) {
+	return s.mem.Get(ctx, id)
+}
+
+func (s *store) ListAll(ctx context.Context) ([]storage.Event, error) {
+	return s.mem.ListAll(ctx)
+}
+
+func (s *store) ListDay(ctx context.Context, userID int, date time.Time) ([]storage.Event, error) {
+	return s.mem.ListDay(ctx, userID, date)
+}
+
+func (s *store) ListWeek(ctx context.Context, userID int, date time.Time) ([]storage.Event, error) {
+	return s.mem.ListWeek(ctx, userID, date)
+}
+
+func (s *store) ListMonth(ctx context.Context, userID int, date time.Time) ([]storage.Event, error) {
+	return s.mem.ListMonth(ctx, userID, date)
+}
+
+func (s *store) ListRange(ctx context.Context, from, to time.Time, filter storage.Filter) (storage.Page, error) {
+	return s.mem.ListRange(ctx, from, to, filter)
+}
+
+func (s *store) ListToNotify(ctx context.Context, from, to time.Time) ([]storage.Event, error) {
+	return s.mem.ListToNotify(ctx, from, to)
+}
+
+func (s *store) ListBefore(ctx context.Context, date time.Time) ([]storage.Event, error) {
+	return s.mem.ListBefore(ctx, date)
+}
+
+func (s *store) IsTimeBusy(ctx context.Context, userID int, start, stop time.Time, excludeID int) (bool, error) {
+	return s.mem.IsTimeBusy(ctx, userID, start, stop, excludeID)
+}
+
+func (s *store) GetSettings(ctx context.Context, userID int) (storage.UserSettings, error) {
+	return s.mem.GetSettings(ctx, userID)
+}
+
+func (s *store) writable() error {
+	if s.log == nil {
+		return ErrClosed
+	}
+	return s.err
+}
+
+// appendCreated записывает в журнал созданное событие id,
+// а если запись не удалась - удаляет его из памяти.
+func (s *store) appendCreated(ctx context.Context, id int) error {
+	err := s.appendPut(ctx, id)
+	if err != nil {
+		//nolint:errcheck
+		s.mem.Delete(ctx, id)
+	}
+	return err
+}
+
+// appendUpdated записывает в журнал измененное событие,
+// а если запись не удалась - возвращает в память прежнее old.
+func (s *store) appendUpdated(ctx context.Context, old storage.Event) error {
+	err := s.appendPut(ctx, old.ID)
+	if err != nil {
+		s.mem.Put(old)
+	}
+	return err
+}
+
+// appendPut записывает в журнал событие в том виде, в каком оно сохранено в памяти.
+func (s *store) appendPut(ctx context.Context, id int) error {
+	event, err := s.mem.Get(ctx, id)
+	if err != nil {
+		return err
+	}
+	return s.append(record{Op: opPut, Event: &event})
+}
+
+func (s *store) append(r record) error {
+	s.seq++
+	r.Seq = s.seq
+	data, err := encodeRecord(r)
+	if err != nil {
+		s.err = err
+		return err
+	}
+	if _, err := s.log.Write(data); err != nil {
+		s.err = fmt.Errorf("wal write: %w", err)
+		return s.err
+	}
+	s.dirty = true
+	if s.opts.fsync == FsyncAlways {
+		s.sync()
+		if s.err != nil {
+			return s.err
+		}
+	}
+
+	s.sinceSnapshot++
+	return nil
+}
+
+// snapshotIfNeeded сохраняет снимок, когда в журнале накопилось snapshotEvery записей.
+// Вызывается после того, как изменение применено к памяти, иначе снимок его не содержал бы,
+// а журнал с ним был бы очищен.
+func (s *store) snapshotIfNeeded() {
+	if s.err != nil || s.sinceSnapshot < s.opts.snapshotEvery {
+		return
+	}
+	// изменение уже в журнале, поэтому ошибка снимка не отменяет его;
+	// снимок повторится со следующей записью, а ошибку покажет Ping
+	s.snapshotErr = s.snapshot()
+}
+
+// snapshot сохраняет состояние в снимок и очищает журнал.
+// Снимок пишется во временный файл и переименовывается, так что после сбоя
+// на диске остается либо старый, либо новый снимок целиком.
+func (s *store) snapshot() error {
+	data, err := json.Marshal(snapshotFile{Seq: s.seq, Snapshot: s.mem.Snapshot()})
+	if err != nil {
+		return fmt.Errorf("wal snapshot: %w", err)
+	}
+	path := filepath.Join(s.opts.dir, snapshotName)
+	if err := writeFileSync(path+".tmp", data); err != nil {
+		return fmt.Errorf("wal snapshot: %w", err)
+	}
+	if err := os.Rename(path+".tmp", path); err != nil {
+		return fmt.Errorf("wal snapshot: %w", err)
+	}
+	if err := syncDir(s.opts.dir); err != nil {
+		return fmt.Errorf("wal snapshot: %w", err)
+	}
+
+	if err := s.log.Truncate(0); err != nil {
+		return fmt.Errorf("wal truncate: %w", err)
+	}
+	if s.opts.fsync != FsyncNever {
+		if err := s.log.Sync(); err != nil {
+			return fmt.Errorf("wal sync: %w", err)
+		}
+	}
+	s.dirty = false
+	s.sinceSnapshot = 0
+	return nil
+}
+
+func writeFileSync(path string, data []byte) error {
+	file, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0o644)
+	if err != nil {
+		return err
+	}
+	if _, err := file.Write(data); err != nil {
+		file.Close()
+		return err
+	}
+	if err := file.Sync(); err != nil {
+		file.Close()
+		return err
+	}
+	return file.Close()
+}
+
+// syncDir сохраняет на диске переименование файла в каталоге.
+func syncDir(dir string) error {
+	file, err := os.Open(dir)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+	return file.Sync()
+}
@@ -0,0 +1,393 @@
+package walstorage
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/anfilat/otus-go/hw12_13_14_15_calendar/internal/storage"
+)
+
+func open(t *testing.T, connect string) *store {
+	s := &store{}
+	require.NoError(t, s.Connect(context.Background(), connect))
+	return s
+}
+
+// crash закрывает журнал без снимка, как при аварийном завершении.
+// Блокировку каталога при этом снимает операционная система.
+func crash(t *testing.T, s *store) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	require.NoError(t, s.log.Close())
+	s.log = nil
+	s.unlockDir()
+}
+
+func newEvent(title string, start time.Time) storage.Event {
+	notification := 15 * time.Minute
+	return storage.Event{
+		Title:        title,
+		Start:        start,
+		Stop:         start.Add(time.Hour),
+		UserID:       1,
+		Notification: &notification,
+	}
+}
+
+var start = time.Date(2021, 3, 1, 10, 0, 0, 0, time.UTC)
+
+func TestReplay(t *testing.T) {
+	ctx := context.Background()
+	dir := t.TempDir()
+	s := open(t, dir)
+
+	id1, err := s.Create(ctx, newEvent("first", start))
+	require.NoError(t, err)
+	id2, err := s.CreateIfFree(ctx, newEvent("second", start.Add(2*time.Hour)))
+	require.NoError(t, err)
+	id3, err := s.Create(ctx, newEvent("third", start.Add(4*time.Hour)))
+	require.NoError(t, err)
+
+	change := newEvent("first changed", start)
+	change.Version = storage.InitialVersion
+	require.NoError(t, s.Update(ctx, id1, change))
+	require.NoError(t, s.Delete(ctx, id2))
+	count, err := s.DeleteBefore(ctx, start.Add(5*time.Hour+30*time.Minute))
+	require.NoError(t, err)
+	require.Equal(t, 2, count)
+	id4, err := s.Create(ctx, newEvent("fourth", start.Add(24*time.Hour)))
+	require.NoError(t, err)
+	crash(t, s)
+
+	s = open(t, dir)
+	defer s.Close(ctx)
+	events, err := s.ListAll(ctx)
+	require.NoError(t, err)
+	require.Len(t, events, 1)
+	require.Equal(t, id4, events[0].ID)
+	require.Equal(t, "fourth", events[0].Title)
+	require.Equal(t, 15*time.Minute, *events[0].Notification)
+	require.True(t, start.Add(24*time.Hour).Equal(events[0].Start))
+
+	// идентификаторы не выдаются повторно
+	id5, err := s.Create(ctx, newEvent("fifth", start))
+	require.NoError(t, err)
+	require.Greater(t, id5, id4)
+	require.NotContains(t, []int{id1, id2, id3}, id5)
+}
+
+func TestReplayVersions(t *testing.T) {
+	ctx := context.Background()
+	dir := t.TempDir()
+	s := open(t, dir)
+
+	id, err := s.Create(ctx, newEvent("event", start))
+	require.NoError(t, err)
+	change := newEvent("event", start)
+	change.Version = storage.InitialVersion
+	require.NoError(t, s.UpdateIfFree(ctx, id, change))
+	crash(t, s)
+
+	s = open(t, dir)
+	defer s.Close(ctx)
+	event, err := s.Get(ctx, id)
+	require.NoError(t, err)
+	require.Equal(t, storage.InitialVersion+1, event.Version)
+	// устаревшая версия по-прежнему отклоняется
+	require.True(t, errors.Is(s.Update(ctx, id, change), storage.ErrConflict))
+}
+
+func TestReplaySettings(t *testing.T) {
+	ctx := context.Background()
+	dir := t.TempDir()
+	s := open(t, dir)
+
+	require.NoError(t, s.SaveSettings(ctx, 1, storage.UserSettings{TimeZone: "Europe/Moscow"}))
+	require.NoError(t, s.SaveSettings(ctx, 2, storage.UserSettings{TimeZone: "Asia/Tokyo"}))
+	// в снимок, журнал после него
+	require.NoError(t, s.Close(ctx))
+	s = open(t, dir)
+	require.NoError(t, s.SaveSettings(ctx, 2, storage.UserSettings{TimeZone: "America/New_York"}))
+	crash(t, s)
+
+	s = open(t, dir)
+	defer s.Close(ctx)
+	settings, err := s.GetSettings(ctx, 1)
+	require.NoError(t, err)
+	require.Equal(t, "Europe/Moscow", settings.TimeZone)
+	settings, err = s.GetSettings(ctx, 2)
+	require.NoError(t, err)
+	require.Equal(t, "America/New_York", settings.TimeZone)
+}
+
+func TestSnapshot(t *testing.T) {
+	ctx := context.Background()
+	dir := t.TempDir()
+	s := open(t, dir+"?snapshotEvery=3")
+
+	for i := 0; i < 4; i++ {
+		_, err := s.Create(ctx, newEvent("event", start.AddDate(0, 0, i)))
+		require.NoError(t, err)
+	}
+	require.FileExists(t, filepath.Join(dir, snapshotName))
+	// в журнале только запись после снимка
+	require.Equal(t, int64(recordSize(t, s, 4)), fileSize(t, filepath.Join(dir, logName)))
+	crash(t, s)
+
+	s = open(t, dir)
+	events, err := s.ListAll(ctx)
+	require.NoError(t, err)
+	require.Len(t, events, 4)
+
+	// Close сохраняет снимок и очищает журнал
+	require.NoError(t, s.Close(ctx))
+	require.Equal(t, int64(0), fileSize(t, filepath.Join(dir, logName)))
+	require.True(t, errors.Is(s.Ping(ctx), ErrClosed))
+
+	s = open(t, dir)
+	defer s.Close(ctx)
+	events, err = s.ListAll(ctx)
+	require.NoError(t, err)
+	require.Len(t, events, 4)
+}
+
+func TestSnapshotBeforeLogTruncate(t *testing.T) {
+	ctx := context.Background()
+	dir := t.TempDir()
+	s := open(t, dir)
+
+	_, err := s.Create(ctx, newEvent("first", start))
+	require.NoError(t, err)
+	_, err = s.Create(ctx, newEvent("second", start.AddDate(0, 0, 1)))
+	require.NoError(t, err)
+	log, err := os.ReadFile(filepath.Join(dir, logName))
+	require.NoError(t, err)
+	require.NoError(t, s.Close(ctx))
+
+	// сбой между сохранением снимка и очисткой журнала
+	require.NoError(t, os.WriteFile(filepath.Join(dir, logName), log, 0o644))
+
+	s = open(t, dir)
+	defer s.Close(ctx)
+	events, err := s.ListAll(ctx)
+	require.NoError(t, err)
+	require.Len(t, events, 2)
+	id, err := s.Create(ctx, newEvent("third", start.AddDate(0, 0, 2)))
+	require.NoError(t, err)
+	require.Equal(t, 3, id)
+}
+
+func TestSnapshotAfterDelete(t *testing.T) {
+	ctx := context.Background()
+	dir := t.TempDir()
+	s := open(t, dir+"?snapshotEvery=2")
+
+	id, err := s.Create(ctx, newEvent("event", start))
+	require.NoError(t, err)
+	// удаление сначала пишется в журнал, а снимок после него должен его уже содержать
+	require.NoError(t, s.Delete(ctx, id))
+	require.Equal(t, int64(0), fileSize(t, filepath.Join(dir, logName)))
+	crash(t, s)
+
+	s = open(t, dir)
+	defer s.Close(ctx)
+	events, err := s.ListAll(ctx)
+	require.NoError(t, err)
+	require.Empty(t, events)
+}
+
+func TestWriteError(t *testing.T) {
+	ctx := context.Background()
+	dir := t.TempDir()
+	s := open(t, dir)
+	defer s.unlockDir()
+
+	id, err := s.Create(ctx, newEvent("event", start))
+	require.NoError(t, err)
+	require.NoError(t, s.log.Close())
+
+	// изменения, не попавшие в журнал, не остаются в памяти
+	_, err = s.Create(ctx, newEvent("created", start.AddDate(0, 0, 1)))
+	require.Error(t, err)
+	require.Error(t, s.Ping(ctx))
+
+	s.err = nil
+	change := newEvent("changed", start)
+	change.Version = storage.InitialVersion
+	require.Error(t, s.Update(ctx, id, change))
+
+	s.err = nil
+	require.Error(t, s.Delete(ctx, id))
+
+	s.err = nil
+	require.Error(t, s.DeleteAll(ctx))
+
+	events, err := s.ListAll(ctx)
+	require.NoError(t, err)
+	require.Len(t, events, 1)
+	require.Equal(t, id, events[0].ID)
+	require.Equal(t, "event", events[0].Title)
+	require.Equal(t, storage.InitialVersion, events[0].Version)
+}
+
+func TestTruncatedLog(t *testing.T) {
+	ctx := context.Background()
+
+	tests := []struct {
+		name string
+		// сколько байт последней записи осталось в журнале
+		keep func(size int) int
+	}{
+		{"empty tail", func(int) int { return 0 }},
+		{"mid header", func(int) int { return headerSize / 2 }},
+		{"header only", func(int) int { return headerSize }},
+		{"mid payload", func(size int) int { return size / 2 }},
+		{"last byte lost", func(size int) int { return size - 1 }},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			s := open(t, dir)
+			for i := 0; i < 3; i++ {
+				_, err := s.Create(ctx, newEvent("event", start.AddDate(0, 0, i)))
+				require.NoError(t, err)
+			}
+			last := recordSize(t, s, 3)
+			crash(t, s)
+
+			path := filepath.Join(dir, logName)
+			size := fileSize(t, path)
+			require.NoError(t, os.Truncate(path, size-int64(last)+int64(tt.keep(last))))
+
+			s = open(t, dir)
+			events, err := s.ListAll(ctx)
+			require.NoError(t, err)
+			require.Len(t, events, 2)
+			// оборванный хвост отрезан, новые записи читаются после него
+			require.Equal(t, size-int64(last), fileSize(t, path))
+			id, err := s.Create(ctx, newEvent("new", start.AddDate(0, 0, 5)))
+			require.NoError(t, err)
+			require.Equal(t, 3, id)
+			crash(t, s)
+
+			s = open(t, dir)
+			defer s.Close(ctx)
+			event, err := s.Get(ctx, id)
+			require.NoError(t, err)
+			require.Equal(t, "new", event.Title)
+		})
+	}
+}
+
+func TestCorruptedRecord(t *testing.T) {
+	ctx := context.Background()
+	dir := t.TempDir()
+	s := open(t, dir)
+	for i := 0; i < 3; i++ {
+		_, err := s.Create(ctx, newEvent("event", start.AddDate(0, 0, i)))
+		require.NoError(t, err)
+	}
+	crash(t, s)
+
+	path := filepath.Join(dir, logName)
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	data[len(data)-2] ^= 0xff
+	require.NoError(t, os.WriteFile(path, data, 0o644))
+
+	s = open(t, dir)
+	defer s.Close(ctx)
+	events, err := s.ListAll(ctx)
+	require.NoError(t, err)
+	require.Len(t, events, 2)
+}
+
+func TestFsyncInterval(t *testing.T) {
+	ctx := context.Background()
+	dir := t.TempDir()
+	s := open(t, dir+"?fsync=interval&fsyncInterval=10ms")
+
+	_, err := s.Create(ctx, newEvent("event", start))
+	require.NoError(t, err)
+	require.Eventually(t, func() bool {
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		return !s.dirty
+	}, time.Second, 10*time.Millisecond)
+	require.NoError(t, s.Ping(ctx))
+	require.NoError(t, s.Close(ctx))
+
+	s = open(t, dir)
+	defer s.Close(ctx)
+	events, err := s.ListAll(ctx)
+	require.NoError(t, err)
+	require.Len(t, events, 1)
+}
+
+func TestLockDir(t *testing.T) {
+	ctx := context.Background()
+	dir := t.TempDir()
+	s := open(t, dir)
+
+	err := (&store{}).Connect(ctx, dir)
+	require.True(t, errors.Is(err, ErrLocked))
+
+	require.NoError(t, s.Close(ctx))
+	s = open(t, dir)
+	require.NoError(t, s.Close(ctx))
+}
+
+func TestParseConnect(t *testing.T) {
+	opts, err := parseConnect("/var/lib/calendar")
+	require.NoError(t, err)
+	require.Equal(t, options{
+		dir:           "/var/lib/calendar",
+		fsync:         FsyncAlways,
+		fsyncInterval: defaultFsyncInterval,
+		snapshotEvery: defaultSnapshotEvery,
+	}, opts)
+
+	opts, err = parseConnect("data?fsync=interval&fsyncInterval=200ms&snapshotEvery=10")
+	require.NoError(t, err)
+	require.Equal(t, options{
+		dir:           "data",
+		fsync:         FsyncInterval,
+		fsyncInterval: 200 * time.Millisecond,
+		snapshotEvery: 10,
+	}, opts)
+
+	for _, connect := range []string{
+		"",
+		"?fsync=never",
+		"data?fsync=sometimes",
+		"data?fsyncInterval=0s",
+		"data?snapshotEvery=-1",
+		"data?cache=1",
+	} {
+		_, err := parseConnect(connect)
+		require.Error(t, err, connect)
+	}
+}
+
+// recordSize возвращает размер записи журнала о событии id.
+// Номер записи совпадает с id, если в журнал писались только создания событий.
+func recordSize(t *testing.T, s *store, id int) int {
+	event, err := s.Get(context.Background(), id)
+	require.NoError(t, err)
+	data, err := encodeRecord(record{Seq: uint64(id), Op: opPut, Event: &event})
+	require.NoError(t, err)
+	return len(data)
+}
+
+func fileSize(t *testing.T, path string) int64 {
+	info, err := os.Stat(path)
+	require.NoError(t, err)
+	return info.Size()
+}
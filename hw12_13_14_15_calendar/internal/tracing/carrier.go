@@ -0,0 +1,60 @@
+package tracing
+
+import (
+	"context"
+	"net/http"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/propagation"
+	"google.golang.org/grpc/metadata"
+)
+
+// ExtractHTTP возвращает контекст с контекстом трассировки из заголовков запроса.
+func ExtractHTTP(ctx context.Context, header http.Header) context.Context {
+	return otel.GetTextMapPropagator().Extract(ctx, propagation.HeaderCarrier(header))
+}
+
+// InjectHTTP добавляет контекст трассировки в заголовки запроса.
+func InjectHTTP(ctx context.Context, header http.Header) {
+	otel.GetTextMapPropagator().Inject(ctx, propagation.HeaderCarrier(header))
+}
+
+// ExtractGRPC возвращает контекст с контекстом трассировки из метаданных входящего запроса.
+func ExtractGRPC(ctx context.Context) context.Context {
+	md, _ := metadata.FromIncomingContext(ctx)
+	return otel.GetTextMapPropagator().Extract(ctx, metadataCarrier(md))
+}
+
+// InjectGRPC добавляет контекст трассировки в метаданные исходящего запроса.
+func InjectGRPC(ctx context.Context) context.Context {
+	md, ok := metadata.FromOutgoingContext(ctx)
+	if ok {
+		md = md.Copy()
+	} else {
+		md = metadata.MD{}
+	}
+	otel.GetTextMapPropagator().Inject(ctx, metadataCarrier(md))
+	return metadata.NewOutgoingContext(ctx, md)
+}
+
+type metadataCarrier metadata.MD
+
+func (c metadataCarrier) Get(key string) string {
+	values := metadata.MD(c).Get(key)
+	if len(values) == 0 {
+		return ""
+	}
+	return values[0]
+}
+
+func (c metadataCarrier) Set(key string, value string) {
+	metadata.MD(c).Set(key, value)
+}
+
+func (c metadataCarrier) Keys() []string {
+	keys := make([]string, 0, len(c))
+	for key := range c {
+		keys = append(keys, key)
+	}
+	return keys
+}
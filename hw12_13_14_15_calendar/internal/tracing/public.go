@@ -0,0 +1,44 @@
+// Package tracing настраивает трассировку OpenTelemetry и передачу контекста трассировки
+// в заголовках traceparent (W3C Trace Context) HTTP запросов и метаданных gRPC.
+package tracing
+
+import (
+	"context"
+	"errors"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const instrumentationName = "github.com/anfilat/otus-go/hw12_13_14_15_calendar"
+
+// экспортеры спанов
+const (
+	ExporterNone   = "none"
+	ExporterStdout = "stdout"
+	ExporterFile   = "file"
+)
+
+var ErrUnknownExporter = errors.New("unknown trace exporter")
+
+// Init устанавливает глобальные TracerProvider и пропагатор.
+// Возвращает функцию, которая выгружает накопленные спаны и закрывает экспортер.
+// С ExporterNone спаны не записываются, но контекст трассировки передается дальше.
+func Init(exporter, file, service string) (shutdown func(ctx context.Context) error, err error) {
+	return initTracing(exporter, file, service)
+}
+
+// Start начинает спан глобального TracerProvider.
+func Start(ctx context.Context, name string, opts ...trace.SpanOption) (context.Context, trace.Span) {
+	return otel.Tracer(instrumentationName).Start(ctx, name, opts...)
+}
+
+// End завершает спан, отмечая в нем ошибку.
+func End(span trace.Span, err error) {
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	span.End()
+}
@@ -0,0 +1,71 @@
+package tracing
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log"
+	"os"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/exporters/stdout"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/semconv"
+)
+
+func initTracing(exporter, file, service string) (func(ctx context.Context) error, error) {
+	otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator(
+		propagation.TraceContext{},
+		propagation.Baggage{},
+	))
+
+	var w io.Writer
+	closeWriter := func() error { return nil }
+	switch exporter {
+	case "", ExporterNone:
+		return func(ctx context.Context) error { return nil }, nil
+	case ExporterStdout:
+		w = os.Stdout
+	case ExporterFile:
+		f, err := os.OpenFile(file, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o644)
+		if err != nil {
+			return nil, fmt.Errorf("failed to open trace file: %w", err)
+		}
+		w = f
+		closeWriter = f.Close
+	default:
+		return nil, fmt.Errorf("%w: %q", ErrUnknownExporter, exporter)
+	}
+
+	exp, err := stdout.NewExporter(stdout.WithWriter(w), stdout.WithoutMetricExport())
+	if err != nil {
+		_ = closeWriter()
+		return nil, fmt.Errorf("failed to create trace exporter: %w", err)
+	}
+	otel.SetErrorHandler(errorHandler{})
+	provider := sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(exp),
+		sdktrace.WithResource(resource.NewWithAttributes(semconv.ServiceNameKey.String(service))),
+	)
+	otel.SetTracerProvider(provider)
+
+	return func(ctx context.Context) error {
+		err := provider.Shutdown(ctx)
+		if closeErr := closeWriter(); err == nil {
+			err = closeErr
+		}
+		return err
+	}, nil
+}
+
+// errorHandler пишет ошибки OpenTelemetry в стандартный лог.
+// resource.Environment в v0.20 передает обработчику nil, такие вызовы пропускаются.
+type errorHandler struct{}
+
+func (errorHandler) Handle(err error) {
+	if err != nil {
+		log.Print("tracing: ", err)
+	}
+}
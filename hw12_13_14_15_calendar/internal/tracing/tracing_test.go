@@ -0,0 +1,51 @@
+package tracing
+
+import (
+	"context"
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel"
+)
+
+func TestInitFile(t *testing.T) {
+	provider := otel.GetTracerProvider()
+	defer otel.SetTracerProvider(provider)
+
+	dir, err := ioutil.TempDir("", "tracing")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+	file := filepath.Join(dir, "trace.log")
+
+	shutdown, err := Init(ExporterFile, file, "calendar-test")
+	require.NoError(t, err)
+
+	ctx, span := Start(context.Background(), "parent")
+	_, child := Start(ctx, "child")
+	End(child, errors.New("some error"))
+	End(span, nil)
+
+	require.NoError(t, shutdown(context.Background()))
+
+	data, err := ioutil.ReadFile(file)
+	require.NoError(t, err)
+	require.Contains(t, string(data), `"Name":"parent"`)
+	require.Contains(t, string(data), `"Name":"child"`)
+	require.Contains(t, string(data), "some error")
+	require.Contains(t, string(data), "calendar-test")
+}
+
+func TestInitNone(t *testing.T) {
+	shutdown, err := Init(ExporterNone, "", "calendar-test")
+	require.NoError(t, err)
+	require.NoError(t, shutdown(context.Background()))
+}
+
+func TestInitUnknown(t *testing.T) {
+	_, err := Init("jaeger", "", "calendar-test")
+	require.True(t, errors.Is(err, ErrUnknownExporter))
+}
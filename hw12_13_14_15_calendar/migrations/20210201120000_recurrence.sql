@@ -0,0 +1,15 @@
+-- +goose Up
+-- SQL in this section is executed when the migration is applied.
+ALTER TABLE event ADD COLUMN rrule TEXT;
+ALTER TABLE event ADD COLUMN exdate TEXT;
+-- окончание последнего повторения, NULL для бесконечно повторяющихся событий
+ALTER TABLE event ADD COLUMN last_stop timestamptz;
+UPDATE event SET last_stop = stop;
+CREATE INDEX event_last_stop_idx ON event (last_stop);
+
+-- +goose Down
+-- SQL in this section is executed when the migration is rolled back.
+DROP INDEX event_last_stop_idx;
+ALTER TABLE event DROP COLUMN last_stop;
+ALTER TABLE event DROP COLUMN exdate;
+ALTER TABLE event DROP COLUMN rrule;
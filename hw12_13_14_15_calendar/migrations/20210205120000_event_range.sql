@@ -0,0 +1,8 @@
+-- +goose Up
+-- SQL in this section is executed when the migration is applied.
+-- выборка интервала событий пользователя с постраничной навигацией по (start, event_id)
+CREATE INDEX event_user_start_idx ON event (user_id, start, event_id);
+
+-- +goose Down
+-- SQL in this section is executed when the migration is rolled back.
+DROP INDEX event_user_start_idx;
@@ -0,0 +1,8 @@
+-- +goose Up
+-- SQL in this section is executed when the migration is applied.
+-- версия для оптимистичной блокировки, увеличивается при каждом изменении
+ALTER TABLE event ADD COLUMN version int NOT NULL DEFAULT 1;
+
+-- +goose Down
+-- SQL in this section is executed when the migration is rolled back.
+ALTER TABLE event DROP COLUMN version;
@@ -0,0 +1,8 @@
+-- +goose Up
+-- SQL in this section is executed when the migration is applied.
+-- событие на весь день: start и stop - полночь UTC первого дня и дня после последнего
+ALTER TABLE event ADD COLUMN all_day boolean NOT NULL DEFAULT false;
+
+-- +goose Down
+-- SQL in this section is executed when the migration is rolled back.
+ALTER TABLE event DROP COLUMN all_day;
@@ -0,0 +1,26 @@
+// Package migrations содержит SQL миграции в формате goose, встроенные в бинарник.
+package migrations
+
+import (
+	"embed"
+	"io/fs"
+)
+
+// FS - миграции для PostgreSQL.
+//
+//go:embed *.sql
+var FS embed.FS
+
+//go:embed sqlite/*.sql
+var sqliteFS embed.FS
+
+// SQLite - миграции для SQLite с теми же версиями и именами, что и в FS.
+var SQLite = mustSub(sqliteFS, "sqlite")
+
+func mustSub(fsys fs.FS, dir string) fs.FS {
+	sub, err := fs.Sub(fsys, dir)
+	if err != nil {
+		panic(err)
+	}
+	return sub
+}
@@ -0,0 +1,16 @@
+-- +goose Up
+-- SQL in this section is executed when the migration is applied.
+-- время хранится в микросекундах от начала эпохи, notification - в наносекундах
+CREATE TABLE IF NOT EXISTS event (
+    event_id INTEGER PRIMARY KEY AUTOINCREMENT,
+    title TEXT NOT NULL,
+    start INTEGER NOT NULL,
+    stop INTEGER NOT NULL,
+    description TEXT,
+    user_id INTEGER NOT NULL,
+    notification INTEGER
+);
+
+-- +goose Down
+-- SQL in this section is executed when the migration is rolled back.
+DROP TABLE event;
@@ -0,0 +1,12 @@
+-- +goose Up
+-- SQL in this section is executed when the migration is applied.
+-- настройки пользователей, строка появляется при первом сохранении
+CREATE TABLE IF NOT EXISTS user_settings (
+    user_id INTEGER PRIMARY KEY,
+    -- часовой пояс IANA, пустой - не задан
+    time_zone TEXT NOT NULL DEFAULT ''
+);
+
+-- +goose Down
+-- SQL in this section is executed when the migration is rolled back.
+DROP TABLE user_settings;
@@ -0,0 +1,8 @@
+-- +goose Up
+-- SQL in this section is executed when the migration is applied.
+-- часовой пояс, в котором раскрывается правило повторения, NULL для неповторяющихся событий
+ALTER TABLE event ADD COLUMN time_zone TEXT;
+
+-- +goose Down
+-- SQL in this section is executed when the migration is rolled back.
+ALTER TABLE event DROP COLUMN time_zone;